@@ -5,11 +5,14 @@ import (
 	"log"
 
 	_ "github.com/EtaPanel-dev/EtaPanel/core/cmd/api/docs"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/backup"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/middleware"
 
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/file"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/router"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/setup"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -63,6 +66,15 @@ import (
 // @tag.name DNS账号管理
 // @tag.description DNS服务商账号配置管理
 
+// @tag.name 数据库管理
+// @tag.description MySQL/PostgreSQL/Redis/SQLite 数据库连接与管理
+
+// @tag.name 用户管理
+// @tag.description 面板用户与角色权限管理
+
+// @tag.name 数据库备份
+// @tag.description 数据库定时备份、保留策略与恢复
+
 func main() {
 
 	// 初始化配置
@@ -74,6 +86,24 @@ func main() {
 		log.Fatalf("database connection error: %s", err)
 	}
 
+	// 检查是否需要首次初始化
+	if err := setup.Init(); err != nil {
+		log.Fatalf("Failed to check setup state: %v", err)
+	}
+
+	// 启动数据库定时备份
+	if err := backup.InitScheduler(); err != nil {
+		log.Fatalf("Failed to start backup scheduler: %v", err)
+	}
+
+	// 清理过期的分块上传和回收站文件
+	if err := file.InitUploads(); err != nil {
+		log.Fatalf("Failed to initialize uploads: %v", err)
+	}
+	if err := file.InitTrash(); err != nil {
+		log.Fatalf("Failed to initialize trash: %v", err)
+	}
+
 	// 初始化IPFS客户端
 	if config.AppConfig.IPFS.Enabled {
 		middleware.InitIPFS(config.AppConfig.IPFS.URL)
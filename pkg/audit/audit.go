@@ -0,0 +1,32 @@
+package audit
+
+import (
+	"log"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Log 写入一条审计日志，写入失败只记录到标准日志，不影响业务流程
+func Log(entry models.AuditLog) {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	if err := database.DbConn.Create(&entry).Error; err != nil {
+		log.Printf("写入审计日志失败: %v", err)
+	}
+}
+
+// Record 以当前登录用户和客户端IP写入审计日志
+func Record(c *gin.Context, action, target string, success bool, detail string) {
+	Log(models.AuditLog{
+		Username: c.GetString("username"),
+		Action:   action,
+		Target:   target,
+		ClientIP: c.ClientIP(),
+		Success:  success,
+		Detail:   detail,
+	})
+}
@@ -0,0 +1,305 @@
+package backup
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/dbmgr"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/safepath"
+	dbmgr2 "github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/dbmgr"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	scheduler *cron.Cron
+	entries   = make(map[uint]cron.EntryID)
+	entriesMu sync.Mutex
+	running   sync.Map // planID -> struct{}，防止同一计划并发执行
+)
+
+// 各引擎备份文件扩展名
+var fileExtensions = map[string]string{
+	dbmgr.EngineMySQL:      ".sql",
+	dbmgr.EnginePostgreSQL: ".sql",
+	dbmgr.EngineSQLite:     ".db",
+	dbmgr.EngineRedis:      ".jsonl",
+}
+
+// InitScheduler 启动备份调度器并加载所有已启用的备份计划
+func InitScheduler() error {
+	if err := failStaleRecords(); err != nil {
+		return err
+	}
+
+	scheduler = cron.New()
+
+	var plans []models.BackupPlan
+	if err := database.DbConn.Where("enabled = ?", true).Find(&plans).Error; err != nil {
+		return err
+	}
+
+	for _, plan := range plans {
+		if err := Schedule(plan); err != nil {
+			log.Printf("备份计划 %d 调度失败: %v", plan.ID, err)
+		}
+	}
+
+	scheduler.Start()
+	log.Printf("备份调度器已启动，已加载 %d 个计划", len(plans))
+	return nil
+}
+
+// failStaleRecords 将上次进程退出时仍处于执行中的备份记录标记为失败
+func failStaleRecords() error {
+	result := database.DbConn.Model(&models.BackupRecord{}).
+		Where("status = ?", models.BackupStatusRunning).
+		Updates(map[string]interface{}{
+			"status": models.BackupStatusFailed,
+			"error":  "backup interrupted by panel restart",
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("已将 %d 条中断的备份记录标记为失败", result.RowsAffected)
+	}
+	return nil
+}
+
+// ResolvePath 将路径限制在配置的备份根目录内，返回清理后的绝对路径
+func ResolvePath(path string) (string, error) {
+	return safepath.Resolve(config.AppConfig.Backup.Root, path)
+}
+
+// ValidateSpec 校验 cron 表达式
+func ValidateSpec(spec string) error {
+	_, err := cron.ParseStandard(spec)
+	return err
+}
+
+// Schedule 注册或更新备份计划，未启用的计划会被移除
+func Schedule(plan models.BackupPlan) error {
+	Unschedule(plan.ID)
+	if !plan.Enabled || scheduler == nil {
+		return nil
+	}
+
+	planID := plan.ID
+	id, err := scheduler.AddFunc(plan.CronSpec, func() {
+		if _, err := RunPlan(planID); err != nil {
+			log.Printf("备份计划 %d 执行失败: %v", planID, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	entriesMu.Lock()
+	entries[plan.ID] = id
+	entriesMu.Unlock()
+	return nil
+}
+
+// Unschedule 移除备份计划
+func Unschedule(planID uint) {
+	entriesMu.Lock()
+	defer entriesMu.Unlock()
+
+	if id, ok := entries[planID]; ok {
+		scheduler.Remove(id)
+		delete(entries, planID)
+	}
+}
+
+// NextRun 返回计划的下次执行时间，未调度时返回 nil
+func NextRun(planID uint) *time.Time {
+	entriesMu.Lock()
+	defer entriesMu.Unlock()
+
+	id, ok := entries[planID]
+	if !ok {
+		return nil
+	}
+	next := scheduler.Entry(id).Next
+	return &next
+}
+
+// RunPlan 立即执行备份计划并应用保留策略
+func RunPlan(planID uint) (models.BackupRecord, error) {
+	if _, busy := running.LoadOrStore(planID, struct{}{}); busy {
+		return models.BackupRecord{}, fmt.Errorf("backup plan %d is already running", planID)
+	}
+	defer running.Delete(planID)
+
+	var plan models.BackupPlan
+	if err := database.DbConn.First(&plan, planID).Error; err != nil {
+		return models.BackupRecord{}, err
+	}
+
+	var conn models.DatabaseConnection
+	if err := database.DbConn.First(&conn, plan.ConnectionID).Error; err != nil {
+		return models.BackupRecord{}, fmt.Errorf("connection %d not found: %w", plan.ConnectionID, err)
+	}
+
+	destDir, err := ResolvePath(plan.DestDir)
+	if err != nil {
+		return models.BackupRecord{}, err
+	}
+
+	record, err := Run(conn, plan.Database, destDir, plan.ID)
+
+	now := time.Now()
+	database.DbConn.Model(&plan).Updates(map[string]interface{}{
+		"last_run_at": &now,
+		"last_status": record.Status,
+	})
+
+	if err == nil {
+		applyRetention(plan)
+	}
+	return record, err
+}
+
+// Run 执行一次备份，并将执行结果写入备份记录；任何提前返回或 panic 都会把记录标记为失败
+func Run(conn models.DatabaseConnection, db, destDir string, planID uint) (record models.BackupRecord, err error) {
+	if db == "" {
+		db = conn.Database
+	}
+
+	record = models.BackupRecord{
+		PlanID:       planID,
+		ConnectionID: conn.ID,
+		Engine:       conn.Engine,
+		Database:     db,
+		Status:       models.BackupStatusRunning,
+		StartedAt:    time.Now(),
+	}
+	record.FilePath = filepath.Join(destDir, backupFileName(conn, db, record.StartedAt))
+	if err := database.DbConn.Create(&record).Error; err != nil {
+		return record, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("backup panicked: %v", r)
+		}
+
+		record.DurationMs = time.Since(record.StartedAt).Milliseconds()
+		if err != nil {
+			record.Status = models.BackupStatusFailed
+			record.Error = err.Error()
+			_ = os.Remove(record.FilePath)
+		} else {
+			record.Status = models.BackupStatusSuccess
+			if stat, statErr := os.Stat(record.FilePath); statErr == nil {
+				record.Size = stat.Size()
+			}
+		}
+
+		if saveErr := database.DbConn.Save(&record).Error; saveErr != nil {
+			log.Printf("保存备份记录失败: %v", saveErr)
+		}
+	}()
+
+	if err := os.MkdirAll(destDir, 0700); err != nil {
+		return record, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	return record, backup(conn, db, record.FilePath)
+}
+
+// Restore 将备份记录对应的文件恢复到数据库，db 为空时恢复到备份来源数据库
+func Restore(record models.BackupRecord, db string) error {
+	if record.Status != models.BackupStatusSuccess {
+		return fmt.Errorf("backup record %d is not restorable (status: %s)", record.ID, record.Status)
+	}
+	if _, err := os.Stat(record.FilePath); err != nil {
+		return fmt.Errorf("backup file unavailable: %w", err)
+	}
+
+	var conn models.DatabaseConnection
+	if err := database.DbConn.First(&conn, record.ConnectionID).Error; err != nil {
+		return fmt.Errorf("connection %d not found: %w", record.ConnectionID, err)
+	}
+	if conn.Engine != record.Engine {
+		return fmt.Errorf("connection engine changed from %s to %s", record.Engine, conn.Engine)
+	}
+
+	if db == "" {
+		db = record.Database
+	}
+
+	mgr, err := dbmgr2.OpenManager(conn, db)
+	if err != nil {
+		return err
+	}
+	defer mgr.Disconnect()
+
+	restorer, ok := mgr.(dbmgr.Restorer)
+	if !ok {
+		return fmt.Errorf("%s does not support restore", conn.Engine)
+	}
+	return restorer.Restore(db, record.FilePath)
+}
+
+// backup 连接数据库并写入备份文件
+func backup(conn models.DatabaseConnection, db, outputPath string) error {
+	mgr, err := dbmgr2.OpenManager(conn, db)
+	if err != nil {
+		return err
+	}
+	defer mgr.Disconnect()
+
+	backupper, ok := mgr.(dbmgr.Backupper)
+	if !ok {
+		return fmt.Errorf("%s does not support backup", conn.Engine)
+	}
+	return backupper.Backup(db, outputPath)
+}
+
+// applyRetention 按保留份数和保留天数清理过期备份
+func applyRetention(plan models.BackupPlan) {
+	if plan.KeepCount <= 0 && plan.KeepDays <= 0 {
+		return
+	}
+
+	var records []models.BackupRecord
+	if err := database.DbConn.
+		Where("plan_id = ? AND status = ?", plan.ID, models.BackupStatusSuccess).
+		Order("started_at DESC").
+		Find(&records).Error; err != nil {
+		log.Printf("读取备份记录失败: %v", err)
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -plan.KeepDays)
+	for i, record := range records {
+		expired := plan.KeepCount > 0 && i >= plan.KeepCount
+		expired = expired || plan.KeepDays > 0 && record.StartedAt.Before(cutoff)
+		if !expired {
+			continue
+		}
+
+		if err := os.Remove(record.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("删除过期备份文件失败 %s: %v", record.FilePath, err)
+			continue
+		}
+		database.DbConn.Delete(&record)
+		log.Printf("已清理过期备份: %s", record.FilePath)
+	}
+}
+
+// backupFileName 生成备份文件名: 引擎_连接ID_数据库_时间.扩展名
+func backupFileName(conn models.DatabaseConnection, db string, at time.Time) string {
+	name := fmt.Sprintf("%d", conn.ID)
+	if db != "" {
+		name += "_" + db
+	}
+	return fmt.Sprintf("%s_%s_%s%s", conn.Engine, name, at.Format("20060102-150405"), fileExtensions[conn.Engine])
+}
@@ -0,0 +1,185 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/robfig/cron/v3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupBackupDB(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	config.AppConfig = &config.Config{Backup: config.BackupConfig{Root: root}}
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.BackupPlan{}, &models.BackupRecord{}, &models.DatabaseConnection{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	database.DbConn = db
+	return root
+}
+
+// createRecord 写入一条成功的备份记录及其备份文件
+func createRecord(t *testing.T, dir string, planID uint, startedAt time.Time) models.BackupRecord {
+	t.Helper()
+
+	path := filepath.Join(dir, startedAt.Format("20060102-150405")+".db")
+	if err := os.WriteFile(path, []byte("backup"), 0600); err != nil {
+		t.Fatalf("write backup file: %v", err)
+	}
+	record := models.BackupRecord{
+		PlanID:    planID,
+		FilePath:  path,
+		Status:    models.BackupStatusSuccess,
+		StartedAt: startedAt,
+	}
+	if err := database.DbConn.Create(&record).Error; err != nil {
+		t.Fatalf("create record: %v", err)
+	}
+	return record
+}
+
+func remainingRecords(t *testing.T, planID uint) []models.BackupRecord {
+	t.Helper()
+	var records []models.BackupRecord
+	if err := database.DbConn.Where("plan_id = ?", planID).Order("started_at DESC").Find(&records).Error; err != nil {
+		t.Fatalf("list records: %v", err)
+	}
+	return records
+}
+
+func TestApplyRetentionKeepCount(t *testing.T) {
+	root := setupBackupDB(t)
+
+	now := time.Now()
+	var records []models.BackupRecord
+	for i := 0; i < 4; i++ {
+		records = append(records, createRecord(t, root, 1, now.Add(-time.Duration(i)*time.Hour)))
+	}
+	other := createRecord(t, root, 2, now.Add(-48*time.Hour))
+
+	applyRetention(models.BackupPlan{Model: gorm.Model{ID: 1}, KeepCount: 2})
+
+	left := remainingRecords(t, 1)
+	if len(left) != 2 || left[0].ID != records[0].ID || left[1].ID != records[1].ID {
+		t.Fatalf("expected the 2 newest records to remain, got %+v", left)
+	}
+	for _, record := range records[2:] {
+		if _, err := os.Stat(record.FilePath); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", record.FilePath)
+		}
+	}
+	if len(remainingRecords(t, 2)) != 1 {
+		t.Errorf("retention must not touch other plans")
+	}
+	if _, err := os.Stat(other.FilePath); err != nil {
+		t.Errorf("other plan's file removed: %v", err)
+	}
+}
+
+func TestApplyRetentionKeepDays(t *testing.T) {
+	root := setupBackupDB(t)
+
+	now := time.Now()
+	fresh := createRecord(t, root, 1, now.Add(-time.Hour))
+	createRecord(t, root, 1, now.AddDate(0, 0, -10))
+
+	applyRetention(models.BackupPlan{Model: gorm.Model{ID: 1}, KeepDays: 7})
+
+	left := remainingRecords(t, 1)
+	if len(left) != 1 || left[0].ID != fresh.ID {
+		t.Fatalf("expected only the fresh record to remain, got %+v", left)
+	}
+}
+
+func TestScheduleRegistersEnabledPlans(t *testing.T) {
+	setupBackupDB(t)
+	scheduler = cron.New()
+
+	plan := models.BackupPlan{Model: gorm.Model{ID: 7}, CronSpec: "0 3 * * *", Enabled: true}
+	if err := Schedule(plan); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	t.Cleanup(func() { Unschedule(plan.ID) })
+
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	next := NextRun(plan.ID)
+	if next == nil || next.Hour() != 3 || next.Minute() != 0 || !next.After(time.Now()) {
+		t.Fatalf("unexpected next run: %v", next)
+	}
+
+	plan.Enabled = false
+	if err := Schedule(plan); err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+	if NextRun(plan.ID) != nil {
+		t.Error("disabled plan should be unscheduled")
+	}
+
+	plan.Enabled, plan.CronSpec = true, "not a cron spec"
+	if err := Schedule(plan); err == nil {
+		t.Error("expected invalid cron spec to be rejected")
+	}
+}
+
+func TestRunMarksRecordFailed(t *testing.T) {
+	root := setupBackupDB(t)
+
+	conn := models.DatabaseConnection{Name: "broken", Engine: "unknown"}
+	database.DbConn.Create(&conn)
+
+	record, err := Run(conn, "", filepath.Join(root, "daily"), 0)
+	if err == nil {
+		t.Fatal("expected backup with an unknown engine to fail")
+	}
+
+	var saved models.BackupRecord
+	database.DbConn.First(&saved, record.ID)
+	if saved.Status != models.BackupStatusFailed || saved.Error == "" {
+		t.Fatalf("expected failed record with error, got %+v", saved)
+	}
+}
+
+func TestFailStaleRecords(t *testing.T) {
+	setupBackupDB(t)
+
+	stale := models.BackupRecord{Status: models.BackupStatusRunning, StartedAt: time.Now()}
+	database.DbConn.Create(&stale)
+
+	if err := failStaleRecords(); err != nil {
+		t.Fatalf("fail stale records: %v", err)
+	}
+
+	var saved models.BackupRecord
+	database.DbConn.First(&saved, stale.ID)
+	if saved.Status != models.BackupStatusFailed {
+		t.Fatalf("expected stale record to be failed, got %s", saved.Status)
+	}
+}
+
+func TestResolvePathConfinedToRoot(t *testing.T) {
+	root := setupBackupDB(t)
+
+	if path, err := ResolvePath("daily"); err != nil || path != filepath.Join(root, "daily") {
+		t.Errorf("relative path: got %q, %v", path, err)
+	}
+	for _, path := range []string{"/etc", "../escape", filepath.Join(root, "..", "escape")} {
+		if _, err := ResolvePath(path); err == nil {
+			t.Errorf("expected %q to be rejected", path)
+		}
+	}
+}
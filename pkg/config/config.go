@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/pelletier/go-toml/v2"
 )
@@ -14,6 +15,11 @@ type Config struct {
 	IPFS         IPFSConfig      `toml:"ipfs"`
 	Injective    InjectiveConfig `json:"injective" toml:"injective"` // Injective链配置
 	DockerConfig DockerConfig    `json:"docker" toml:"docker"`       // Docker配置
+	Security     SecurityConfig  `json:"security" toml:"security"`   // 登录安全配置
+	AI           AIConfig        `json:"ai" toml:"ai"`               // AI服务配置
+	Backup       BackupConfig    `json:"backup" toml:"backup"`       // 数据库备份配置
+	Query        QueryConfig     `json:"query" toml:"query"`         // 数据库查询限制
+	Files        FilesConfig     `json:"files" toml:"files"`         // 文件管理路径策略
 }
 
 type ServerConfig struct {
@@ -29,6 +35,153 @@ type JWTConfig struct {
 	Secret string `toml:"secret"`
 }
 
+// SecurityConfig 登录防爆破配置
+type SecurityConfig struct {
+	MaxLoginFailures   int `toml:"max_login_failures"`   // 同一用户名连续失败N次后锁定
+	LockoutMinutes     int `toml:"lockout_minutes"`      // 锁定时长，同时作为失败计数的过期窗口
+	BackoffBaseSeconds int `toml:"backoff_base_seconds"` // 失败后的初始等待时间，之后每次失败翻倍
+	BackoffMaxSeconds  int `toml:"backoff_max_seconds"`  // 等待时间上限
+}
+
+// applyDefaults 为旧配置文件中缺失的字段填充默认值
+func (s *SecurityConfig) applyDefaults() {
+	if s.MaxLoginFailures <= 0 {
+		s.MaxLoginFailures = 5
+	}
+	if s.LockoutMinutes <= 0 {
+		s.LockoutMinutes = 15
+	}
+	if s.BackoffBaseSeconds <= 0 {
+		s.BackoffBaseSeconds = 1
+	}
+	if s.BackoffMaxSeconds <= 0 {
+		s.BackoffMaxSeconds = 300
+	}
+}
+
+// BackupConfig 数据库备份配置
+type BackupConfig struct {
+	Root string `toml:"root"` // 备份文件根目录，备份计划目录和手动备份路径都必须位于其中
+}
+
+// applyDefaults 为旧配置文件中缺失的字段填充默认值
+func (b *BackupConfig) applyDefaults() {
+	if b.Root == "" {
+		b.Root = "/var/backups/etapanel"
+	}
+}
+
+// QueryConfig 查询控制台和 AI 只读查询的限制
+type QueryConfig struct {
+	MaxRows        int `toml:"max_rows"`        // 单次查询最多返回的行数，超出部分截断
+	TimeoutSeconds int `toml:"timeout_seconds"` // 单条查询的执行超时
+	// SQLiteDirs 已保存的 SQLite 连接可以使用的数据库目录，为空时不允许保存 SQLite 连接，面板自身数据库始终拒绝
+	SQLiteDirs []string `toml:"sqlite_dirs"`
+}
+
+// applyDefaults 为旧配置文件中缺失的字段填充默认值
+func (q *QueryConfig) applyDefaults() {
+	if q.MaxRows <= 0 {
+		q.MaxRows = 1000
+	}
+	if q.TimeoutSeconds <= 0 {
+		q.TimeoutSeconds = 30
+	}
+}
+
+// FilesConfig 文件管理的路径策略，受保护的系统文件（models.ProtectedDirs）、面板的配置文件和数据库始终拒绝访问。
+// 除管理员外的角色还看不到 Backup.Root 和回收站目录，按隐藏的路径处理，角色策略无法放开
+type FilesConfig struct {
+	Default FilePolicy `toml:"default"`
+	// Roles 按角色覆盖的策略，角色策略中未设置的字段沿用默认策略
+	Roles map[string]FilePolicy `toml:"roles"`
+	// 分块上传：未完成的文件保存在临时目录中，超过过期时间没有新的分块时清理
+	UploadDir         string `toml:"upload_dir"`          // 未完成上传的临时目录
+	UploadExpireHours int    `toml:"upload_expire_hours"` // 未完成的上传保留时长
+	UploadMaxChunkMB  int    `toml:"upload_max_chunk_mb"` // 单个分块的最大大小
+	// 回收站：删除的文件移动到回收站目录，超过保留天数后自动清除
+	TrashDir           string `toml:"trash_dir"`            // 回收站目录，文件管理中不可直接访问
+	TrashRetentionDays int    `toml:"trash_retention_days"` // 回收站中文件的保留天数
+}
+
+// applyDefaults 为旧配置文件中缺失的字段填充默认值
+func (f *FilesConfig) applyDefaults() {
+	if f.UploadDir == "" {
+		f.UploadDir = filepath.Join(os.TempDir(), "etapanel-uploads")
+	}
+	if f.UploadExpireHours <= 0 {
+		f.UploadExpireHours = 24
+	}
+	if f.UploadMaxChunkMB <= 0 {
+		f.UploadMaxChunkMB = 64
+	}
+	if f.TrashDir == "" {
+		f.TrashDir = "/var/lib/etapanel/trash"
+	}
+	if f.TrashRetentionDays <= 0 {
+		f.TrashRetentionDays = 30
+	}
+}
+
+// FilePolicy 文件管理的路径策略
+type FilePolicy struct {
+	AllowedRoots  []string `toml:"allowed_roots"`   // 允许访问的目录，为空时不限制
+	ReadOnlyRoots []string `toml:"read_only_roots"` // 只读的目录，可以浏览和下载，不能修改
+	// Hidden 隐藏的路径模式，不在列表中显示也不能访问：不含 / 时匹配任意一级的名称（如 .env、*.pem），含 / 时匹配绝对路径及其下的内容
+	Hidden []string `toml:"hidden"`
+}
+
+// PolicyFor 返回角色的文件管理策略
+func (f FilesConfig) PolicyFor(role string) FilePolicy {
+	policy := f.Default
+	override, ok := f.Roles[role]
+	if !ok {
+		return policy
+	}
+	if override.AllowedRoots != nil {
+		policy.AllowedRoots = override.AllowedRoots
+	}
+	if override.ReadOnlyRoots != nil {
+		policy.ReadOnlyRoots = override.ReadOnlyRoots
+	}
+	if override.Hidden != nil {
+		policy.Hidden = override.Hidden
+	}
+	return policy
+}
+
+// AIConfig AI服务配置，支持任意 OpenAI 兼容接口
+type AIConfig struct {
+	Provider       string `toml:"provider"`        // openai, moonshot, deepseek, ollama 或 custom
+	BaseURL        string `toml:"base_url"`        // 为空时使用 provider 的默认地址，custom 时必填
+	APIKey         string `toml:"api_key"`         // 本地服务（如 ollama）可留空
+	Model          string `toml:"model"`           // 模型名称
+	TimeoutSeconds int    `toml:"timeout_seconds"` // 单次请求超时
+	// SQLiteDirs AI 工具可直接打开的 SQLite 数据库目录，其余路径只能通过已保存的连接访问，面板自身数据库始终拒绝
+	SQLiteDirs []string `toml:"sqlite_dirs"`
+	// 服务器日志分析：先将相似的行聚合，再分块逐一总结后合并
+	LogMaxMB       int `toml:"log_max_mb"`      // 单次分析最多读取的日志大小，文件从末尾向前截取
+	LogChunkChars  int `toml:"log_chunk_chars"` // 每个分块提交给 AI 的最大字符数
+	LogMaxChunks   int `toml:"log_max_chunks"`  // 最多提交的分块数，超出的低优先级内容不分析
+	LogConcurrency int `toml:"log_concurrency"` // 同时总结的分块数
+}
+
+// applyDefaults 为旧配置文件中缺失的字段填充默认值
+func (a *AIConfig) applyDefaults() {
+	if a.LogMaxMB <= 0 {
+		a.LogMaxMB = 512
+	}
+	if a.LogChunkChars <= 0 {
+		a.LogChunkChars = 12000
+	}
+	if a.LogMaxChunks <= 0 {
+		a.LogMaxChunks = 8
+	}
+	if a.LogConcurrency <= 0 {
+		a.LogConcurrency = 3
+	}
+}
+
 type IPFSConfig struct {
 	URL     string `toml:"url"`
 	Enabled bool   `toml:"enabled"`
@@ -64,14 +217,27 @@ type DockerRegistry struct {
 // AppConfig 全局应用配置
 var AppConfig *Config
 
+// configPath 配置文件路径
+const configPath = "config.toml"
+
+// Path 返回配置文件的绝对路径
+func Path() string {
+	if abs, err := filepath.Abs(configPath); err == nil {
+		return abs
+	}
+	return configPath
+}
+
+// DefaultJWTSecret 默认配置中的占位密钥，首次初始化时会被替换为随机密钥
+const DefaultJWTSecret = "your_jwt_secret"
+
 func Init() error {
-	if _, err := os.Stat("config.toml"); os.IsNotExist(err) {
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		createDefaultConfig()
-		log.Println("Created default config.toml file. Please modify it and restart the application.")
-		os.Exit(0)
+		log.Println("Created default config.toml file, the panel will start in setup mode.")
 	}
 
-	data, err := os.ReadFile("config.toml")
+	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return err
 	}
@@ -82,10 +248,27 @@ func Init() error {
 		return err
 	}
 
+	cfg.Security.applyDefaults()
+	cfg.Backup.applyDefaults()
+	cfg.Query.applyDefaults()
+	cfg.AI.applyDefaults()
+	cfg.Files.applyDefaults()
+
 	AppConfig = &cfg
 	return nil
 }
 
+// Save 将当前配置写回配置文件
+func Save() error {
+	data, err := toml.Marshal(AppConfig)
+	if err != nil {
+		return err
+	}
+
+	// 配置中包含密钥，仅允许属主读写
+	return os.WriteFile(configPath, data, 0600)
+}
+
 func createDefaultConfig() {
 	defaultConfig := Config{
 		Server: ServerConfig{
@@ -96,7 +279,7 @@ func createDefaultConfig() {
 			Path: "data.db",
 		},
 		JWT: JWTConfig{
-			Secret: "your_jwt_secret",
+			Secret: DefaultJWTSecret,
 		},
 		IPFS: IPFSConfig{
 			URL:     "http://localhost:5001",
@@ -132,16 +315,24 @@ func createDefaultConfig() {
 			DefaultRegistry: "https://registry-1.docker.io",
 		},
 	}
+	defaultConfig.Security.applyDefaults()
+	defaultConfig.Backup.applyDefaults()
+	defaultConfig.Query.applyDefaults()
+	defaultConfig.Files.applyDefaults()
+	defaultConfig.AI = AIConfig{
+		Provider:       "moonshot",
+		Model:          "kimi-k2-0711-preview",
+		TimeoutSeconds: 60,
+	}
+	defaultConfig.AI.applyDefaults()
 
 	data, err := toml.Marshal(defaultConfig)
 	if err != nil {
 		log.Fatalf("Failed to marshal default config: %v", err)
 	}
 
-	err = os.WriteFile("config.toml", data, 0644)
+	err = os.WriteFile(configPath, data, 0600)
 	if err != nil {
 		log.Fatalf("Failed to write default config file: %v", err)
 	}
-
-	log.Println("Default config.toml file created successfully, please modify it and restart the application.")
 }
@@ -1,7 +1,6 @@
 package database
 
 import (
-	"golang.org/x/crypto/bcrypt"
 	"log"
 
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
@@ -28,11 +27,41 @@ func (d *Database) Connect() error {
 		return err
 	}
 
+	// 旧版 auth_tokens 表的 ua 列带唯一约束且从未写入过数据，直接重建
+	if DbC.Migrator().HasColumn(&models.AuthToken{}, "ua") {
+		if err := DbC.Migrator().DropTable(&models.AuthToken{}); err != nil {
+			return err
+		}
+	}
+
+	// 旧版 login_attempts 表使用保留字 key 作为列名，其中只有短期失败计数，直接重建
+	if DbC.Migrator().HasColumn(&models.LoginAttempt{}, "key") {
+		if err := DbC.Migrator().DropTable(&models.LoginAttempt{}); err != nil {
+			return err
+		}
+	}
+
+	// 角色字段加入前创建的用户都是面板管理员，迁移后需显式授予管理员角色
+	legacyUsers := DbC.Migrator().HasTable(&models.User{}) && !DbC.Migrator().HasColumn(&models.User{}, "role")
+
 	// 自动迁移数据库表
 	err = DbC.AutoMigrate(
 		&models.User{},
+		&models.RecoveryCode{},
 		&models.Server{},
 		&models.AuthToken{},
+		&models.AuditLog{},
+		&models.LoginAttempt{},
+		&models.PanelSettings{},
+		&models.DatabaseConnection{},
+		&models.AIToolApproval{},
+		&models.AIConversation{},
+		&models.AIMessage{},
+		&models.AIUsage{},
+		&models.AIQuota{},
+		&models.BackupPlan{},
+		&models.BackupRecord{},
+		&models.TrashItem{},
 		&ssl.Ssl{},
 		&ssl.AcmeClient{},
 		&ssl.WebsiteAcmeAccount{},
@@ -43,12 +72,16 @@ func (d *Database) Connect() error {
 		return err
 	}
 
+	if legacyUsers {
+		if err := DbC.Model(&models.User{}).Where("1 = 1").Update("role", models.RoleAdmin).Error; err != nil {
+			return err
+		}
+		log.Println("已为角色功能上线前创建的用户授予管理员角色")
+	}
+
 	d.DbConn = DbC
 	DbConn = DbC
 
-	// 创建默认管理员用户
-	d.createDefaultUser()
-
 	return nil
 }
 
@@ -59,32 +92,6 @@ func (d *Database) GetDb() *Database {
 	return d
 }
 
-// createDefaultUser 创建默认管理员用户
-func (d *Database) createDefaultUser() {
-	var count int64
-	d.DbConn.Model(&models.User{}).Count(&count)
-
-	// 如果没有用户，创建默认管理员
-	if count == 0 {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte("Abc123456"), bcrypt.DefaultCost)
-		if err != nil {
-			log.Println("初始化用户失败，密码处理错误")
-			return
-		}
-		defaultUser := models.User{
-			Username: "demo",
-			Password: string(hashedPassword),
-		}
-
-		if err := d.DbConn.Create(&defaultUser).Error; err != nil {
-			log.Printf("创建默认用户失败: %v", err)
-		} else {
-			log.Println("已创建默认管理员用户 - 用户名: demo, 密码: Abc123456")
-			log.Println("请尽快登录并修改默认密码！")
-		}
-	}
-}
-
 type Database struct {
 	DbConn *gorm.DB
 }
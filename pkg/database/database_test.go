@@ -0,0 +1,61 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestConnectMigratesUserRoles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.db")
+	config.AppConfig = &config.Config{Database: config.DatabaseConfig{Path: path}}
+
+	// 角色功能上线前的用户表
+	legacy, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := legacy.Exec(`CREATE TABLE users (id integer PRIMARY KEY AUTOINCREMENT, created_at datetime, updated_at datetime, deleted_at datetime, username text NOT NULL UNIQUE, password text NOT NULL)`).Error; err != nil {
+		t.Fatalf("create legacy table: %v", err)
+	}
+	if err := legacy.Exec(`INSERT INTO users (username, password) VALUES ('owner', 'hash')`).Error; err != nil {
+		t.Fatalf("insert legacy user: %v", err)
+	}
+	sqlDB, _ := legacy.DB()
+	sqlDB.Close()
+
+	if err := InitDb().Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	var owner models.User
+	DbConn.Where("username = ?", "owner").First(&owner)
+	if owner.Role != models.RoleAdmin {
+		t.Errorf("legacy user: expected role %q, got %q", models.RoleAdmin, owner.Role)
+	}
+
+	// 新建用户未指定角色时为最低权限
+	if err := DbConn.Exec(`INSERT INTO users (username, password) VALUES ('newcomer', 'hash')`).Error; err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	var newcomer models.User
+	DbConn.Where("username = ?", "newcomer").First(&newcomer)
+	if newcomer.Role != models.RoleReadOnly {
+		t.Errorf("new user: expected role %q, got %q", models.RoleReadOnly, newcomer.Role)
+	}
+
+	// 再次连接不会重复授予管理员
+	sqlDB, _ = DbConn.DB()
+	sqlDB.Close()
+	if err := InitDb().Connect(); err != nil {
+		t.Fatalf("reconnect: %v", err)
+	}
+	DbConn.Where("username = ?", "newcomer").First(&newcomer)
+	if newcomer.Role != models.RoleReadOnly {
+		t.Errorf("reconnect: expected role %q, got %q", models.RoleReadOnly, newcomer.Role)
+	}
+}
@@ -0,0 +1,279 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/sashabaranov/go-openai"
+)
+
+// ErrNotConfigured AI服务未配置
+var ErrNotConfigured = errors.New("AI provider is not configured, please set the [ai] section in config.toml")
+
+// defaultTimeout 未配置超时时的默认值
+const defaultTimeout = 60 * time.Second
+
+// providerBaseURLs 内置服务商的默认接口地址
+var providerBaseURLs = map[string]string{
+	"openai":   "https://api.openai.com/v1",
+	"moonshot": "https://api.moonshot.cn/v1",
+	"deepseek": "https://api.deepseek.com/v1",
+	"ollama":   "http://localhost:11434/v1",
+}
+
+// Meter 在每次请求前检查是否允许调用（如配额），请求完成后记录用量
+type Meter interface {
+	Allow(ctx context.Context) error
+	// Record 记录一次请求的用量，estimated 表示服务商未返回用量、由内容长度估算
+	Record(model string, usage openai.Usage, estimated bool)
+}
+
+// Client OpenAI 兼容接口客户端
+type Client struct {
+	client  *openai.Client
+	model   string
+	timeout time.Duration
+	meter   Meter
+}
+
+// NewClient 根据配置创建客户端
+func NewClient(cfg config.AIConfig) (*Client, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.Provider))
+
+	baseURL := strings.TrimSpace(cfg.BaseURL)
+	if baseURL == "" {
+		baseURL = providerBaseURLs[provider]
+	}
+	if baseURL == "" || cfg.Model == "" {
+		return nil, ErrNotConfigured
+	}
+	// 本地服务通常不校验密钥，其余服务商必须配置
+	if cfg.APIKey == "" && provider != "ollama" && provider != "custom" {
+		return nil, ErrNotConfigured
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	clientConfig := openai.DefaultConfig(cfg.APIKey)
+	clientConfig.BaseURL = strings.TrimRight(baseURL, "/")
+	clientConfig.HTTPClient = &http.Client{Timeout: timeout}
+
+	return &Client{
+		client:  openai.NewClientWithConfig(clientConfig),
+		model:   cfg.Model,
+		timeout: timeout,
+	}, nil
+}
+
+// DefaultClient 使用全局配置创建客户端
+func DefaultClient() (*Client, error) {
+	return NewClient(config.AppConfig.AI)
+}
+
+// WithMeter 返回使用 meter 计量的客户端副本
+func (c *Client) WithMeter(meter Meter) *Client {
+	metered := *c
+	metered.meter = meter
+	return &metered
+}
+
+// Model 返回当前使用的模型名称
+func (c *Client) Model() string {
+	return c.model
+}
+
+// CreateChatCompletion 发送对话请求，未指定模型时使用配置中的模型
+func (c *Client) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	if request.Model == "" {
+		request.Model = c.model
+	}
+	if c.meter != nil {
+		if err := c.meter.Allow(ctx); err != nil {
+			return openai.ChatCompletionResponse{}, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return resp, fmt.Errorf("AI request failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return resp, errors.New("AI response contains no choices")
+	}
+	if c.meter != nil {
+		c.record(request, resp)
+	}
+	return resp, nil
+}
+
+// record 记录一次非流式请求的用量，服务商未返回用量时按内容估算
+func (c *Client) record(request openai.ChatCompletionRequest, resp openai.ChatCompletionResponse) {
+	if resp.Usage.TotalTokens > 0 {
+		c.meter.Record(request.Model, resp.Usage, false)
+		return
+	}
+
+	var prompt strings.Builder
+	for _, message := range request.Messages {
+		prompt.WriteString(message.Content)
+	}
+	c.meter.Record(request.Model, EstimateUsage(prompt.String(), resp.Choices[0].Message.Content), true)
+}
+
+// Chat 发送单轮对话，返回模型回复内容
+func (c *Client) Chat(ctx context.Context, prompt string, content string) (string, error) {
+	resp, err := c.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt + content,
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// maxRepairAttempts 回复不符合 Schema 时要求模型修正的次数
+const maxRepairAttempts = 1
+
+// ChatJSON 发送单轮对话，将回复按 task 的 Schema 校验后解析到 out；回复不符合时把错误和 Schema 发回模型要求修正，
+// 修正后仍不符合时返回 ErrInvalidOutput
+func (c *Client) ChatJSON(ctx context.Context, task Task, content string, out interface{}) error {
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: task.Prompt + content,
+		},
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.CreateChatCompletion(ctx, openai.ChatCompletionRequest{Messages: messages})
+		if err != nil {
+			return err
+		}
+		reply := resp.Choices[0].Message.Content
+
+		parseErr := ParseOutput(task.Schema, reply, out)
+		if parseErr == nil {
+			return nil
+		}
+		if attempt >= maxRepairAttempts {
+			return fmt.Errorf("%w: %v", ErrInvalidOutput, parseErr)
+		}
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: reply},
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: fmt.Sprintf(RepairPrompt, parseErr, task.Schema)},
+		)
+	}
+}
+
+// ChatStream 以流式方式发送单轮对话，每收到一段内容即调用 onDelta，onDelta 返回错误时中止；
+// ctx 取消时停止接收，返回已收到的完整回复
+func (c *Client) ChatStream(ctx context.Context, prompt string, content string, onDelta func(delta string) error) (string, error) {
+	if c.meter != nil {
+		if err := c.meter.Allow(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	stream, err := c.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+		Model:         c.model,
+		Stream:        true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt + content,
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("AI request failed: %w", err)
+	}
+	defer stream.Close()
+
+	var reply strings.Builder
+	var usage *openai.Usage
+	// 中途取消或失败时服务商不会返回用量，按已发送和已收到的内容估算
+	defer func() {
+		if c.meter == nil {
+			return
+		}
+		if usage != nil {
+			c.meter.Record(c.model, *usage, false)
+			return
+		}
+		c.meter.Record(c.model, EstimateUsage(prompt+content, reply.String()), true)
+	}()
+
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return reply.String(), nil
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return reply.String(), ctxErr
+			}
+			return reply.String(), fmt.Errorf("AI stream failed: %w", err)
+		}
+		if resp.Usage != nil {
+			usage = resp.Usage
+		}
+		if len(resp.Choices) == 0 || resp.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		delta := resp.Choices[0].Delta.Content
+		reply.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return reply.String(), err
+		}
+	}
+}
+
+// EstimateUsage 按字符数粗略估算用量：ASCII 约 4 个字符一个 token，其余字符（如中文）约一个字符一个 token
+func EstimateUsage(prompt, completion string) openai.Usage {
+	estimate := func(text string) int {
+		ascii, other := 0, 0
+		for _, r := range text {
+			if r < 0x80 {
+				ascii++
+			} else {
+				other++
+			}
+		}
+		return (ascii+3)/4 + other
+	}
+
+	usage := openai.Usage{PromptTokens: estimate(prompt), CompletionTokens: estimate(completion)}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return usage
+}
+
+// Chat 使用全局配置发送单轮对话
+func Chat(ctx context.Context, prompt string, content string) (string, error) {
+	client, err := DefaultClient()
+	if err != nil {
+		return "", err
+	}
+	return client.Chat(ctx, prompt, content)
+}
@@ -1,5 +1,11 @@
 package ai
 
 const DirCleanPrompt = "我接下来会给你一段内容，内容是一些目录结构和文件，请分析这些内容，并给出一个清理目录的建议。请注意，目录结构可能包含一些不必要的文件或目录，你需要判断哪些是可以删除的。请给出清理建议，格式如下：1. 删除文件/目录：<文件或目录名>，2. 保留文件/目录：<文件或目录名>，请确保你的建议是合理的，并且不会删除任何重要的文件或目录。删除的文件以JSON列表给出并使用绝对路径，不要包含 Markdown 结构，也不要包含其他内容，只需要 JSON 列表，内容是："
-const LogAnalyzer = "我接下来会给你一段内容，内容是一些日志数据，请分析这些内容，并给出一个日志分析的建议。请注意，日志数据可能包含一些错误或异常信息，你需要判断哪些是需要关注的。请给出分析建议，格式如下：1. 错误信息：<错误信息>，2. 异常信息：<异常信息>，请确保你的建议是合理的，并且不会遗漏任何重要的信息，发现的问题存储在字段 problem，解决方式存储在字段 solution，例如: [{'problem':'弱密码','solution':'修改密码'}]，不要包含 Markdown 结构，也不要包含其他内容，只需要返回 JSON 列表。内容是："
+const LogAnalyzer = "我接下来会给你一段内容，内容是一些日志数据，请分析这些内容，并给出一个日志分析的建议。请注意，日志数据可能包含一些错误或异常信息，你需要判断哪些是需要关注的。请给出分析建议，格式如下：1. 错误信息：<错误信息>，2. 异常信息：<异常信息>，请确保你的建议是合理的，并且不会遗漏任何重要的信息，发现的问题存储在字段 problem，解决方式存储在字段 solution，例如: [{\"problem\":\"弱密码\",\"solution\":\"修改密码\"}]，不要包含 Markdown 结构，也不要包含其他内容，只需要返回 JSON 列表。内容是："
 const normalChat = "我接下来会给你一段内容，内容是用户给你的一段话，请针对这句话以及之前的上下文进行回复。请注意，用户可能会问一些问题或者表达一些情感，你需要根据上下文进行合理的回复。请给出回复内容，格式如下：回复内容：<回复内容>，请确保你的回复是合理的，并且能够满足用户的需求。不要包含 Markdown 结构，也不要包含其他内容，只需要返回回复内容的JSON，JSON 的 key 为 response。内容是："
+const DatabaseAgentPrompt = "你是 EtaPanel 的数据库管理助手，可以通过提供的工具连接数据库、查看表结构、执行查询和维护操作。面板中已保存的 MySQL、PostgreSQL、Redis 连接请先用 list_database_connections 获取连接ID，再通过连接ID调用相应工具，同一次对话可以同时操作多个连接。请先使用工具获取必要的信息再回答，不要臆测表名或字段；执行修改数据或删除表等破坏性操作前，必须确认用户的请求中明确要求了该操作。所有工具调用完成后，请用简洁的中文总结执行结果回复用户。"
+const LogChunkSummarizer = "我接下来会给你一段服务器日志的片段，相似的行已合并，每行开头的 [×N] 表示该类日志出现了 N 次，行按严重程度和出现次数排序。请找出其中需要关注的错误、异常和可疑行为，逐条说明问题、出现次数和有代表性的原文，忽略正常的访问和运行信息。只需要输出简洁的中文要点，不要包含 Markdown 结构。内容是："
+const LogReducer = "我接下来会给你同一份服务器日志各个片段的分析要点，请合并重复的问题，按严重程度排序，并给出解决方式。发现的问题存储在字段 problem，解决方式存储在字段 solution，例如: [{\"problem\":\"弱密码\",\"solution\":\"修改密码\"}]，不要包含 Markdown 结构，也不要包含其他内容，只需要返回 JSON 列表。内容是："
+const RepairPrompt = "你上一次的回复不符合要求：%s。请重新回复，只返回符合以下 JSON Schema 的 JSON，不要包含 Markdown 结构，也不要包含其他内容：%s"
+const NginxSiteGenerator = "你是 nginx 配置专家。我接下来会给你网站的需求描述和当前配置，请按需求生成完整的网站配置，可以包含一个或多个 server 块以及 upstream、map 块，不要包含 events、http 块和 user、pid、worker_processes 等主配置指令，include 只能引用 /etc/nginx/ 下的文件。除非需求要求修改，请保留当前配置中的域名、证书、根目录和日志路径。如果给出了上一次生成的配置及 nginx -t 的错误，请修正这些错误。配置存储在字段 config，修改说明存储在字段 explanation，不要包含 Markdown 结构，也不要包含其他内容，只需要返回 JSON 对象。内容是："
+const HealthDiagnosis = "你是 Linux 服务器运维专家。我接下来会给你一份服务器状态快照（JSON），包括 CPU、内存、磁盘、系统信息、资源占用最高的进程和相关日志的末尾。请诊断需要关注的问题，只报告有快照数据支持的问题，按严重程度给出 severity：critical 表示影响服务或即将耗尽资源，warning 表示需要尽快处理，info 表示优化建议。每个问题给出标题 title、说明 detail、快照中的依据 evidence，以及可选的处理操作 actions，操作只能从以下几种中选择：kill_process（终止进程，需要 pid，不要终止 sshd、systemd 等系统进程）、restart_nginx、reload_nginx、test_nginx（检查 nginx 配置）、analyze_log（深入分析日志文件，需要 path）。每个操作给出理由 reason。不要包含 Markdown 结构，也不要包含其他内容，只需要返回问题的 JSON 列表，没有问题时返回空列表。快照是："
@@ -0,0 +1,197 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidOutput 模型回复不是符合约定结构的 JSON，且修复重试后仍然不符合
+var ErrInvalidOutput = errors.New("AI output does not match the expected schema")
+
+// Schema JSON Schema 的子集，支持 type、properties、required、items、enum、minItems、minLength 和 pattern
+type Schema struct {
+	Type        string             `json:"type"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	MinItems    int                `json:"minItems,omitempty"`
+	MinLength   int                `json:"minLength,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+}
+
+// String 返回 Schema 的 JSON 文本，用于提示模型
+func (s *Schema) String() string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// Validate 检查已解析的 JSON 值是否符合 Schema，返回的错误包含出错位置，如 $[0].problem
+func (s *Schema) Validate(value interface{}) error {
+	return s.validate("$", value)
+}
+
+func (s *Schema) validate(path string, value interface{}) error {
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: 应为对象", path)
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: 缺少字段 %s", path, name)
+			}
+		}
+		// 按字段名顺序检查，使错误信息稳定
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			field, ok := object[name]
+			if !ok {
+				continue
+			}
+			if err := s.Properties[name].validate(path+"."+name, field); err != nil {
+				return err
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: 应为数组", path)
+		}
+		if len(array) < s.MinItems {
+			return fmt.Errorf("%s: 至少需要 %d 项", path, s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range array {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: 应为字符串", path)
+		}
+		if len([]rune(strings.TrimSpace(text))) < s.MinLength {
+			return fmt.Errorf("%s: 不能为空", path)
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, text) {
+			return fmt.Errorf("%s: 应为 %s 之一", path, strings.Join(s.Enum, "、"))
+		}
+		if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(text) {
+			return fmt.Errorf("%s: 格式不正确，应匹配 %s", path, s.Pattern)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: 应为数字", path)
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != float64(int64(number)) {
+			return fmt.Errorf("%s: 应为整数", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: 应为布尔值", path)
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// fencePattern 匹配 Markdown 代码块，语言标记可选
+var fencePattern = regexp.MustCompile("(?s)```[a-zA-Z]*\\s*\\n?(.*?)```")
+
+// ExtractJSON 从模型回复中取出 JSON：优先使用代码块中的内容，否则取第一个完整的 JSON 对象或数组，
+// 忽略前后的说明文字
+func ExtractJSON(reply string) (string, error) {
+	candidates := make([]string, 0, 2)
+	for _, match := range fencePattern.FindAllStringSubmatch(reply, -1) {
+		candidates = append(candidates, match[1])
+	}
+	candidates = append(candidates, reply)
+
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		if json.Valid([]byte(candidate)) {
+			return candidate, nil
+		}
+		for start := 0; start < len(candidate); start++ {
+			if candidate[start] != '{' && candidate[start] != '[' {
+				continue
+			}
+			if end := matchingBracket(candidate, start); end > 0 && json.Valid([]byte(candidate[start:end])) {
+				return candidate[start:end], nil
+			}
+		}
+	}
+	return "", errors.New("回复中没有找到 JSON")
+}
+
+// matchingBracket 返回从 start 开始的括号匹配结束后的位置，跳过字符串中的括号，没有匹配时返回 -1
+func matchingBracket(text string, start int) int {
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(text); i++ {
+		ch := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\':
+				escaped = true
+			case ch == '"':
+				inString = false
+			}
+			continue
+		}
+		switch ch {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// ParseOutput 从模型回复中提取 JSON，按 Schema 校验后解析到 out
+func ParseOutput(schema *Schema, reply string, out interface{}) error {
+	text, err := ExtractJSON(reply)
+	if err != nil {
+		return err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return err
+	}
+	if err := schema.Validate(value); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(text), out)
+}
@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractJSON(t *testing.T) {
+	cases := []struct {
+		name  string
+		reply string
+		want  string
+	}{
+		{"plain", `[{"problem":"a","solution":"b"}]`, `[{"problem":"a","solution":"b"}]`},
+		{"fenced", "分析结果如下：\n```json\n[\"/tmp/a.log\"]\n```\n请确认后删除。", `["/tmp/a.log"]`},
+		{"fence without language", "```\n{\"ok\":true}\n```", `{"ok":true}`},
+		{"prose around", `好的，以下是结果 [{"problem":"磁盘已满 [95%]","solution":"清理 /var/log"}] 希望有帮助`, `[{"problem":"磁盘已满 [95%]","solution":"清理 /var/log"}]`},
+		{"brackets before json", `注意 [重要]：{"ok":true}`, `{"ok":true}`},
+	}
+	for _, tc := range cases {
+		got, err := ExtractJSON(tc.reply)
+		if err != nil || got != tc.want {
+			t.Errorf("%s: got %q, %v; want %q", tc.name, got, err, tc.want)
+		}
+	}
+
+	if _, err := ExtractJSON("日志中没有发现问题"); err == nil {
+		t.Error("expected an error when the reply contains no JSON")
+	}
+}
+
+func TestParseOutputValidatesSchema(t *testing.T) {
+	var problems []Problem
+	if err := ParseOutput(problemListSchema, "```json\n[{\"problem\":\"弱密码\",\"solution\":\"修改密码\"}]\n```", &problems); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(problems) != 1 || problems[0].Solution != "修改密码" {
+		t.Fatalf("unexpected problems %+v", problems)
+	}
+
+	for reply, want := range map[string]string{
+		`{"problem":"弱密码"}`:                   "$: 应为数组",
+		`[{"problem":"弱密码"}]`:                 "$[0]: 缺少字段 solution",
+		`[{"problem":"弱密码","solution":3}]`:    "$[0].solution: 应为字符串",
+		`[{"problem":" ","solution":"修改密码"}]`: "$[0].problem: 不能为空",
+	} {
+		var out []Problem
+		if err := ParseOutput(problemListSchema, reply, &out); err == nil || err.Error() != want {
+			t.Errorf("%s: got %v, want %q", reply, err, want)
+		}
+	}
+
+	var paths []string
+	if err := ParseOutput(pathListSchema, `["/tmp/a.log", "cache/b"]`, &paths); err == nil || !strings.Contains(err.Error(), "$[1]") {
+		t.Errorf("relative path should be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package ai
+
+// Task 提示词及其回复应符合的 JSON Schema
+type Task struct {
+	Prompt string
+	Schema *Schema
+}
+
+// Problem 日志分析发现的问题及解决方式
+type Problem struct {
+	Problem  string `json:"problem" example:"弱密码"`
+	Solution string `json:"solution" example:"修改密码"`
+}
+
+// problemListSchema 问题列表，对应 []Problem
+var problemListSchema = &Schema{
+	Type: "array",
+	Items: &Schema{
+		Type:     "object",
+		Required: []string{"problem", "solution"},
+		Properties: map[string]*Schema{
+			"problem":  {Type: "string", Description: "发现的问题", MinLength: 1},
+			"solution": {Type: "string", Description: "解决方式", MinLength: 1},
+		},
+	},
+}
+
+// pathListSchema 建议删除的绝对路径列表，对应 []string
+var pathListSchema = &Schema{
+	Type:  "array",
+	Items: &Schema{Type: "string", Description: "绝对路径", Pattern: "^/"},
+}
+
+// nginxSiteSchema 生成的网站配置及说明
+var nginxSiteSchema = &Schema{
+	Type:     "object",
+	Required: []string{"config", "explanation"},
+	Properties: map[string]*Schema{
+		"config":      {Type: "string", Description: "完整的网站配置", MinLength: 1},
+		"explanation": {Type: "string", Description: "配置的修改说明"},
+	},
+}
+
+// 诊断问题的严重程度，按此顺序排序
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// 诊断可以建议的处理操作
+const (
+	ActionKillProcess  = "kill_process"
+	ActionRestartNginx = "restart_nginx"
+	ActionReloadNginx  = "reload_nginx"
+	ActionTestNginx    = "test_nginx"
+	ActionAnalyzeLog   = "analyze_log"
+)
+
+// Finding 健康诊断发现的问题
+type Finding struct {
+	Severity string          `json:"severity" example:"critical"`
+	Title    string          `json:"title" example:"内存即将耗尽"`
+	Detail   string          `json:"detail"`
+	Evidence string          `json:"evidence"`
+	Actions  []FindingAction `json:"actions"`
+}
+
+// FindingAction 模型建议的处理操作，pid 和 path 只在对应操作中使用
+type FindingAction struct {
+	Action string `json:"action" example:"kill_process"`
+	PID    int    `json:"pid,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// findingListSchema 诊断问题列表，对应 []Finding
+var findingListSchema = &Schema{
+	Type: "array",
+	Items: &Schema{
+		Type:     "object",
+		Required: []string{"severity", "title", "detail"},
+		Properties: map[string]*Schema{
+			"severity": {Type: "string", Enum: []string{SeverityCritical, SeverityWarning, SeverityInfo}},
+			"title":    {Type: "string", MinLength: 1},
+			"detail":   {Type: "string"},
+			"evidence": {Type: "string"},
+			"actions": {
+				Type: "array",
+				Items: &Schema{
+					Type:     "object",
+					Required: []string{"action"},
+					Properties: map[string]*Schema{
+						"action": {Type: "string", Enum: []string{ActionKillProcess, ActionRestartNginx, ActionReloadNginx, ActionTestNginx, ActionAnalyzeLog}},
+						"pid":    {Type: "integer"},
+						"path":   {Type: "string", Pattern: "^/"},
+						"reason": {Type: "string"},
+					},
+				},
+			},
+		},
+	},
+}
+
+var (
+	// LogAnalysisTask 分析日志，回复 []Problem
+	LogAnalysisTask = Task{Prompt: LogAnalyzer, Schema: problemListSchema}
+	// LogReduceTask 合并各日志分块的分析要点，回复 []Problem
+	LogReduceTask = Task{Prompt: LogReducer, Schema: problemListSchema}
+	// DirCleanTask 给出可删除的文件，回复 []string
+	DirCleanTask = Task{Prompt: DirCleanPrompt, Schema: pathListSchema}
+	// NginxSiteTask 按描述生成网站配置，回复 {config, explanation}
+	NginxSiteTask = Task{Prompt: NginxSiteGenerator, Schema: nginxSiteSchema}
+	// HealthDiagnosisTask 根据系统状态快照诊断问题，回复 []Finding
+	HealthDiagnosisTask = Task{Prompt: HealthDiagnosis, Schema: findingListSchema}
+)
@@ -0,0 +1,277 @@
+package dbmgr
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Supported engine names
+const (
+	EngineMySQL      = "mysql"
+	EnginePostgreSQL = "postgresql"
+	EngineRedis      = "redis"
+	EngineSQLite     = "sqlite"
+)
+
+// Capability describes an optional, engine-specific feature
+type Capability string
+
+const (
+	CapabilityUsers        Capability = "users"
+	CapabilityBackup       Capability = "backup"
+	CapabilityRestore      Capability = "restore"
+	CapabilityServerStatus Capability = "server_status"
+	CapabilityTableSchema  Capability = "table_schema"
+	CapabilityDropTable    Capability = "drop_table"
+	CapabilityVacuum       Capability = "vacuum"
+	CapabilityExtensions   Capability = "extensions"
+	CapabilitySlowLog      Capability = "slowlog"
+	CapabilityKeyScan      Capability = "key_scan"
+	CapabilityReadOnly     Capability = "read_only_query"
+)
+
+// ConnectionConfig is the engine-agnostic connection configuration used by the registry
+type ConnectionConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Database string `json:"database"`
+	SSLMode  string `json:"ssl_mode"`
+	Path     string `json:"path"`
+}
+
+// DatabaseManager is the common behaviour shared by every engine manager
+type DatabaseManager interface {
+	// Engine returns the registry name of the engine
+	Engine() string
+	Connect() error
+	Disconnect() error
+	TestConnection() error
+	// ListDatabases returns the databases visible on the connection
+	ListDatabases() (interface{}, error)
+	// ListTables returns the tables (or keys for Redis) of a database, empty means the current one
+	ListTables(database string) (interface{}, error)
+	// Query runs a read statement and returns its rows
+	Query(query string) ([]map[string]interface{}, error)
+	// Execute runs a write statement and returns the number of affected rows
+	Execute(statement string) (int64, error)
+}
+
+var (
+	_ DatabaseManager = (*MySQLManager)(nil)
+	_ DatabaseManager = (*PostgreSQLManager)(nil)
+	_ DatabaseManager = (*RedisManager)(nil)
+	_ DatabaseManager = (*SQLiteManager)(nil)
+)
+
+// UserLister is implemented by engines that manage their own accounts
+type UserLister interface {
+	ListUsers() (interface{}, error)
+}
+
+// Backupper is implemented by engines that can write a backup file
+type Backupper interface {
+	Backup(database, outputPath string) error
+}
+
+// Restorer is implemented by engines that can restore a backup file
+type Restorer interface {
+	Restore(database, backupPath string) error
+}
+
+// ServerStatusReader is implemented by engines that report server status
+type ServerStatusReader interface {
+	GetServerStatus() (map[string]interface{}, error)
+}
+
+// TableDescriber is implemented by engines that can describe a table's columns (MySQL, PostgreSQL, SQLite)
+type TableDescriber interface {
+	GetTableSchema(tableName string) ([]map[string]interface{}, error)
+}
+
+// TableDropper is implemented by engines that can drop a table by name
+type TableDropper interface {
+	DropTable(tableName string) error
+}
+
+// Vacuumer is implemented by engines that support VACUUM
+type Vacuumer interface {
+	VacuumDatabase() error
+}
+
+// ExtensionManager is implemented by engines with installable extensions (PostgreSQL)
+type ExtensionManager interface {
+	GetExtensions() ([]map[string]interface{}, error)
+	CreateExtension(name, schema string) error
+	DropExtension(name string, cascade bool) error
+}
+
+// SlowLogReader is implemented by engines exposing slow queries (MySQL slow_log table, PostgreSQL pg_stat_statements, Redis SLOWLOG)
+type SlowLogReader interface {
+	GetSlowLog(count int64) ([]map[string]interface{}, error)
+}
+
+// KeyScanner is implemented by key-value engines that page through keys with a cursor (Redis)
+type KeyScanner interface {
+	GetKeys(pattern string, cursor uint64, count int64) ([]string, uint64, error)
+}
+
+// ReadOnlyQuerier is implemented by SQL engines that can run a query inside a read-only transaction.
+// It reports whether the result was truncated to limits.MaxRows
+type ReadOnlyQuerier interface {
+	QueryReadOnly(query string, limits QueryLimits) ([]map[string]interface{}, bool, error)
+}
+
+// Capabilities returns the optional features supported by a manager
+func Capabilities(m DatabaseManager) []Capability {
+	var caps []Capability
+	if _, ok := m.(UserLister); ok {
+		caps = append(caps, CapabilityUsers)
+	}
+	if _, ok := m.(Backupper); ok {
+		caps = append(caps, CapabilityBackup)
+	}
+	if _, ok := m.(Restorer); ok {
+		caps = append(caps, CapabilityRestore)
+	}
+	if _, ok := m.(ServerStatusReader); ok {
+		caps = append(caps, CapabilityServerStatus)
+	}
+	if _, ok := m.(TableDescriber); ok {
+		caps = append(caps, CapabilityTableSchema)
+	}
+	if _, ok := m.(TableDropper); ok {
+		caps = append(caps, CapabilityDropTable)
+	}
+	if _, ok := m.(Vacuumer); ok {
+		caps = append(caps, CapabilityVacuum)
+	}
+	if _, ok := m.(ExtensionManager); ok {
+		caps = append(caps, CapabilityExtensions)
+	}
+	if _, ok := m.(SlowLogReader); ok {
+		caps = append(caps, CapabilitySlowLog)
+	}
+	if _, ok := m.(KeyScanner); ok {
+		caps = append(caps, CapabilityKeyScan)
+	}
+	if _, ok := m.(ReadOnlyQuerier); ok {
+		caps = append(caps, CapabilityReadOnly)
+	}
+	return caps
+}
+
+// HasCapability reports whether a manager supports the given capability
+func HasCapability(m DatabaseManager, capability Capability) bool {
+	for _, c := range Capabilities(m) {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Factory creates an unconnected manager from a connection config, rejecting configs the engine cannot use
+type Factory func(config ConnectionConfig) (DatabaseManager, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register adds an engine factory to the registry, replacing any existing one
+func Register(engine string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[engine] = factory
+}
+
+// New creates an unconnected manager for the named engine
+func New(engine string, config ConnectionConfig) (DatabaseManager, error) {
+	registryMu.RLock()
+	factory, ok := registry[engine]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported database engine: %s", engine)
+	}
+	return factory(config)
+}
+
+// Open creates a manager for the named engine and connects it
+func Open(engine string, config ConnectionConfig) (DatabaseManager, error) {
+	m, err := New(engine, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Connect(); err != nil {
+		_ = m.Disconnect()
+		return nil, err
+	}
+	return m, nil
+}
+
+// Registered reports whether an engine factory is registered under the given name
+func Registered(engine string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	_, ok := registry[engine]
+	return ok
+}
+
+// Engines returns the names of all registered engines
+func Engines() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	engines := make([]string, 0, len(registry))
+	for name := range registry {
+		engines = append(engines, name)
+	}
+	sort.Strings(engines)
+	return engines
+}
+
+func init() {
+	Register(EngineMySQL, func(c ConnectionConfig) (DatabaseManager, error) {
+		return NewMySQLManager(MySQLConfig{
+			Host:     c.Host,
+			Port:     c.Port,
+			Username: c.Username,
+			Password: c.Password,
+			Database: c.Database,
+		}), nil
+	})
+	Register(EnginePostgreSQL, func(c ConnectionConfig) (DatabaseManager, error) {
+		return NewPostgreSQLManager(PostgreSQLConfig{
+			Host:     c.Host,
+			Port:     c.Port,
+			Username: c.Username,
+			Password: c.Password,
+			Database: c.Database,
+			SSLMode:  c.SSLMode,
+		}), nil
+	})
+	Register(EngineRedis, func(c ConnectionConfig) (DatabaseManager, error) {
+		db := 0
+		if c.Database != "" {
+			var err error
+			if db, err = strconv.Atoi(c.Database); err != nil {
+				return nil, fmt.Errorf("invalid redis database index: %s", c.Database)
+			}
+		}
+		return NewRedisManager(RedisConfig{
+			Host:     c.Host,
+			Port:     c.Port,
+			Password: c.Password,
+			Database: db,
+		}), nil
+	})
+	Register(EngineSQLite, func(c ConnectionConfig) (DatabaseManager, error) {
+		return NewSQLiteManager(SQLiteConfig{
+			DatabasePath: c.Path,
+		}), nil
+	})
+}
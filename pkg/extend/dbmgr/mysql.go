@@ -1,9 +1,12 @@
 package dbmgr
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -464,16 +467,134 @@ func (m *MySQLManager) BackupDatabase(database, outputPath string) error {
 		if rows.Next() {
 			var tableName, createStmt string
 			rows.Scan(&tableName, &createStmt)
+			backupSQL.WriteString(fmt.Sprintf("DROP TABLE IF EXISTS `%s`;\n", table.Name))
 			backupSQL.WriteString(fmt.Sprintf("%s;\n\n", createStmt))
 		}
 		rows.Close()
+
+		if err := m.dumpTableData(&backupSQL, database, table.Name); err != nil {
+			return fmt.Errorf("failed to dump table '%s': %w", table.Name, err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := os.WriteFile(outputPath, []byte(backupSQL.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
 	}
 
-	// Write to file (simplified - you'd want better file handling)
-	log.Printf("Backup SQL generated for database '%s'", database)
+	log.Printf("Database '%s' backed up to %s", database, outputPath)
 	return nil
 }
 
+// RestoreDatabase replays a backup produced by BackupDatabase into the given database
+func (m *MySQLManager) RestoreDatabase(database, backupPath string) error {
+	if m.conn == nil {
+		return fmt.Errorf("no active connection")
+	}
+
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to read backup file: %w", err)
+	}
+
+	// USE only applies to a single session, so pin one connection from the pool
+	ctx := context.Background()
+	conn, err := m.conn.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("USE `%s`", database)); err != nil {
+		return fmt.Errorf("failed to select database: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return fmt.Errorf("failed to disable foreign key checks: %w", err)
+	}
+	defer conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+
+	// BackupDatabase escapes newlines inside values, so every statement ends with ";\n"
+	for _, chunk := range strings.Split(string(data), ";\n") {
+		var lines []string
+		for _, line := range strings.Split(chunk, "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+				lines = append(lines, line)
+			}
+		}
+		stmt := strings.TrimSpace(strings.Join(lines, "\n"))
+		if stmt == "" {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Printf("Database '%s' restored from %s", database, backupPath)
+	return nil
+}
+
+// dumpTableData writes INSERT statements for every row of a table
+func (m *MySQLManager) dumpTableData(out *strings.Builder, database, table string) error {
+	rows, err := m.conn.Query(fmt.Sprintf("SELECT * FROM `%s`.`%s`", database, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return err
+		}
+
+		literals := make([]string, len(values))
+		for i, val := range values {
+			literals[i] = mysqlLiteral(val)
+		}
+		out.WriteString(fmt.Sprintf("INSERT INTO `%s` VALUES (%s);\n", table, strings.Join(literals, ", ")))
+	}
+	out.WriteString("\n")
+
+	return rows.Err()
+}
+
+// mysqlLiteral formats a scanned value as a SQL literal
+func mysqlLiteral(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return "'" + mysqlEscaper.Replace(string(v)) + "'"
+	case string:
+		return "'" + mysqlEscaper.Replace(v) + "'"
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05") + "'"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+var mysqlEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"'", "\\'",
+	"\x00", "\\0",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\x1a", "\\Z",
+)
+
 // GetTablesForDatabase returns tables for a specific database
 func (m *MySQLManager) GetTablesForDatabase(database string) ([]TableInfo, error) {
 	if m.conn == nil {
@@ -556,3 +677,94 @@ func (m *MySQLManager) GetServerStatus() (map[string]interface{}, error) {
 
 	return status, nil
 }
+
+// GetTableSchema returns the columns of a table in the current database
+func (m *MySQLManager) GetTableSchema(tableName string) ([]map[string]interface{}, error) {
+	if m.conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT COLUMN_NAME AS name, COLUMN_TYPE AS type, IS_NULLABLE AS nullable,
+			COLUMN_DEFAULT AS default_value, COLUMN_KEY AS column_key, EXTRA AS extra, COLUMN_COMMENT AS comment
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = %s
+		ORDER BY ORDINAL_POSITION`, mysqlLiteral(tableName))
+	return m.ExecuteQuery(query)
+}
+
+// GetSlowLog returns the latest slow query log entries. Entries are only
+// available when the server writes the slow log to a table (log_output=TABLE).
+func (m *MySQLManager) GetSlowLog(count int64) ([]map[string]interface{}, error) {
+	if m.conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	if count <= 0 {
+		count = 10
+	}
+
+	query := fmt.Sprintf(`
+		SELECT start_time, user_host, query_time, lock_time, rows_sent, rows_examined, db, sql_text
+		FROM mysql.slow_log
+		ORDER BY start_time DESC
+		LIMIT %d`, count)
+	return m.ExecuteQuery(query)
+}
+
+// Engine implements DatabaseManager
+func (m *MySQLManager) Engine() string {
+	return EngineMySQL
+}
+
+// ListDatabases implements DatabaseManager
+func (m *MySQLManager) ListDatabases() (interface{}, error) {
+	return m.GetDatabases()
+}
+
+// ListTables implements DatabaseManager
+func (m *MySQLManager) ListTables(database string) (interface{}, error) {
+	if database == "" {
+		database = m.Database
+	}
+	return m.GetTablesForDatabase(database)
+}
+
+// Query implements DatabaseManager
+func (m *MySQLManager) Query(query string) ([]map[string]interface{}, error) {
+	return m.ExecuteQuery(query)
+}
+
+// Execute implements DatabaseManager
+func (m *MySQLManager) Execute(statement string) (int64, error) {
+	return m.ExecuteNonQuery(statement)
+}
+
+// QueryReadOnly implements ReadOnlyQuerier using START TRANSACTION READ ONLY; the timeout cancels the query through its context
+func (m *MySQLManager) QueryReadOnly(query string, limits QueryLimits) ([]map[string]interface{}, bool, error) {
+	return runReadOnly(m.conn, query, limits, true, func(ctx context.Context, conn *sql.Conn) (*sql.Tx, func(), error) {
+		tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		return tx, nil, err
+	})
+}
+
+// ListUsers implements UserLister
+func (m *MySQLManager) ListUsers() (interface{}, error) {
+	return m.GetUsers()
+}
+
+// Backup implements Backupper
+func (m *MySQLManager) Backup(database, outputPath string) error {
+	if database == "" {
+		database = m.Database
+	}
+	return m.BackupDatabase(database, outputPath)
+}
+
+// Restore implements Restorer
+func (m *MySQLManager) Restore(database, backupPath string) error {
+	if database == "" {
+		database = m.Database
+	}
+	return m.RestoreDatabase(database, backupPath)
+}
@@ -1,13 +1,18 @@
 package dbmgr
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // PostgreSQLManager handles PostgreSQL database operations
@@ -685,34 +690,74 @@ func (p *PostgreSQLManager) BackupDatabase(database, outputPath string, options
 		return fmt.Errorf("no active connection")
 	}
 
-	// This would typically use pg_dump command
-	// For now, return a placeholder implementation
+	if database == "" {
+		database = p.Database
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	args := []string{
+		"-h", p.Host,
+		"-p", strconv.Itoa(p.Port),
+		"-U", p.Username,
+		"-d", database,
+		"-f", outputPath,
+	}
+	if format, ok := options["format"].(string); ok && format != "" {
+		args = append(args, "-F", format)
+	}
+	if clean, ok := options["clean"].(bool); ok && clean {
+		args = append(args, "--clean", "--if-exists")
+	}
+
 	log.Printf("备份已启动，数据库: '%s'，输出路径: '%s'", database, outputPath)
 
-	// In a real implementation, you would:
-	// 1. Construct pg_dump command with appropriate options
-	// 2. Execute the command using os/exec
-	// 3. Handle the output and errors
+	cmd := exec.Command("pg_dump", args...)
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+p.Password, "PGSSLMODE="+p.SSLMode)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("pg_dump failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
 
-	return fmt.Errorf("backup functionality requires pg_dump command integration")
+	return nil
 }
 
-// RestoreDatabase restores a database from backup using pg_restore command
+// RestoreDatabase restores a database from backup. Plain SQL dumps are
+// replayed with psql, custom/tar/directory archives with pg_restore.
 func (p *PostgreSQLManager) RestoreDatabase(database, backupPath string, options map[string]interface{}) error {
 	if p.conn == nil {
 		return fmt.Errorf("no active connection")
 	}
 
-	// This would typically use pg_restore command
-	// For now, return a placeholder implementation
+	if database == "" {
+		database = p.Database
+	}
+
+	args := []string{
+		"-h", p.Host,
+		"-p", strconv.Itoa(p.Port),
+		"-U", p.Username,
+		"-d", database,
+	}
+
+	name := "psql"
+	if format, ok := options["format"].(string); ok && format != "" && format != "p" && format != "plain" {
+		name = "pg_restore"
+		args = append(args, "--clean", "--if-exists", backupPath)
+	} else {
+		args = append(args, "-v", "ON_ERROR_STOP=1", "-f", backupPath)
+	}
+
 	log.Printf("恢复已启动，数据库: '%s'，备份路径: '%s'", database, backupPath)
 
-	// In a real implementation, you would:
-	// 1. Construct pg_restore command with appropriate options
-	// 2. Execute the command using os/exec
-	// 3. Handle the output and errors
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+p.Password, "PGSSLMODE="+p.SSLMode)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(string(output)))
+	}
 
-	return fmt.Errorf("restore functionality requires pg_restore command integration")
+	return nil
 }
 
 // GetExtensions returns list of installed PostgreSQL extensions
@@ -777,3 +822,107 @@ func (p *PostgreSQLManager) DropExtension(name string, cascade bool) error {
 	log.Printf("扩展 '%s' 删除成功", name)
 	return nil
 }
+
+// GetTableSchema returns the columns of a table, optionally qualified as schema.table
+func (p *PostgreSQLManager) GetTableSchema(tableName string) ([]map[string]interface{}, error) {
+	if p.conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	schema := "public"
+	if parts := strings.SplitN(tableName, ".", 2); len(parts) == 2 {
+		schema, tableName = parts[0], parts[1]
+	}
+
+	query := fmt.Sprintf(`
+		SELECT column_name AS name, data_type AS type, is_nullable AS nullable,
+			column_default AS default_value, character_maximum_length AS max_length
+		FROM information_schema.columns
+		WHERE table_schema = %s AND table_name = %s
+		ORDER BY ordinal_position`, pq.QuoteLiteral(schema), pq.QuoteLiteral(tableName))
+	return p.ExecuteQuery(query)
+}
+
+// GetSlowLog returns the statements with the highest mean execution time.
+// It requires the pg_stat_statements extension (PostgreSQL 13+ column names).
+func (p *PostgreSQLManager) GetSlowLog(count int64) ([]map[string]interface{}, error) {
+	if p.conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	if count <= 0 {
+		count = 10
+	}
+
+	query := fmt.Sprintf(`
+		SELECT query, calls, total_exec_time, mean_exec_time, rows
+		FROM pg_stat_statements
+		ORDER BY mean_exec_time DESC
+		LIMIT %d`, count)
+	rows, err := p.ExecuteQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("pg_stat_statements is not available: %w", err)
+	}
+	return rows, nil
+}
+
+// Engine implements DatabaseManager
+func (p *PostgreSQLManager) Engine() string {
+	return EnginePostgreSQL
+}
+
+// ListDatabases implements DatabaseManager
+func (p *PostgreSQLManager) ListDatabases() (interface{}, error) {
+	return p.GetDatabases()
+}
+
+// ListTables implements DatabaseManager. PostgreSQL cannot query across
+// databases, so only the connected database can be listed.
+func (p *PostgreSQLManager) ListTables(database string) (interface{}, error) {
+	if database != "" && database != p.Database {
+		return nil, fmt.Errorf("connected to database '%s', reconnect to list tables of '%s'", p.Database, database)
+	}
+	return p.GetTables()
+}
+
+// Query implements DatabaseManager
+func (p *PostgreSQLManager) Query(query string) ([]map[string]interface{}, error) {
+	return p.ExecuteQuery(query)
+}
+
+// Execute implements DatabaseManager
+func (p *PostgreSQLManager) Execute(statement string) (int64, error) {
+	return p.ExecuteNonQuery(statement)
+}
+
+// QueryReadOnly implements ReadOnlyQuerier using a READ ONLY transaction with a transaction-local statement_timeout
+func (p *PostgreSQLManager) QueryReadOnly(query string, limits QueryLimits) ([]map[string]interface{}, bool, error) {
+	return runReadOnly(p.conn, query, limits, true, func(ctx context.Context, conn *sql.Conn) (*sql.Tx, func(), error) {
+		tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return nil, nil, err
+		}
+		if limits.Timeout > 0 {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", limits.Timeout.Milliseconds())); err != nil {
+				_ = tx.Rollback()
+				return nil, nil, err
+			}
+		}
+		return tx, nil, nil
+	})
+}
+
+// ListUsers implements UserLister
+func (p *PostgreSQLManager) ListUsers() (interface{}, error) {
+	return p.GetUsers()
+}
+
+// Backup implements Backupper
+func (p *PostgreSQLManager) Backup(database, outputPath string) error {
+	return p.BackupDatabase(database, outputPath, map[string]interface{}{"clean": true})
+}
+
+// Restore implements Restorer
+func (p *PostgreSQLManager) Restore(database, backupPath string) error {
+	return p.RestoreDatabase(database, backupPath, nil)
+}
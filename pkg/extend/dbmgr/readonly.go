@@ -0,0 +1,103 @@
+package dbmgr
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// QueryLimits bounds a read-only query
+type QueryLimits struct {
+	// MaxRows truncates the result after this many rows, zero means unlimited
+	MaxRows int
+	// Timeout cancels the query after this duration, zero means no timeout
+	Timeout time.Duration
+}
+
+// beginReadOnly starts a read-only transaction on a dedicated connection. The optional cleanup runs on the
+// connection after the transaction has been rolled back and before it is returned to the pool
+type beginReadOnly func(ctx context.Context, conn *sql.Conn) (*sql.Tx, func(), error)
+
+// runReadOnly runs query inside a transaction opened by begin and always rolls it back
+func runReadOnly(db *sql.DB, query string, limits QueryLimits, bytesAsString bool, begin beginReadOnly) ([]map[string]interface{}, bool, error) {
+	if db == nil {
+		return nil, false, fmt.Errorf("no active connection")
+	}
+
+	ctx := context.Background()
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	tx, cleanup, err := begin(ctx, conn)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to start read-only transaction: %w", err)
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, false, fmt.Errorf("query timed out after %s", limits.Timeout)
+		}
+		return nil, false, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	results, truncated, err := collectRows(rows, limits.MaxRows, bytesAsString)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return nil, false, fmt.Errorf("query timed out after %s", limits.Timeout)
+	}
+	return results, truncated, err
+}
+
+// collectRows scans at most maxRows rows into maps keyed by column name and reports whether more rows were available
+func collectRows(rows *sql.Rows, maxRows int, bytesAsString bool) ([]map[string]interface{}, bool, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	results := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		if maxRows > 0 && len(results) == maxRows {
+			return results, true, nil
+		}
+
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, false, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			if b, ok := values[i].([]byte); ok && bytesAsString {
+				row[col] = string(b)
+			} else {
+				row[col] = values[i]
+			}
+		}
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return results, false, nil
+}
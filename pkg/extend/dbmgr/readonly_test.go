@@ -0,0 +1,66 @@
+package dbmgr
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openTestSQLite(t *testing.T) *SQLiteManager {
+	t.Helper()
+	m := NewSQLiteManager(SQLiteConfig{DatabasePath: filepath.Join(t.TempDir(), "app.db")})
+	if err := m.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = m.Disconnect() })
+
+	if _, err := m.Execute(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT); INSERT INTO users (name) VALUES ('a'), ('b'), ('c');`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	return m
+}
+
+func TestSQLiteQueryReadOnlyRejectsWrites(t *testing.T) {
+	m := openTestSQLite(t)
+
+	if _, _, err := m.QueryReadOnly("DELETE FROM users", QueryLimits{}); err == nil {
+		t.Fatal("expected the read-only connection to reject DELETE")
+	}
+
+	// 连接归还连接池后必须恢复可写
+	if _, err := m.Execute("INSERT INTO users (name) VALUES ('d')"); err != nil {
+		t.Fatalf("write after read-only query: %v", err)
+	}
+
+	rows, _, err := m.QueryReadOnly("SELECT COUNT(*) AS total FROM users", QueryLimits{})
+	if err != nil || rows[0]["total"] != int64(4) {
+		t.Fatalf("expected 4 rows, got %v (%v)", rows, err)
+	}
+}
+
+func TestQueryReadOnlyLimits(t *testing.T) {
+	m := openTestSQLite(t)
+
+	rows, truncated, err := m.QueryReadOnly("SELECT * FROM users ORDER BY id", QueryLimits{MaxRows: 2})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(rows) != 2 || !truncated {
+		t.Fatalf("expected 2 rows truncated, got %d truncated=%v", len(rows), truncated)
+	}
+
+	if _, truncated, _ := m.QueryReadOnly("SELECT * FROM users", QueryLimits{MaxRows: 3}); truncated {
+		t.Fatal("expected an exact fit not to be reported as truncated")
+	}
+
+	start := time.Now()
+	_, _, err = m.QueryReadOnly("WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT COUNT(*) FROM c",
+		QueryLimits{Timeout: 100 * time.Millisecond})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("query was not interrupted, took %s", time.Since(start))
+	}
+}
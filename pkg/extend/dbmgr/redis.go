@@ -1,9 +1,13 @@
 package dbmgr
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -760,3 +764,228 @@ func (r *RedisManager) GetCommandStats() (map[string]interface{}, error) {
 
 	return stats, nil
 }
+
+// Engine implements DatabaseManager
+func (r *RedisManager) Engine() string {
+	return EngineRedis
+}
+
+// ListDatabases implements DatabaseManager
+func (r *RedisManager) ListDatabases() (interface{}, error) {
+	return r.GetDatabases()
+}
+
+// ListTables implements DatabaseManager. Redis has no tables, so the first
+// page of keys of the given (or current) database is returned instead.
+func (r *RedisManager) ListTables(database string) (interface{}, error) {
+	if database != "" {
+		db, err := strconv.Atoi(database)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis database index: %s", database)
+		}
+		if db != r.Database {
+			if err := r.SwitchDatabase(db); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	keys, _, err := r.GetKeys("*", 0, 100)
+	return keys, err
+}
+
+// Query implements DatabaseManager by running a whitespace separated command
+func (r *RedisManager) Query(query string) ([]map[string]interface{}, error) {
+	result, err := r.executeCommandLine(query)
+	if err != nil {
+		return nil, err
+	}
+	return []map[string]interface{}{{"result": result}}, nil
+}
+
+// Execute implements DatabaseManager by running a whitespace separated command.
+// Integer replies (DEL, INCR...) are returned as the affected count.
+func (r *RedisManager) Execute(statement string) (int64, error) {
+	result, err := r.executeCommandLine(statement)
+	if err != nil {
+		return 0, err
+	}
+	if n, ok := result.(int64); ok {
+		return n, nil
+	}
+	return 0, nil
+}
+
+// executeCommandLine splits a command line and runs it
+func (r *RedisManager) executeCommandLine(line string) (interface{}, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	args := make([]interface{}, 0, len(fields)-1)
+	for _, f := range fields[1:] {
+		args = append(args, f)
+	}
+	return r.ExecuteCommand(fields[0], args...)
+}
+
+// ListUsers implements UserLister using ACL USERS (Redis 6+)
+func (r *RedisManager) ListUsers() (interface{}, error) {
+	return r.ExecuteCommand("ACL", "USERS")
+}
+
+// GetServerStatus implements ServerStatusReader
+func (r *RedisManager) GetServerStatus() (map[string]interface{}, error) {
+	info, err := r.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"version":           info.Version,
+		"mode":              info.Mode,
+		"role":              info.Role,
+		"connected_clients": info.ConnectedClients,
+		"used_memory_human": info.UsedMemoryHuman,
+		"total_keys":        info.TotalKeys,
+		"uptime_seconds":    info.Uptime,
+	}, nil
+}
+
+// redisBackupEntry is one line of a Redis backup file
+type redisBackupEntry struct {
+	Key   string `json:"key"`
+	TTL   int64  `json:"ttl_ms"`
+	Value []byte `json:"value"` // DUMP serialized value
+}
+
+// BackupDatabase writes every key of the current database to outputPath as
+// JSON lines holding the DUMP payload and remaining TTL of each key
+func (r *RedisManager) BackupDatabase(outputPath string) error {
+	if r.client == nil {
+		return fmt.Errorf("no active connection")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	file, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+
+	var cursor uint64
+	var count int
+	for {
+		keys, next, err := r.client.Scan(r.ctx, cursor, "*", 500).Result()
+		if err != nil {
+			return fmt.Errorf("failed to scan keys: %w", err)
+		}
+
+		for _, key := range keys {
+			value, err := r.client.Dump(r.ctx, key).Result()
+			if err == redis.Nil {
+				continue // key expired during the scan
+			}
+			if err != nil {
+				return fmt.Errorf("failed to dump key '%s': %w", key, err)
+			}
+
+			ttl, err := r.client.PTTL(r.ctx, key).Result()
+			if err != nil {
+				return fmt.Errorf("failed to get TTL of key '%s': %w", key, err)
+			}
+
+			entry := redisBackupEntry{Key: key, Value: []byte(value)}
+			if ttl > 0 {
+				entry.TTL = ttl.Milliseconds()
+			}
+			if err := encoder.Encode(entry); err != nil {
+				return fmt.Errorf("failed to write backup entry: %w", err)
+			}
+			count++
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+
+	log.Printf("Backed up %d keys of Redis database %d to %s", count, r.Database, outputPath)
+	return nil
+}
+
+// Backup implements Backupper. The database argument selects the Redis db index.
+func (r *RedisManager) Backup(database, outputPath string) error {
+	if database != "" {
+		db, err := strconv.Atoi(database)
+		if err != nil {
+			return fmt.Errorf("invalid redis database index: %s", database)
+		}
+		if db != r.Database {
+			if err := r.SwitchDatabase(db); err != nil {
+				return err
+			}
+		}
+	}
+	return r.BackupDatabase(outputPath)
+}
+
+// RestoreDatabase loads a backup written by BackupDatabase into the current
+// database, replacing keys that already exist
+func (r *RedisManager) RestoreDatabase(backupPath string) error {
+	if r.client == nil {
+		return fmt.Errorf("no active connection")
+	}
+
+	file, err := os.Open(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	var count int
+	for decoder.More() {
+		var entry redisBackupEntry
+		if err := decoder.Decode(&entry); err != nil {
+			return fmt.Errorf("failed to read backup entry: %w", err)
+		}
+
+		ttl := time.Duration(entry.TTL) * time.Millisecond
+		if err := r.client.RestoreReplace(r.ctx, entry.Key, ttl, string(entry.Value)).Err(); err != nil {
+			return fmt.Errorf("failed to restore key '%s': %w", entry.Key, err)
+		}
+		count++
+	}
+
+	log.Printf("Restored %d keys into Redis database %d from %s", count, r.Database, backupPath)
+	return nil
+}
+
+// Restore implements Restorer. The database argument selects the Redis db index.
+func (r *RedisManager) Restore(database, backupPath string) error {
+	if database != "" {
+		db, err := strconv.Atoi(database)
+		if err != nil {
+			return fmt.Errorf("invalid redis database index: %s", database)
+		}
+		if db != r.Database {
+			if err := r.SwitchDatabase(db); err != nil {
+				return err
+			}
+		}
+	}
+	return r.RestoreDatabase(backupPath)
+}
@@ -1,7 +1,9 @@
 package dbmgr
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	_ "github.com/mattn/go-sqlite3"
 	"log"
@@ -282,41 +284,49 @@ func (s *SQLiteManager) BackupDatabase(backupPath string) error {
 		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
-	// Simple file copy for SQLite backup
-	sourceFile, err := os.Open(s.DatabasePath)
-	if err != nil {
-		return fmt.Errorf("failed to open source database: %w", err)
+	// VACUUM INTO refuses to overwrite, and unlike a plain file copy it
+	// includes pages still held in the WAL file
+	if err := os.Remove(backupPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old backup file: %w", err)
 	}
-	defer func() {
-		if err := sourceFile.Close(); err != nil {
-			log.Printf("Error closing source file: %v", err)
-		}
-	}()
 
-	destFile, err := os.Create(backupPath)
+	if _, err := s.conn.Exec("VACUUM INTO ?", backupPath); err != nil {
+		return fmt.Errorf("failed to backup database: %w", err)
+	}
+
+	log.Printf("Database backed up to %s", backupPath)
+	return nil
+}
+
+// RestoreDatabase replaces the database file with a backup and reconnects
+func (s *SQLiteManager) RestoreDatabase(backupPath string) error {
+	if s.conn == nil {
+		return fmt.Errorf("no active connection")
+	}
+
+	data, err := os.ReadFile(backupPath)
 	if err != nil {
-		return fmt.Errorf("failed to create backup file: %w", err)
+		return fmt.Errorf("failed to read backup file: %w", err)
 	}
-	defer func() {
-		if err := destFile.Close(); err != nil {
-			log.Printf("Error closing destination file: %v", err)
-		}
-	}()
 
-	// Copy file contents
-	buffer := make([]byte, 1024*1024) // 1MB buffer
-	for {
-		n, err := sourceFile.Read(buffer)
-		if n == 0 || err != nil {
-			break
-		}
-		if _, err := destFile.Write(buffer[:n]); err != nil {
-			return fmt.Errorf("failed to write to backup file: %w", err)
+	if err := s.conn.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+	s.conn = nil
+
+	// Stale WAL/SHM files would be replayed on top of the restored file
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(s.DatabasePath + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s file: %w", suffix, err)
 		}
 	}
 
-	log.Printf("Database backed up to %s", backupPath)
-	return nil
+	if err := os.WriteFile(s.DatabasePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write database file: %w", err)
+	}
+
+	log.Printf("Database restored from %s", backupPath)
+	return s.Connect()
 }
 
 // GetTableSchema returns the schema for a specific table
@@ -343,3 +353,65 @@ func (s *SQLiteManager) VacuumDatabase() error {
 	log.Printf("Database vacuumed successfully")
 	return nil
 }
+
+// Engine implements DatabaseManager
+func (s *SQLiteManager) Engine() string {
+	return EngineSQLite
+}
+
+// ListDatabases implements DatabaseManager. A SQLite file holds a single database.
+func (s *SQLiteManager) ListDatabases() (interface{}, error) {
+	info, err := s.GetDatabaseInfo()
+	if err != nil {
+		return nil, err
+	}
+	return []*SQLiteDatabaseInfo{info}, nil
+}
+
+// ListTables implements DatabaseManager
+func (s *SQLiteManager) ListTables(database string) (interface{}, error) {
+	return s.GetTables()
+}
+
+// Query implements DatabaseManager
+func (s *SQLiteManager) Query(query string) ([]map[string]interface{}, error) {
+	return s.ExecuteQuery(query)
+}
+
+// Execute implements DatabaseManager
+func (s *SQLiteManager) Execute(statement string) (int64, error) {
+	return s.ExecuteStatement(statement)
+}
+
+// QueryReadOnly implements ReadOnlyQuerier. The sqlite3 driver ignores read-only transaction options,
+// so the dedicated connection is switched to PRAGMA query_only for the duration of the query
+func (s *SQLiteManager) QueryReadOnly(query string, limits QueryLimits) ([]map[string]interface{}, bool, error) {
+	return runReadOnly(s.conn, query, limits, false, func(ctx context.Context, conn *sql.Conn) (*sql.Tx, func(), error) {
+		if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+			return nil, nil, err
+		}
+		reset := func() {
+			if _, err := conn.ExecContext(context.Background(), "PRAGMA query_only = OFF"); err != nil {
+				// 无法恢复时丢弃该连接，避免连接池中留下只读连接
+				_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+			}
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			reset()
+			return nil, nil, err
+		}
+		return tx, reset, nil
+	})
+}
+
+// Backup implements Backupper
+func (s *SQLiteManager) Backup(database, outputPath string) error {
+	return s.BackupDatabase(outputPath)
+}
+
+// Restore implements Restorer
+func (s *SQLiteManager) Restore(database, backupPath string) error {
+	return s.RestoreDatabase(backupPath)
+}
@@ -0,0 +1,280 @@
+package logdigest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxLineLength 单行保留的最大长度，超出部分丢弃
+	maxLineLength = 64 * 1024
+	// maxSampleLength 分块中每个样例行的最大长度
+	maxSampleLength = 500
+	// defaultMaxTemplates 默认最多保留的行模板数
+	defaultMaxTemplates = 5000
+)
+
+// Options 聚合和分块的限制
+type Options struct {
+	MaxBytes     int64 // 最多读取的字节数，超出后停止读取
+	ChunkChars   int   // 每个分块的最大字符数
+	MaxChunks    int   // 最多生成的分块数，按严重程度和出现次数保留最重要的行
+	MaxTemplates int   // 最多保留的行模板数，为 0 时使用默认值
+	// FilterTime 按行首时间戳过滤 Since 和 Until，用于无法在读取时过滤的日志文件
+	FilterTime bool
+}
+
+// Stats 读取和聚合的统计信息
+type Stats struct {
+	Lines      int   `json:"lines"`     // 时间窗口内的行数
+	Bytes      int64 `json:"bytes"`     // 读取的字节数
+	Distinct   int   `json:"distinct"`  // 去重后的行模板数
+	ChunkCount int   `json:"chunks"`    // 提交给 AI 的分块数
+	Truncated  bool  `json:"truncated"` // 超过读取、模板或分块上限，部分内容未分析
+}
+
+// Digest 聚合后的日志，Chunks 中每行以 [×N] 开头表示相似行的出现次数
+type Digest struct {
+	Stats
+	Chunks []string
+}
+
+// entry 一类相似的行
+type entry struct {
+	sample   string
+	count    int
+	severity int
+	order    int
+}
+
+var (
+	uuidPattern   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	hexPattern    = regexp.MustCompile(`\b(?:0x)?[0-9a-fA-F]{8,}\b`)
+	numberPattern = regexp.MustCompile(`\d+`)
+)
+
+// 严重程度关键字，按从高到低匹配
+var severityKeywords = []struct {
+	keywords []string
+	level    int
+}{
+	{[]string{"EMERG", "PANIC", "FATAL", "CRIT", "ALERT"}, 4},
+	{[]string{"ERROR", "[ERR]", " ERR ", "FAILED", "FAILURE", "EXCEPTION", "TRACEBACK", "DENIED", "REFUSED", "TIMED OUT", "TIMEOUT"}, 3},
+	{[]string{"WARN"}, 2},
+}
+
+// template 将行中的数字、十六进制串和 UUID 替换为占位符，使仅在变量上不同的行归为一类
+func template(line string) string {
+	line = uuidPattern.ReplaceAllString(line, "<uuid>")
+	line = hexPattern.ReplaceAllString(line, "<hex>")
+	return numberPattern.ReplaceAllString(line, "<n>")
+}
+
+// severity 根据关键字估计行的严重程度
+func severity(line string) int {
+	upper := strings.ToUpper(line)
+	for _, group := range severityKeywords {
+		for _, keyword := range group.keywords {
+			if strings.Contains(upper, keyword) {
+				return group.level
+			}
+		}
+	}
+	return 0
+}
+
+// Build 流式读取日志，将相似的行聚合为模板并按严重程度和出现次数排序后分块，内存占用与日志大小无关
+func Build(r io.Reader, src Source, opts Options) (Digest, error) {
+	maxTemplates := opts.MaxTemplates
+	if maxTemplates <= 0 {
+		maxTemplates = defaultMaxTemplates
+	}
+
+	var digest Digest
+	entries := make(map[string]*entry)
+	window := newTimeWindow(src.Since, src.Until, opts.FilterTime)
+
+	reader := bufio.NewReaderSize(r, 64*1024)
+	for {
+		if opts.MaxBytes > 0 && digest.Bytes >= opts.MaxBytes {
+			digest.Truncated = true
+			break
+		}
+
+		line, n, err := readLine(reader)
+		digest.Bytes += int64(n)
+		if n > 0 {
+			line = strings.TrimRight(line, "\r")
+			if strings.TrimSpace(line) != "" && window.include(line) {
+				digest.Lines++
+				key := template(line)
+				if e, ok := entries[key]; ok {
+					e.count++
+				} else if len(entries) < maxTemplates {
+					entries[key] = &entry{sample: line, count: 1, severity: severity(line), order: len(entries)}
+				} else {
+					digest.Truncated = true
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Digest{}, fmt.Errorf("failed to read log: %w", err)
+		}
+	}
+
+	sorted := make([]*entry, 0, len(entries))
+	for _, e := range entries {
+		sorted = append(sorted, e)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].severity != sorted[j].severity {
+			return sorted[i].severity > sorted[j].severity
+		}
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].order < sorted[j].order
+	})
+
+	digest.Distinct = len(sorted)
+	digest.Chunks, digest.Truncated = chunk(sorted, opts.ChunkChars, opts.MaxChunks, digest.Truncated)
+	digest.ChunkCount = len(digest.Chunks)
+	return digest, nil
+}
+
+// chunk 将排好序的模板按字符数切分为分块，超出 maxChunks 的部分丢弃
+func chunk(entries []*entry, chunkChars, maxChunks int, truncated bool) ([]string, bool) {
+	var chunks []string
+	var current strings.Builder
+	for _, e := range entries {
+		sample := e.sample
+		if len(sample) > maxSampleLength {
+			sample = strings.ToValidUTF8(sample[:maxSampleLength], "") + "..."
+		}
+		line := fmt.Sprintf("[×%d] %s\n", e.count, sample)
+
+		if current.Len() > 0 && chunkChars > 0 && current.Len()+len(line) > chunkChars {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			if maxChunks > 0 && len(chunks) == maxChunks {
+				return chunks, true
+			}
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks, truncated
+}
+
+// readLine 读取一行并返回消耗的字节数，超过 maxLineLength 的部分被丢弃
+func readLine(reader *bufio.Reader) (string, int, error) {
+	var line []byte
+	consumed := 0
+	for {
+		fragment, err := reader.ReadSlice('\n')
+		consumed += len(fragment)
+		if room := maxLineLength - len(line); room > 0 {
+			line = append(line, fragment[:min(len(fragment), room)]...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		return strings.TrimSuffix(string(line), "\n"), consumed, err
+	}
+}
+
+// 行首时间戳格式
+var timestampFormats = []struct {
+	pattern *regexp.Regexp
+	layout  string
+	noYear  bool
+}{
+	// ISO 8601，journal short-iso、docker --timestamps 和多数应用日志
+	{regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?)`), "", false},
+	// nginx error.log
+	{regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2})`), "2006/01/02 15:04:05", false},
+	// nginx/apache access.log
+	{regexp.MustCompile(`\[(\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})\]`), "02/Jan/2006:15:04:05 -0700", false},
+	// syslog
+	{regexp.MustCompile(`^([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2})`), "Jan _2 15:04:05", true},
+}
+
+// isoLayouts ISO 8601 时间戳可能的格式
+var isoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// parseTimestamp 解析行首的时间戳，无时区的时间按本地时间处理
+func parseTimestamp(line string, now time.Time) (time.Time, bool) {
+	for _, format := range timestampFormats {
+		match := format.pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if format.layout == "" {
+			for _, layout := range isoLayouts {
+				if t, err := time.ParseInLocation(layout, match[1], time.Local); err == nil {
+					return t, true
+				}
+			}
+			continue
+		}
+
+		t, err := time.ParseInLocation(format.layout, match[1], time.Local)
+		if err != nil {
+			continue
+		}
+		if format.noYear {
+			// syslog 不含年份，取不晚于当前时间的最近一年
+			t = t.AddDate(now.Year(), 0, 0)
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// timeWindow 按时间戳过滤行，没有时间戳的行（如堆栈）沿用上一行的结果
+type timeWindow struct {
+	since, until time.Time
+	enabled      bool
+	included     bool
+	now          time.Time
+}
+
+func newTimeWindow(since, until time.Time, enabled bool) *timeWindow {
+	return &timeWindow{
+		since:    since,
+		until:    until,
+		enabled:  enabled && (!since.IsZero() || !until.IsZero()),
+		included: true,
+		now:      time.Now(),
+	}
+}
+
+func (w *timeWindow) include(line string) bool {
+	if !w.enabled {
+		return true
+	}
+	if t, ok := parseTimestamp(line, w.now); ok {
+		w.included = (w.since.IsZero() || !t.Before(w.since)) && (w.until.IsZero() || !t.After(w.until))
+	}
+	return w.included
+}
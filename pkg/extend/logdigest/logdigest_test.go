@@ -0,0 +1,138 @@
+package logdigest
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildAggregatesAndRanks(t *testing.T) {
+	var log strings.Builder
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&log, "2024/01/01 12:00:%02d [info] GET /static/app.%d.js 200\n", i%60, i)
+	}
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(&log, "2024/01/01 12:01:%02d [error] connect() failed (111: Connection refused) upstream 10.0.0.%d:3000\n", i, i)
+	}
+	log.WriteString("2024/01/01 12:02:00 [warn] client sent too long header\n")
+
+	digest, err := Build(strings.NewReader(log.String()), Source{Kind: SourceFile}, Options{})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if digest.Lines != 504 || digest.Distinct != 3 || digest.Truncated {
+		t.Fatalf("unexpected stats %+v", digest.Stats)
+	}
+
+	lines := strings.Split(strings.TrimSpace(digest.Chunks[0]), "\n")
+	if !strings.HasPrefix(lines[0], "[×3] ") || !strings.Contains(lines[0], "[error]") {
+		t.Errorf("errors should rank first, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "[warn]") || !strings.HasPrefix(lines[2], "[×500] ") {
+		t.Errorf("unexpected order %q", lines)
+	}
+}
+
+func TestBuildLimitsChunks(t *testing.T) {
+	var log strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&log, "worker %s exited\n", strings.Repeat(string(rune('a'+i%26)), i/26+1))
+	}
+
+	digest, err := Build(strings.NewReader(log.String()), Source{Kind: SourceFile}, Options{ChunkChars: 200, MaxChunks: 2})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(digest.Chunks) != 2 || digest.ChunkCount != 2 || !digest.Truncated {
+		t.Fatalf("expected 2 chunks and truncation, got %d chunks, %+v", len(digest.Chunks), digest.Stats)
+	}
+	for _, chunk := range digest.Chunks {
+		if len(chunk) > 200 {
+			t.Errorf("chunk exceeds limit: %d", len(chunk))
+		}
+	}
+}
+
+func TestBuildFiltersTimeWindow(t *testing.T) {
+	log := strings.Join([]string{
+		"2024-01-01T11:00:00Z before the window",
+		"2024-01-01T12:30:00Z panic: inside the window",
+		"goroutine 1 [running]:",
+		`10.0.0.1 - - [01/Jan/2024:12:45:00 +0000] "GET / HTTP/1.1" 500 0`,
+		"2024-01-01T14:00:00Z after the window",
+		"    stack line of an excluded entry",
+	}, "\n")
+
+	src := Source{
+		Kind:  SourceFile,
+		Since: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Until: time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC),
+	}
+	digest, err := Build(strings.NewReader(log), src, Options{FilterTime: true})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if digest.Lines != 3 {
+		t.Fatalf("expected 3 lines in the window, got %d: %q", digest.Lines, digest.Chunks)
+	}
+	if strings.Contains(digest.Chunks[0], "before") || strings.Contains(digest.Chunks[0], "after") || strings.Contains(digest.Chunks[0], "excluded") {
+		t.Errorf("lines outside the window were kept: %q", digest.Chunks[0])
+	}
+}
+
+func TestOpenFileReadsTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	var log strings.Builder
+	for i := 1; i <= 100000; i++ {
+		fmt.Fprintf(&log, "line %d\n", i)
+	}
+	if err := os.WriteFile(path, []byte(log.String()), 0644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		tail     int
+		maxBytes int64
+		first    string
+	}{
+		{"tail lines", 3, 0, "line 99998\n"},
+		{"max bytes skips the partial line", 0, 15, "line 100000\n"},
+		{"tail longer than the file", 200000, 0, "line 1\n"},
+	} {
+		reader, err := Open(t.Context(), Source{Kind: SourceFile, Path: path, Tail: tc.tail}, tc.maxBytes)
+		if err != nil {
+			t.Fatalf("%s: open: %v", tc.name, err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("%s: read: %v", tc.name, err)
+		}
+		if !strings.HasPrefix(string(data), tc.first) || !strings.HasSuffix(string(data), "line 100000\n") {
+			t.Errorf("%s: unexpected content starting with %q", tc.name, string(data[:min(len(data), 20)]))
+		}
+	}
+}
+
+func TestSourceValidate(t *testing.T) {
+	for _, tc := range []struct {
+		src Source
+		ok  bool
+	}{
+		{Source{Kind: SourceJournal, Unit: "nginx.service"}, true},
+		{Source{Kind: SourceJournal, Unit: "nginx; rm -rf /"}, false},
+		{Source{Kind: SourceDocker, Container: "web_1"}, true},
+		{Source{Kind: SourceDocker, Container: "--help"}, false},
+		{Source{Kind: SourceFile}, false},
+		{Source{Kind: "syslog"}, false},
+	} {
+		if err := tc.src.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%+v: got %v, want ok=%v", tc.src, err, tc.ok)
+		}
+	}
+}
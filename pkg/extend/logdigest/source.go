@@ -0,0 +1,292 @@
+package logdigest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 日志来源类型
+const (
+	SourceFile    = "file"    // 服务器上的日志文件
+	SourceJournal = "journal" // systemd 单元日志
+	SourceDocker  = "docker"  // 容器日志
+)
+
+var (
+	unitPattern      = regexp.MustCompile(`^[A-Za-z0-9@._:\\-]+$`)
+	containerPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+)
+
+// Source 描述要读取的日志
+type Source struct {
+	Kind      string
+	Path      string    // SourceFile 的文件路径，调用方负责校验访问权限
+	Unit      string    // SourceJournal 的单元名
+	Container string    // SourceDocker 的容器名或ID
+	Since     time.Time // 零值表示不限
+	Until     time.Time // 零值表示不限
+	Tail      int       // 大于 0 时只读取最后 Tail 行
+}
+
+// Validate 校验来源参数
+func (s Source) Validate() error {
+	switch s.Kind {
+	case SourceFile:
+		if s.Path == "" {
+			return fmt.Errorf("path is required for file logs")
+		}
+	case SourceJournal:
+		if !unitPattern.MatchString(s.Unit) {
+			return fmt.Errorf("invalid unit name %q", s.Unit)
+		}
+	case SourceDocker:
+		if !containerPattern.MatchString(s.Container) {
+			return fmt.Errorf("invalid container name %q", s.Container)
+		}
+	default:
+		return fmt.Errorf("unsupported log source %q", s.Kind)
+	}
+	if s.Tail < 0 {
+		return fmt.Errorf("tail must not be negative")
+	}
+	if !s.Since.IsZero() && !s.Until.IsZero() && s.Until.Before(s.Since) {
+		return fmt.Errorf("until must not be before since")
+	}
+	return nil
+}
+
+// ParseTime 解析时间窗口的边界，支持相对当前时间的时长（如 2h、30m）和 RFC3339 时间，空字符串返回零值
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			d = -d
+		}
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use a duration such as 2h or an RFC3339 time", value)
+	}
+	return t, nil
+}
+
+// Open 打开日志来源。文件从末尾的 maxBytes 字节（指定 Tail 时为最后 Tail 行）开始读取，
+// journal 和 docker 日志通过命令行工具按时间窗口和 Tail 输出，命令失败时读取返回错误
+func Open(ctx context.Context, src Source, maxBytes int64) (io.ReadCloser, error) {
+	if err := src.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch src.Kind {
+	case SourceFile:
+		return openFile(src, maxBytes)
+	case SourceJournal:
+		args := []string{"--no-pager", "--output", "short-iso", "--unit", src.Unit}
+		if !src.Since.IsZero() {
+			args = append(args, "--since", src.Since.Local().Format("2006-01-02 15:04:05"))
+		}
+		if !src.Until.IsZero() {
+			args = append(args, "--until", src.Until.Local().Format("2006-01-02 15:04:05"))
+		}
+		if src.Tail > 0 {
+			args = append(args, "--lines", strconv.Itoa(src.Tail))
+		}
+		return startCommand(ctx, false, "journalctl", args...)
+	default:
+		args := []string{"logs", "--timestamps"}
+		if !src.Since.IsZero() {
+			args = append(args, "--since", src.Since.Format(time.RFC3339))
+		}
+		if !src.Until.IsZero() {
+			args = append(args, "--until", src.Until.Format(time.RFC3339))
+		}
+		if src.Tail > 0 {
+			args = append(args, "--tail", strconv.Itoa(src.Tail))
+		}
+		args = append(args, "--", src.Container)
+		// 容器写到 stderr 的内容同样是日志
+		return startCommand(ctx, true, "docker", args...)
+	}
+}
+
+// openFile 打开普通文件并定位到需要读取的起点
+func openFile(src Source, maxBytes int64) (io.ReadCloser, error) {
+	file, err := os.Open(src.Path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		file.Close()
+		return nil, fmt.Errorf("%s is not a regular file", src.Path)
+	}
+
+	offset := int64(0)
+	if maxBytes > 0 && info.Size() > maxBytes {
+		offset = info.Size() - maxBytes
+	}
+	if src.Tail > 0 {
+		tailOffset, err := findTail(file, info.Size(), src.Tail)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		offset = max(offset, tailOffset)
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	// 从文件中间开始时丢弃不完整的第一行
+	if offset > 0 {
+		if err := skipPartialLine(file, offset); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// findTail 从文件末尾向前查找，返回最后 lines 行的起始位置
+func findTail(file *os.File, size int64, lines int) (int64, error) {
+	const blockSize = 64 * 1024
+	buf := make([]byte, blockSize)
+
+	end := size
+	// 忽略文件末尾的换行，避免把它算作一个空行
+	if end > 0 {
+		if _, err := file.ReadAt(buf[:1], end-1); err != nil {
+			return 0, err
+		}
+		if buf[0] == '\n' {
+			end--
+		}
+	}
+
+	count := 0
+	for pos := end; pos > 0; {
+		n := int64(blockSize)
+		if pos < n {
+			n = pos
+		}
+		pos -= n
+		if _, err := file.ReadAt(buf[:n], pos); err != nil {
+			return 0, err
+		}
+		for i := n - 1; i >= 0; i-- {
+			if buf[i] != '\n' {
+				continue
+			}
+			count++
+			if count == lines {
+				return pos + i + 1, nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+// skipPartialLine 在 offset 不是行首时跳过当前行的剩余部分
+func skipPartialLine(file *os.File, offset int64) error {
+	prev := make([]byte, 1)
+	if _, err := file.ReadAt(prev, offset-1); err != nil {
+		return err
+	}
+	if prev[0] == '\n' {
+		return nil
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := file.Read(buf)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			_, err := file.Seek(int64(i+1-n), io.SeekCurrent)
+			return err
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// commandReader 读取命令输出，关闭时结束命令
+type commandReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (r *commandReader) Close() error {
+	r.cancel()
+	err := r.PipeReader.Close()
+	<-r.done
+	return err
+}
+
+// startCommand 启动命令并返回其输出，mergeStderr 为 false 时 stderr 仅用于错误信息
+func startCommand(ctx context.Context, mergeStderr bool, name string, args ...string) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = pw
+	if mergeStderr {
+		cmd.Stderr = pw
+	} else {
+		cmd.Stderr = &limitedBuffer{buf: &stderr, limit: 4096}
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to run %s: %w", name, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := cmd.Wait()
+		if err != nil && ctx.Err() == nil {
+			message := strings.TrimSpace(stderr.String())
+			if message == "" {
+				message = err.Error()
+			}
+			err = fmt.Errorf("%s failed: %s", name, message)
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return &commandReader{PipeReader: pr, cancel: cancel, done: done}, nil
+}
+
+// limitedBuffer 只保留前 limit 字节的 io.Writer
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		b.buf.Write(p[:min(len(p), remaining)])
+	}
+	return len(p), nil
+}
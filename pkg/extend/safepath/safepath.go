@@ -0,0 +1,76 @@
+package safepath
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Resolve 将 path 限制在 root 目录内并返回清理后的绝对路径，相对路径视为相对 root。
+// 路径中已存在的部分会解析符号链接，指向 root 之外时同样拒绝。
+func Resolve(root, path string) (string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path = filepath.Clean(path)
+
+	if !Within(root, path) {
+		return "", fmt.Errorf("path %s is outside %s", path, root)
+	}
+
+	resolvedRoot, err := evalExisting(root)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := evalExisting(path)
+	if err != nil {
+		return "", err
+	}
+	if !Within(resolvedRoot, resolved) {
+		return "", fmt.Errorf("path %s resolves outside %s", path, root)
+	}
+	return path, nil
+}
+
+// Canonical 返回规范化的绝对路径：清理 ../ 并解析已存在部分的符号链接，尚不存在的部分按字面拼接
+func Canonical(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return evalExisting(abs)
+}
+
+// Within 判断清理后的绝对路径 path 是否等于 root 或位于其下，不访问文件系统
+func Within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// evalExisting 解析路径中已存在的最长前缀的符号链接，并拼回尚不存在的部分
+func evalExisting(path string) (string, error) {
+	rest := ""
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(path, rest), nil
+		}
+		rest = filepath.Join(filepath.Base(path), rest)
+		path = parent
+	}
+}
@@ -0,0 +1,56 @@
+package safepath
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	for _, tc := range []struct {
+		path string
+		ok   bool
+	}{
+		{"a/b.sql", true},
+		{filepath.Join(root, "new", "dir"), true},
+		{root, true},
+		{"../x", false},
+		{"/etc/passwd", false},
+		{root + "-sibling/x", false},
+		{"link/x.sql", false},
+	} {
+		if _, err := Resolve(root, tc.path); (err == nil) != tc.ok {
+			t.Errorf("Resolve(%q) = %v, want ok=%v", tc.path, err, tc.ok)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	root := t.TempDir()
+	real, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "dir"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "dir"), filepath.Join(root, "link")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	for path, want := range map[string]string{
+		filepath.Join(root, "link", "new", "file"):    filepath.Join(real, "dir", "new", "file"),
+		filepath.Join(root, "dir", "..", "link"):      filepath.Join(real, "dir"),
+		filepath.Join(root, "missing", "..", "other"): filepath.Join(real, "other"),
+	} {
+		if got, err := Canonical(path); err != nil || got != want {
+			t.Errorf("Canonical(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package sqlguard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// readOnlyRedisCommands 只读的 Redis 命令
+var readOnlyRedisCommands = map[string]bool{
+	"GET": true, "MGET": true, "STRLEN": true, "GETRANGE": true,
+	"HGET": true, "HMGET": true, "HGETALL": true, "HKEYS": true, "HVALS": true, "HLEN": true, "HEXISTS": true, "HSCAN": true,
+	"LRANGE": true, "LLEN": true, "LINDEX": true,
+	"SMEMBERS": true, "SCARD": true, "SISMEMBER": true, "SSCAN": true,
+	"ZRANGE": true, "ZRANGEBYSCORE": true, "ZCARD": true, "ZSCORE": true, "ZRANK": true, "ZSCAN": true,
+	"EXISTS": true, "TYPE": true, "TTL": true, "PTTL": true, "SCAN": true, "DBSIZE": true, "INFO": true,
+}
+
+// CheckRedisReadOnly 确认以空格分隔的 Redis 命令行为只读命令
+func CheckRedisReadOnly(command string) error {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return fmt.Errorf("command must not be empty")
+	}
+
+	name := strings.ToUpper(fields[0])
+	if !readOnlyRedisCommands[name] {
+		return fmt.Errorf("redis command %s is not allowed, only read-only commands can be used", name)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package sqlguard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind 语句类别
+type Kind string
+
+const (
+	// KindRead 只读查询：SELECT、SHOW、DESCRIBE、EXPLAIN 等
+	KindRead Kind = "read"
+	// KindWrite 数据修改（DML）：INSERT、UPDATE、DELETE 等
+	KindWrite Kind = "write"
+	// KindDDL 结构或权限变更：CREATE、DROP、ALTER、GRANT 等
+	KindDDL Kind = "ddl"
+	// KindUnknown 无法识别的语句，按写操作处理
+	KindUnknown Kind = "unknown"
+)
+
+// 可作为只读语句开头的关键字
+var readKeywords = map[string]bool{
+	"SELECT": true, "SHOW": true, "DESCRIBE": true, "DESC": true, "EXPLAIN": true, "WITH": true, "VALUES": true, "TABLE": true,
+}
+
+// 数据修改语句的开头关键字
+var writeKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "REPLACE": true, "MERGE": true, "UPSERT": true,
+	"LOAD": true, "COPY": true, "CALL": true, "EXEC": true, "EXECUTE": true, "DO": true, "HANDLER": true,
+	"LOCK": true, "UNLOCK": true, "SET": true, "RESET": true, "PRAGMA": true, "ATTACH": true, "DETACH": true,
+	"BEGIN": true, "START": true, "COMMIT": true, "ROLLBACK": true, "SAVEPOINT": true, "RELEASE": true,
+	"VACUUM": true, "ANALYZE": true, "OPTIMIZE": true, "REPAIR": true, "CHECKPOINT": true, "CLUSTER": true,
+	"REINDEX": true, "REFRESH": true, "LISTEN": true, "NOTIFY": true, "DISCARD": true, "KILL": true,
+	"FLUSH": true, "PURGE": true, "INSTALL": true, "UNINSTALL": true, "SHUTDOWN": true, "USE": true,
+}
+
+// 结构和权限变更语句的开头关键字
+var ddlKeywords = map[string]bool{
+	"CREATE": true, "DROP": true, "ALTER": true, "TRUNCATE": true, "RENAME": true,
+	"GRANT": true, "REVOKE": true, "COMMENT": true, "SECURITY": true, "IMPORT": true,
+}
+
+// 只读语句内部出现即视为写操作的关键字，覆盖 SELECT ... INTO OUTFILE、WITH ... DELETE、FOR UPDATE 等
+var embeddedWriteKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "INTO": true,
+	"CREATE": true, "DROP": true, "ALTER": true, "TRUNCATE": true, "GRANT": true, "REVOKE": true,
+	"OUTFILE": true, "DUMPFILE": true, "LOCK": true, "SHARE": true,
+}
+
+// 有副作用或可读写服务器文件的函数，出现在只读语句中同样拒绝
+var unsafeFunctions = map[string]bool{
+	// PostgreSQL
+	"PG_TERMINATE_BACKEND": true, "PG_CANCEL_BACKEND": true, "PG_RELOAD_CONF": true, "PG_ROTATE_LOGFILE": true,
+	"PG_READ_FILE": true, "PG_READ_BINARY_FILE": true, "PG_LS_DIR": true, "PG_STAT_FILE": true,
+	"PG_SLEEP": true, "PG_SLEEP_FOR": true, "PG_SLEEP_UNTIL": true, "SET_CONFIG": true,
+	"PG_ADVISORY_LOCK": true, "PG_ADVISORY_XACT_LOCK": true, "PG_TRY_ADVISORY_LOCK": true,
+	"PG_CREATE_RESTORE_POINT": true, "PG_SWITCH_WAL": true, "PG_PROMOTE": true,
+	"PG_CREATE_LOGICAL_REPLICATION_SLOT": true, "PG_CREATE_PHYSICAL_REPLICATION_SLOT": true, "PG_DROP_REPLICATION_SLOT": true,
+	"LO_IMPORT": true, "LO_EXPORT": true, "LO_UNLINK": true, "LO_CREATE": true, "LO_FROM_BYTEA": true, "LO_PUT": true,
+	"DBLINK": true, "DBLINK_EXEC": true, "NEXTVAL": true, "SETVAL": true, "TXID_CURRENT": true,
+	// MySQL
+	"SLEEP": true, "BENCHMARK": true, "LOAD_FILE": true, "GET_LOCK": true, "RELEASE_LOCK": true, "RELEASE_ALL_LOCKS": true,
+	"MASTER_POS_WAIT": true, "SOURCE_POS_WAIT": true,
+	// SQLite
+	"LOAD_EXTENSION": true, "WRITEFILE": true, "READFILE": true, "EDIT": true, "FTS3_TOKENIZER": true,
+}
+
+// token 词法单元，word 为大写的标识符或关键字，call 表示其后紧跟左括号
+type token struct {
+	word string
+	call bool
+}
+
+// Statement 一条语句的分类结果
+type Statement struct {
+	Kind    Kind   `json:"kind"`
+	Keyword string `json:"keyword"` // 开头关键字（大写）
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ReadOnly 是否为只读语句
+func (s Statement) ReadOnly() bool {
+	return s.Kind == KindRead
+}
+
+// Classify 对 SQL 文本分类，跳过字符串、引号标识符和注释；包含多条语句时返回错误。
+// MySQL 的字符串支持反斜杠转义且 # 为注释，PostgreSQL 和 SQLite 则不然，文本按两种方言各解析一次，
+// 都必须得到同一条语句，结果取较严格者
+func Classify(query string) (Statement, error) {
+	var result Statement
+	for _, mysql := range []bool{true, false} {
+		statements, err := split(query, mysql)
+		if err != nil {
+			return Statement{}, err
+		}
+		if len(statements) == 0 {
+			return Statement{}, fmt.Errorf("query must not be empty")
+		}
+		if len(statements) > 1 {
+			return Statement{}, fmt.Errorf("only a single statement is allowed")
+		}
+
+		statement := classify(statements[0])
+		if mysql || !statement.ReadOnly() {
+			result = statement
+		}
+		if !result.ReadOnly() {
+			break
+		}
+	}
+	return result, nil
+}
+
+// CheckReadOnly 确认 query 为单条只读语句，否则返回说明原因的错误
+func CheckReadOnly(query string) error {
+	statement, err := Classify(query)
+	if err != nil {
+		return err
+	}
+	if !statement.ReadOnly() {
+		if statement.Reason != "" {
+			return fmt.Errorf("only read-only statements are allowed: %s", statement.Reason)
+		}
+		return fmt.Errorf("only read-only statements are allowed, got %s", statement.Keyword)
+	}
+	return nil
+}
+
+// classify 根据开头关键字以及语句内部的关键字和函数调用分类
+func classify(tokens []token) Statement {
+	keyword := tokens[0].word
+	statement := Statement{Keyword: keyword}
+
+	switch {
+	case ddlKeywords[keyword]:
+		statement.Kind = KindDDL
+		return statement
+	case writeKeywords[keyword]:
+		statement.Kind = KindWrite
+		return statement
+	case !readKeywords[keyword]:
+		statement.Kind = KindUnknown
+		statement.Reason = "unrecognized statement " + keyword
+		return statement
+	}
+
+	for i, tok := range tokens[1:] {
+		switch {
+		case keyword == "EXPLAIN" && tok.word == "ANALYZE" && i == 0:
+			// EXPLAIN ANALYZE 会真正执行被分析的语句，按被分析语句分类
+			if len(tokens) < 3 || !classify(tokens[2:]).ReadOnly() {
+				return Statement{Kind: KindWrite, Keyword: keyword, Reason: "EXPLAIN ANALYZE executes the analyzed statement"}
+			}
+		case tok.call && unsafeFunctions[tok.word]:
+			return Statement{Kind: KindWrite, Keyword: keyword, Reason: "function " + strings.ToLower(tok.word) + " has side effects"}
+		case embeddedWriteKeywords[tok.word] && !tok.call:
+			return Statement{Kind: KindWrite, Keyword: keyword, Reason: tok.word + " is not allowed in a read-only statement"}
+		}
+	}
+
+	statement.Kind = KindRead
+	return statement
+}
+
+// split 按 MySQL 或标准 SQL 方言将文本切分为语句，每条语句为关键字和标识符的序列，字面量、引号标识符和注释不参与分类
+func split(query string, mysql bool) ([][]token, error) {
+	var statements [][]token
+	var current []token
+
+	for i := 0; i < len(query); {
+		ch := query[i]
+		switch {
+		case ch == ';':
+			if len(current) > 0 {
+				statements = append(statements, current)
+				current = nil
+			}
+			i++
+		case ch == '\'' || ch == '"' || ch == '`':
+			end, err := skipQuoted(query, i, ch, mysql && ch != '`')
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case isLineComment(query[i:], mysql):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 1
+			}
+		case ch == '/' && strings.HasPrefix(query[i:], "/*"):
+			// MySQL 会执行 /*! ... */ 中的内容，不能当作注释忽略
+			if strings.HasPrefix(query[i:], "/*!") || strings.HasPrefix(query[i:], "/*+") {
+				return nil, fmt.Errorf("executable comments are not allowed")
+			}
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += end + 4
+		case ch == '$':
+			end, err := skipDollarQuoted(query, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case isWordChar(ch):
+			start := i
+			for i < len(query) && isWordChar(query[i]) {
+				i++
+			}
+			next := i
+			for next < len(query) && isSpace(query[next]) {
+				next++
+			}
+			current = append(current, token{
+				word: strings.ToUpper(query[start:i]),
+				call: next < len(query) && query[next] == '(',
+			})
+		default:
+			i++
+		}
+	}
+
+	if len(current) > 0 {
+		statements = append(statements, current)
+	}
+	return statements, nil
+}
+
+// skipQuoted 跳过引号包围的字面量或标识符，支持重复引号，backslashEscapes 时支持反斜杠转义，返回结束引号之后的位置
+func skipQuoted(query string, start int, quote byte, backslashEscapes bool) (int, error) {
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if backslashEscapes {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated quoted string")
+}
+
+// skipDollarQuoted 跳过 PostgreSQL 的 $tag$...$tag$ 字符串，$1 等占位符按普通字符处理
+func skipDollarQuoted(query string, start int) (int, error) {
+	end := start + 1
+	for end < len(query) && isWordChar(query[end]) && !(end == start+1 && query[end] >= '0' && query[end] <= '9') {
+		end++
+	}
+	if end >= len(query) || query[end] != '$' {
+		return start + 1, nil
+	}
+
+	tag := query[start : end+1]
+	closing := strings.Index(query[end+1:], tag)
+	if closing < 0 {
+		return 0, fmt.Errorf("unterminated dollar-quoted string")
+	}
+	return end + 1 + closing + len(tag), nil
+}
+
+// isLineComment 判断是否为单行注释：MySQL 的 -- 之后必须是空白，# 仅在 MySQL 中是注释
+func isLineComment(rest string, mysql bool) bool {
+	if strings.HasPrefix(rest, "--") {
+		return !mysql || len(rest) == 2 || isSpace(rest[2])
+	}
+	return mysql && rest[0] == '#'
+}
+
+func isWordChar(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch >= 0x80
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
@@ -0,0 +1,85 @@
+package sqlguard
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		kind  Kind
+	}{
+		{"SELECT * FROM users", KindRead},
+		{"  select id from users where name = 'DELETE FROM users';", KindRead},
+		{"SHOW TABLES", KindRead},
+		{"DESCRIBE users", KindRead},
+		{"EXPLAIN SELECT * FROM users", KindRead},
+		{"EXPLAIN ANALYZE SELECT * FROM users", KindRead},
+		{"WITH t AS (SELECT 1) SELECT * FROM t", KindRead},
+		{"SELECT `update` FROM t -- DROP TABLE t", KindRead},
+		{"SELECT count(*), replace(name, 'a', 'b'), insert(name, 1, 1, 'x') FROM users", KindRead},
+		{"SELECT $$DROP TABLE users$$", KindRead},
+		{"SELECT $1::int", KindRead},
+		{"INSERT INTO users (name) VALUES ('a')", KindWrite},
+		{"update users set name = 'x'", KindWrite},
+		{"DELETE FROM users", KindWrite},
+		{"PRAGMA writable_schema = ON", KindWrite},
+		{"SET GLOBAL read_only = 0", KindWrite},
+		{"CREATE TABLE t (id int)", KindDDL},
+		{"DROP TABLE users", KindDDL},
+		{"GRANT ALL ON users TO bob", KindDDL},
+		{"SELECT * FROM users INTO OUTFILE '/tmp/users.csv'", KindWrite},
+		{"SELECT * INTO backup FROM users", KindWrite},
+		{"SELECT pg_terminate_backend(pid) FROM pg_stat_activity", KindWrite},
+		{"SELECT pg_catalog.pg_read_file('/etc/passwd')", KindWrite},
+		{"SELECT SLEEP (10)", KindWrite},
+		{"SELECT load_extension('evil.so')", KindWrite},
+		{"SELECT * FROM users FOR UPDATE", KindWrite},
+		{"SELECT * FROM users LOCK IN SHARE MODE", KindWrite},
+		{"WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", KindWrite},
+		{"EXPLAIN ANALYZE DELETE FROM users", KindWrite},
+		{"EXPLAIN (ANALYZE) UPDATE users SET name = 'x'", KindWrite},
+		{"FOO BAR", KindUnknown},
+	} {
+		statement, err := Classify(tc.query)
+		if err != nil {
+			t.Errorf("Classify(%q): unexpected error %v", tc.query, err)
+			continue
+		}
+		if statement.Kind != tc.kind {
+			t.Errorf("Classify(%q) = %s, want %s", tc.query, statement.Kind, tc.kind)
+		}
+	}
+}
+
+func TestClassifyRejectsHiddenStatements(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"-- only a comment",
+		"SELECT 1; DELETE FROM users",
+		"SELECT 1;;DROP TABLE users;",
+		// PostgreSQL 中反斜杠不转义，字符串在 \ 处结束
+		`SELECT 'a\'; DROP TABLE users; --'`,
+		// PostgreSQL 中 # 不是注释
+		"SELECT 1 # 2; DROP TABLE users",
+		// MySQL 中 -- 后须有空白才是注释
+		"SELECT 1--1; DROP TABLE users",
+		"SELECT /*! 1; DROP TABLE users */",
+		"SELECT 'unterminated",
+		"SELECT /* unterminated",
+		"SELECT $tag$ unterminated",
+	} {
+		if _, err := Classify(query); err == nil {
+			t.Errorf("Classify(%q): expected an error", query)
+		}
+	}
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	if err := CheckReadOnly("SELECT 1;"); err != nil {
+		t.Errorf("SELECT 1: %v", err)
+	}
+	for _, query := range []string{"UPDATE users SET name = 'x'", "SELECT * FROM users INTO OUTFILE '/tmp/x'", "SELECT 1; SELECT 2"} {
+		if err := CheckReadOnly(query); err == nil {
+			t.Errorf("CheckReadOnly(%q): expected an error", query)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// contextLines 每个变更块前后保留的未修改行数
+	contextLines = 3
+	// maxCells 逐行比较的规模上限（行数乘积），超出时整体视为替换，避免超大文件占用过多内存
+	maxCells = 4 << 20
+)
+
+// op 一行的变更类型
+type op byte
+
+const (
+	opEqual  op = ' '
+	opDelete op = '-'
+	opInsert op = '+'
+)
+
+type line struct {
+	op   op
+	text string
+	// 该行在旧文件和新文件中的行号，从 0 开始
+	from, to int
+}
+
+// Unified 返回从 a 到 b 的统一格式差异，与 diff -u 的输出一致，内容相同时返回空字符串
+func Unified(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	lines := compare(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, hunk := range hunks(lines) {
+		writeHunk(&out, lines[hunk[0]:hunk[1]])
+	}
+	return out.String()
+}
+
+// splitLines 按行拆分，末尾的换行不产生空行
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// compare 通过最长公共子序列得到逐行的变更列表
+func compare(a, b []string) []line {
+	if len(a)*len(b) > maxCells {
+		lines := make([]line, 0, len(a)+len(b))
+		for i, text := range a {
+			lines = append(lines, line{op: opDelete, text: text, from: i, to: 0})
+		}
+		for j, text := range b {
+			lines = append(lines, line{op: opInsert, text: text, from: len(a), to: j})
+		}
+		return lines
+	}
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]line, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{op: opEqual, text: a[i], from: i, to: j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{op: opDelete, text: a[i], from: i, to: j})
+			i++
+		default:
+			lines = append(lines, line{op: opInsert, text: b[j], from: i, to: j})
+			j++
+		}
+	}
+	return lines
+}
+
+// hunks 将变更行连同前后的上下文分组，间隔不超过两倍上下文的变更合并到同一块，返回每块在 lines 中的范围
+func hunks(lines []line) [][2]int {
+	var result [][2]int
+	for i := 0; i < len(lines); i++ {
+		if lines[i].op == opEqual {
+			continue
+		}
+
+		start := max(i-contextLines, 0)
+		end := i
+		for k := i; k < len(lines); k++ {
+			if lines[k].op != opEqual {
+				end = k
+			} else if k-end > 2*contextLines {
+				break
+			}
+		}
+		end = min(end+contextLines+1, len(lines))
+
+		if n := len(result); n > 0 && start <= result[n-1][1] {
+			result[n-1][1] = end
+		} else {
+			result = append(result, [2]int{start, end})
+		}
+		i = end - 1
+	}
+	return result
+}
+
+// writeHunk 写入一个变更块及其 @@ 头
+func writeHunk(out *strings.Builder, lines []line) {
+	fromStart, toStart := lines[0].from, lines[0].to
+	fromCount, toCount := 0, 0
+	for _, l := range lines {
+		if l.op != opInsert {
+			fromCount++
+		}
+		if l.op != opDelete {
+			toCount++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(fromStart, fromCount), hunkRange(toStart, toCount))
+	for _, l := range lines {
+		out.WriteByte(byte(l.op))
+		out.WriteString(l.text)
+		out.WriteByte('\n')
+	}
+}
+
+// hunkRange 按 diff -u 的规则格式化起始行号和行数：行数为 1 时省略，为 0 时起始行号为前一行
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
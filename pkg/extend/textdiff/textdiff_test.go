@@ -0,0 +1,88 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	old := "server {\n    listen 80;\n    server_name a.com;\n    root /var/www/a;\n    index index.html;\n\n" +
+		"    location / {\n        try_files $uri $uri/ =404;\n    }\n    a\n    b\n    c\n    d\n    e\n    f\n    g\n    h\n}\n"
+	updated := strings.NewReplacer("root /var/www/a;", "root /srv/a;", "    g\n", "    G\n", "    index index.html;\n", "").Replace(old) + "    extra\n"
+
+	want := `--- a.conf
++++ a.conf.new
+@@ -1,8 +1,7 @@
+ server {
+     listen 80;
+     server_name a.com;
+-    root /var/www/a;
+-    index index.html;
++    root /srv/a;
+ 
+     location / {
+         try_files $uri $uri/ =404;
+@@ -13,6 +12,7 @@
+     d
+     e
+     f
+-    g
++    G
+     h
+ }
++    extra
+`
+	if got := Unified("a.conf", "a.conf.new", old, updated); got != want {
+		t.Errorf("unexpected diff:\n%s", got)
+	}
+}
+
+func TestUnifiedNewFile(t *testing.T) {
+	got := Unified("/dev/null", "b.conf", "", "server {\n}\n")
+	want := "--- /dev/null\n+++ b.conf\n@@ -0,0 +1,2 @@\n+server {\n+}\n"
+	if got != want {
+		t.Errorf("unexpected diff:\n%s", got)
+	}
+	if got := Unified("a", "b", "same\n", "same\n"); got != "" {
+		t.Errorf("identical content should produce no diff, got %q", got)
+	}
+}
+
+func TestUnifiedApplies(t *testing.T) {
+	// 大量相同的行可以有多种对齐方式，无论选择哪种，差异都必须能还原出新内容
+	old := strings.Repeat("}\n", 50) + "listen 80;\n" + strings.Repeat("}\n", 50)
+	updated := strings.Repeat("}\n", 20) + "listen 443 ssl;\n" + strings.Repeat("}\n", 81)
+
+	diff := Unified("old", "new", old, updated)
+	if got := apply(old, diff); got != updated {
+		t.Errorf("applying the diff does not reproduce the new content:\n%s", diff)
+	}
+}
+
+// apply 将统一格式差异应用到 text
+func apply(text, diff string) string {
+	source := splitLines(text)
+	var result []string
+	next := 0
+	for _, l := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n")[2:] {
+		switch {
+		case strings.HasPrefix(l, "@@"):
+			var start int
+			_, _ = fmt.Sscanf(l, "@@ -%d", &start)
+			for next < start-1 {
+				result = append(result, source[next])
+				next++
+			}
+		case strings.HasPrefix(l, "-"):
+			next++
+		case strings.HasPrefix(l, "+"):
+			result = append(result, l[1:])
+		default:
+			result = append(result, source[next])
+			next++
+		}
+	}
+	result = append(result, source[next:]...)
+	return strings.Join(result, "\n") + "\n"
+}
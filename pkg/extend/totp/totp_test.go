@@ -0,0 +1,85 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret RFC 6238 附录 B 中 SHA1 测试向量使用的密钥 "12345678901234567890"
+var rfc6238Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// RFC 给出的是 8 位验证码，这里取其后 6 位
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, v := range vectors {
+		code, err := Code(rfc6238Secret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("code at %d: %v", v.unix, err)
+		}
+		if code != v.code {
+			t.Errorf("code at %d: expected %s, got %s", v.unix, v.code, code)
+		}
+	}
+}
+
+func TestValidateAllowsSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+
+	for offset := int64(-2); offset <= 2; offset++ {
+		code, _ := Code(rfc6238Secret, step+offset)
+		matched, ok := Validate(rfc6238Secret, code, now)
+
+		want := offset >= -Skew && offset <= Skew
+		if ok != want {
+			t.Errorf("offset %d: expected ok=%v, got %v", offset, want, ok)
+		}
+		if ok && matched != step+offset {
+			t.Errorf("offset %d: expected step %d, got %d", offset, step+offset, matched)
+		}
+	}
+
+	if _, ok := Validate(rfc6238Secret, "12345", now); ok {
+		t.Error("short code should be rejected")
+	}
+	if _, ok := Validate("not base32!", "123456", now); ok {
+		t.Error("invalid secret should be rejected")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("unexpected recovery code format: %q", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate recovery code: %q", code)
+		}
+		seen[code] = true
+	}
+
+	// 哈希忽略大小写和分隔符
+	code := codes[0]
+	if HashRecoveryCode(code) != HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", ""))) {
+		t.Error("recovery code hash should ignore case and separators")
+	}
+}
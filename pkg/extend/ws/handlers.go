@@ -11,6 +11,8 @@ var upgrader = websocket.Upgrader{
 		// 允许所有来源（生产环境中应该更严格）
 		return true
 	},
+	// 回显客户端用于传递 token 的子协议，否则浏览器会拒绝握手
+	Subprotocols:    []string{"access_token"},
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 }
@@ -39,7 +41,7 @@ func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 创建WebSocket连接
-	_, err = h.manager.CreateConnection(conn, h.path)
+	_, err = h.manager.CreateConnection(conn, h.path, r)
 	if err != nil {
 		conn.Close()
 		http.Error(w, "Failed to create WebSocket connection", http.StatusInternalServerError)
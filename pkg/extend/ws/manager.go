@@ -2,7 +2,9 @@ package ws
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
@@ -10,12 +12,20 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+var (
+	// ErrConnectionClosed 连接已关闭
+	ErrConnectionClosed = errors.New("connection is closed")
+	// ErrSendBufferFull 发送缓冲区已满，客户端读取跟不上
+	ErrSendBufferFull = errors.New("send buffer is full")
+)
+
 // Connection 表示一个WebSocket连接
 type Connection struct {
 	ID      string
 	Conn    *websocket.Conn
 	Send    chan []byte
 	Handler ConnectionHandler
+	Request *http.Request // 握手请求，处理器可从其 Context 中读取认证信息
 	manager *Manager
 	mutex   sync.Mutex
 	closed  bool
@@ -76,7 +86,7 @@ func (m *Manager) RegisterHandler(path string, handler ConnectionHandler) {
 }
 
 // CreateConnection 创建新的WebSocket连接
-func (m *Manager) CreateConnection(conn *websocket.Conn, path string) (*Connection, error) {
+func (m *Manager) CreateConnection(conn *websocket.Conn, path string, r *http.Request) (*Connection, error) {
 	handler, exists := m.handlers[path]
 	if !exists {
 		return nil, fmt.Errorf("no handler registered for path: %s", path)
@@ -87,6 +97,7 @@ func (m *Manager) CreateConnection(conn *websocket.Conn, path string) (*Connecti
 		Conn:    conn,
 		Send:    make(chan []byte, 256),
 		Handler: handler,
+		Request: r,
 		manager: m,
 	}
 
@@ -209,14 +220,14 @@ func (c *Connection) SendMessage(message Message) error {
 	defer c.mutex.Unlock()
 
 	if c.closed {
-		return fmt.Errorf("connection is closed")
+		return ErrConnectionClosed
 	}
 
 	select {
 	case c.Send <- data:
 		return nil
 	default:
-		return fmt.Errorf("send buffer is full")
+		return ErrSendBufferFull
 	}
 }
 
@@ -0,0 +1,167 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ai"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// defaultAgentSteps 默认最多与模型交互的轮数
+	defaultAgentSteps = 10
+	// maxAgentSteps 允许请求指定的最大轮数
+	maxAgentSteps = 20
+)
+
+// AgentMessage is one entry of the agent transcript
+type AgentMessage struct {
+	Role       string          `json:"role"`
+	Content    string          `json:"content,omitempty"`
+	ToolCalls  []ToolCall      `json:"tool_calls,omitempty"`
+	ToolResult *ToolCallResult `json:"tool_result,omitempty"`
+}
+
+// AgentResult is the outcome of an agent run
+type AgentResult struct {
+	Answer     string         `json:"answer"`
+	Completed  bool           `json:"completed"` // false when the step limit was reached before the model answered
+	Steps      int            `json:"steps"`
+	Transcript []AgentMessage `json:"transcript"`
+}
+
+// ToolGate decides whether a tool call may run right away; when it returns false the returned result is sent to the model instead
+type ToolGate func(call ToolCall, risk RiskLevel) (ToolCallResult, bool)
+
+// Agent runs a multi-turn function-calling loop, executing tool calls through a ToolCallReceiver
+type Agent struct {
+	Client       *ai.Client
+	Receiver     *ToolCallReceiver
+	ToolChain    *ToolChain
+	Gate         ToolGate // optional, nil runs every call immediately
+	SystemPrompt string
+	History      []openai.ChatCompletionMessage // earlier turns of the conversation, sent between the system prompt and the new message
+	MaxSteps     int
+}
+
+// NewDatabaseAgent creates an agent equipped with the database tool chain
+func NewDatabaseAgent(client *ai.Client, receiver *ToolCallReceiver) *Agent {
+	return &Agent{
+		Client:       client,
+		Receiver:     receiver,
+		ToolChain:    GenerateToolChain(),
+		SystemPrompt: ai.DatabaseAgentPrompt,
+		MaxSteps:     defaultAgentSteps,
+	}
+}
+
+// Run sends the user message, after any History, to the model and keeps executing the returned tool calls,
+// feeding their results back until the model answers or the step limit is reached.
+// The transcript collected so far is returned together with any error.
+func (a *Agent) Run(ctx context.Context, message string) (AgentResult, error) {
+	result := AgentResult{
+		Transcript: []AgentMessage{{Role: openai.ChatMessageRoleUser, Content: message}},
+	}
+
+	messages := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleSystem, Content: a.SystemPrompt}}
+	messages = append(messages, a.History...)
+	messages = append(messages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: message})
+	tools := a.ToolChain.OpenAITools()
+
+	for result.Steps < a.MaxSteps {
+		result.Steps++
+
+		resp, err := a.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+			Messages: messages,
+			Tools:    tools,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		reply := resp.Choices[0].Message
+		messages = append(messages, reply)
+
+		if len(reply.ToolCalls) == 0 {
+			result.Answer = reply.Content
+			result.Completed = true
+			result.Transcript = append(result.Transcript, AgentMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: reply.Content,
+			})
+			return result, nil
+		}
+
+		calls := make([]ToolCall, 0, len(reply.ToolCalls))
+		for _, call := range reply.ToolCalls {
+			calls = append(calls, convertToolCall(call))
+		}
+		result.Transcript = append(result.Transcript, AgentMessage{
+			Role:      openai.ChatMessageRoleAssistant,
+			Content:   reply.Content,
+			ToolCalls: calls,
+		})
+
+		for _, call := range calls {
+			callResult := a.execute(call)
+
+			content, err := json.Marshal(callResult)
+			if err != nil {
+				return result, fmt.Errorf("failed to encode tool result: %w", err)
+			}
+
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				Content:    string(content),
+				ToolCallID: call.ID,
+			})
+			result.Transcript = append(result.Transcript, AgentMessage{
+				Role:       openai.ChatMessageRoleTool,
+				ToolResult: &callResult,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// execute runs a single tool call, reporting malformed arguments back to the model instead of failing the run
+func (a *Agent) execute(call ToolCall) ToolCallResult {
+	if call.Function.Arguments == nil {
+		return ToolCallResult{
+			ToolCallID: call.ID,
+			Error:      "tool arguments must be a JSON object",
+		}
+	}
+
+	if a.Gate != nil {
+		if result, ok := a.Gate(call, a.ToolChain.RiskOfCall(call)); !ok {
+			return result
+		}
+	}
+
+	results := a.Receiver.ProcessToolCalls([]ToolCall{call})
+	return results[0]
+}
+
+// convertToolCall converts an OpenAI tool call, whose arguments are a JSON string, to a ToolCall
+func convertToolCall(call openai.ToolCall) ToolCall {
+	converted := ToolCall{
+		ID:   call.ID,
+		Type: string(call.Type),
+		Function: FunctionCall{
+			Name: call.Function.Name,
+		},
+	}
+
+	arguments := map[string]interface{}{}
+	if call.Function.Arguments != "" {
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &arguments); err != nil {
+			return converted
+		}
+	}
+	converted.Function.Arguments = arguments
+	return converted
+}
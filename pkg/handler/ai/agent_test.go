@@ -0,0 +1,563 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ai"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/dbmgr"
+	dbmgr2 "github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/dbmgr"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/sashabaranov/go-openai"
+)
+
+// mockLLM is a local OpenAI-compatible server that replays scripted assistant messages
+type mockLLM struct {
+	mu       sync.Mutex
+	replies  []openai.ChatCompletionMessage
+	requests []openai.ChatCompletionRequest
+}
+
+func (m *mockLLM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/chat/completions" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req openai.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	m.requests = append(m.requests, req)
+	if len(m.replies) == 0 {
+		m.mu.Unlock()
+		http.Error(w, `{"error":{"message":"no scripted reply left"}}`, http.StatusInternalServerError)
+		return
+	}
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+		ID:    "mock",
+		Model: req.Model,
+		Choices: []openai.ChatCompletionChoice{
+			{Index: 0, Message: reply, FinishReason: openai.FinishReasonStop},
+		},
+	})
+}
+
+func newMockAgent(t *testing.T, replies ...openai.ChatCompletionMessage) (*Agent, *mockLLM) {
+	t.Helper()
+
+	mock := &mockLLM{replies: replies}
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+
+	client, err := ai.NewClient(config.AIConfig{
+		Provider: "custom",
+		BaseURL:  server.URL,
+		Model:    "mock-model",
+	})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+
+	receiver := NewToolCallReceiver()
+	t.Cleanup(func() { _ = receiver.Close() })
+
+	return NewDatabaseAgent(client, receiver), mock
+}
+
+func toolCallMessage(id, name string, args map[string]interface{}) openai.ChatCompletionMessage {
+	encoded, _ := json.Marshal(args)
+	return openai.ChatCompletionMessage{
+		Role: openai.ChatMessageRoleAssistant,
+		ToolCalls: []openai.ToolCall{{
+			ID:   id,
+			Type: openai.ToolTypeFunction,
+			Function: openai.FunctionCall{
+				Name:      name,
+				Arguments: string(encoded),
+			},
+		}},
+	}
+}
+
+// allowSQLiteDir 将目录加入 AI 工具和已保存连接可打开的 SQLite 目录，测试结束后恢复配置
+func allowSQLiteDir(t *testing.T, dir string) {
+	t.Helper()
+
+	original := config.AppConfig
+	cfg := config.Config{}
+	if original != nil {
+		cfg = *original
+	}
+	cfg.AI.SQLiteDirs = append(append([]string{}, cfg.AI.SQLiteDirs...), dir)
+	cfg.Query.SQLiteDirs = append(append([]string{}, cfg.Query.SQLiteDirs...), dir)
+	config.AppConfig = &cfg
+	t.Cleanup(func() { config.AppConfig = original })
+}
+
+// createTestDatabase 在允许的目录中创建带 users 表的 SQLite 数据库
+func createTestDatabase(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	allowSQLiteDir(t, dir)
+	return seedTestDatabase(t, filepath.Join(dir, "app.db"))
+}
+
+func seedTestDatabase(t *testing.T, path string) string {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT); INSERT INTO users (name) VALUES ('alice'), ('bob');`); err != nil {
+		t.Fatalf("seed sqlite: %v", err)
+	}
+	return path
+}
+
+func TestAgentExecutesToolCallsUntilAnswer(t *testing.T) {
+	dbPath := createTestDatabase(t)
+
+	agent, mock := newMockAgent(t,
+		toolCallMessage("call_1", "connect_sqlite_database", map[string]interface{}{"database_path": dbPath}),
+		toolCallMessage("call_2", "execute_query", map[string]interface{}{"query": "SELECT COUNT(*) AS total FROM users"}),
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "users 表共有 2 行"},
+	)
+
+	result, err := agent.Run(context.Background(), "统计 users 表的行数")
+	if err != nil {
+		t.Fatalf("run agent: %v", err)
+	}
+
+	if !result.Completed || result.Answer != "users 表共有 2 行" {
+		t.Fatalf("unexpected result: completed=%v answer=%q", result.Completed, result.Answer)
+	}
+	if result.Steps != 3 {
+		t.Errorf("expected 3 steps, got %d", result.Steps)
+	}
+
+	// user, assistant+call, tool, assistant+call, tool, assistant
+	if len(result.Transcript) != 6 {
+		t.Fatalf("expected 6 transcript entries, got %d", len(result.Transcript))
+	}
+	for _, i := range []int{2, 4} {
+		entry := result.Transcript[i]
+		if entry.Role != openai.ChatMessageRoleTool || entry.ToolResult == nil || !entry.ToolResult.Success {
+			t.Errorf("transcript[%d]: expected successful tool result, got %+v", i, entry)
+		}
+	}
+
+	// 每次请求都必须携带工具定义，且工具结果要回传给模型
+	if len(mock.requests) != 3 {
+		t.Fatalf("expected 3 requests to the model, got %d", len(mock.requests))
+	}
+	for i, req := range mock.requests {
+		if len(req.Tools) == 0 {
+			t.Errorf("request %d: tools were not sent", i)
+		}
+		if req.Model != "mock-model" {
+			t.Errorf("request %d: expected configured model, got %q", i, req.Model)
+		}
+	}
+
+	last := mock.requests[2].Messages
+	toolMessage := last[len(last)-1]
+	if toolMessage.Role != openai.ChatMessageRoleTool || toolMessage.ToolCallID != "call_2" {
+		t.Fatalf("expected last message to be the result of call_2, got %+v", toolMessage)
+	}
+	if !strings.Contains(toolMessage.Content, `"total":2`) {
+		t.Errorf("tool result not fed back to the model: %s", toolMessage.Content)
+	}
+}
+
+func TestAgentReportsToolErrorsToModel(t *testing.T) {
+	agent, mock := newMockAgent(t,
+		toolCallMessage("call_1", "list_tables", map[string]interface{}{}),
+		openai.ChatCompletionMessage{
+			Role: openai.ChatMessageRoleAssistant,
+			ToolCalls: []openai.ToolCall{{
+				ID:       "call_2",
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: "execute_query", Arguments: "{not json"},
+			}},
+		},
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "请先提供数据库路径"},
+	)
+
+	result, err := agent.Run(context.Background(), "列出所有表")
+	if err != nil {
+		t.Fatalf("run agent: %v", err)
+	}
+	if !result.Completed {
+		t.Fatal("expected the agent to complete")
+	}
+
+	for _, i := range []int{2, 4} {
+		entry := result.Transcript[i]
+		if entry.ToolResult == nil || entry.ToolResult.Success || entry.ToolResult.Error == "" {
+			t.Errorf("transcript[%d]: expected failed tool result, got %+v", i, entry.ToolResult)
+		}
+	}
+
+	if got := mock.requests[1].Messages; !strings.Contains(got[len(got)-1].Content, "no active database connection") {
+		t.Errorf("tool error not fed back to the model: %s", got[len(got)-1].Content)
+	}
+}
+
+func TestAgentStopsAtStepLimit(t *testing.T) {
+	agent, _ := newMockAgent(t,
+		toolCallMessage("call_1", "list_tables", map[string]interface{}{}),
+		toolCallMessage("call_2", "list_tables", map[string]interface{}{}),
+		toolCallMessage("call_3", "list_tables", map[string]interface{}{}),
+	)
+	agent.MaxSteps = 2
+
+	result, err := agent.Run(context.Background(), "循环调用")
+	if err != nil {
+		t.Fatalf("run agent: %v", err)
+	}
+	if result.Completed || result.Steps != 2 {
+		t.Errorf("expected incomplete run after 2 steps, got completed=%v steps=%d", result.Completed, result.Steps)
+	}
+}
+
+func TestAgentReturnsTranscriptOnProviderError(t *testing.T) {
+	agent, _ := newMockAgent(t,
+		toolCallMessage("call_1", "list_tables", map[string]interface{}{}),
+	)
+
+	result, err := agent.Run(context.Background(), "列出所有表")
+	if err == nil {
+		t.Fatal("expected an error once the model stops responding")
+	}
+	if len(result.Transcript) != 3 {
+		t.Errorf("expected partial transcript with 3 entries, got %d", len(result.Transcript))
+	}
+}
+
+func TestAgentGateHoldsDestructiveCalls(t *testing.T) {
+	dbPath := createTestDatabase(t)
+
+	agent, mock := newMockAgent(t,
+		toolCallMessage("call_1", "connect_sqlite_database", map[string]interface{}{"database_path": dbPath}),
+		toolCallMessage("call_2", "drop_table", map[string]interface{}{"table_name": "users"}),
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "删除操作已提交审批"},
+	)
+
+	var gated []string
+	agent.Gate = func(call ToolCall, risk RiskLevel) (ToolCallResult, bool) {
+		if risk != RiskDestructive {
+			return ToolCallResult{}, true
+		}
+		gated = append(gated, call.Function.Name)
+		return ToolCallResult{
+			ToolCallID: call.ID,
+			Result:     map[string]interface{}{"pending_approval": true},
+		}, false
+	}
+
+	result, err := agent.Run(context.Background(), "删除 users 表")
+	if err != nil {
+		t.Fatalf("run agent: %v", err)
+	}
+	if !result.Completed {
+		t.Fatal("expected the agent to complete")
+	}
+
+	if len(gated) != 1 || gated[0] != "drop_table" {
+		t.Fatalf("expected only drop_table to be gated, got %v", gated)
+	}
+	if got := mock.requests[2].Messages; !strings.Contains(got[len(got)-1].Content, "pending_approval") {
+		t.Errorf("pending result not fed back to the model: %s", got[len(got)-1].Content)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		t.Fatalf("users table should still exist: %v", err)
+	}
+}
+
+func TestAgentGateHoldsWritesThroughReadTools(t *testing.T) {
+	dbPath := createTestDatabase(t)
+
+	agent, _ := newMockAgent(t,
+		toolCallMessage("call_1", "connect_sqlite_database", map[string]interface{}{"database_path": dbPath}),
+		toolCallMessage("call_2", "execute_query", map[string]interface{}{"query": "DELETE FROM users"}),
+		toolCallMessage("call_3", "create_table", map[string]interface{}{"create_sql": "DROP TABLE users"}),
+		toolCallMessage("call_4", "execute_query", map[string]interface{}{"query": "SELECT COUNT(*) FROM users"}),
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "已提交审批"},
+	)
+
+	var gated []string
+	agent.Gate = func(call ToolCall, risk RiskLevel) (ToolCallResult, bool) {
+		if risk != RiskDestructive {
+			return ToolCallResult{}, true
+		}
+		gated = append(gated, call.ID)
+		return ToolCallResult{ToolCallID: call.ID, Result: map[string]interface{}{"pending_approval": true}}, false
+	}
+
+	if _, err := agent.Run(context.Background(), "清空 users 表"); err != nil {
+		t.Fatalf("run agent: %v", err)
+	}
+	if len(gated) != 2 || gated[0] != "call_2" || gated[1] != "call_3" {
+		t.Fatalf("expected the DELETE and DROP calls to be held, got %v", gated)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil || count != 2 {
+		t.Fatalf("users table should be untouched, got count=%d err=%v", count, err)
+	}
+}
+
+func TestCreateTableRequiresCreateStatement(t *testing.T) {
+	dbPath := createTestDatabase(t)
+	receiver := NewToolCallReceiver()
+	defer receiver.Close()
+
+	call := func(name string, args map[string]interface{}) ToolCallResult {
+		return receiver.ProcessToolCall(ToolCall{ID: name, Function: FunctionCall{Name: name, Arguments: args}})
+	}
+	if r := call("connect_sqlite_database", map[string]interface{}{"database_path": dbPath}); !r.Success {
+		t.Fatalf("connect: %s", r.Error)
+	}
+
+	for statement, want := range map[string]bool{
+		"CREATE TABLE logs (id INTEGER PRIMARY KEY)":    true,
+		"create table audit (id integer);":              true,
+		"DROP TABLE users":                              false,
+		"CREATE TABLE t (id INTEGER); DROP TABLE users": false,
+		"CREATE INDEX idx_users_name ON users (name)":   false,
+		"DELETE FROM users":                             false,
+	} {
+		if r := call("create_table", map[string]interface{}{"create_sql": statement}); r.Success != want {
+			t.Errorf("create_table %q: expected success=%v, got %+v", statement, want, r)
+		}
+	}
+}
+
+func TestExecuteQueryIsReadOnly(t *testing.T) {
+	dbPath := createTestDatabase(t)
+	receiver := NewToolCallReceiver()
+	defer receiver.Close()
+
+	call := func(name string, args map[string]interface{}) ToolCallResult {
+		return receiver.ProcessToolCall(ToolCall{ID: name, Function: FunctionCall{Name: name, Arguments: args}})
+	}
+	if r := call("connect_sqlite_database", map[string]interface{}{"database_path": dbPath}); !r.Success {
+		t.Fatalf("connect: %s", r.Error)
+	}
+
+	for _, query := range []string{"DELETE FROM users", "SELECT load_extension('x')", "SELECT 1; DROP TABLE users"} {
+		if r := call("execute_query", map[string]interface{}{"query": query}); r.Success {
+			t.Errorf("execute_query %q: expected rejection", query)
+		}
+	}
+	if r := call("execute_query", map[string]interface{}{"query": "SELECT COUNT(*) AS total FROM users"}); !r.Success {
+		t.Fatalf("select: %s", r.Error)
+	}
+}
+
+func TestToolRiskLevels(t *testing.T) {
+	chain := GenerateToolChain()
+
+	for name, want := range map[string]RiskLevel{
+		"execute_query":     RiskRead,
+		"create_table":      RiskWrite,
+		"execute_statement": RiskDestructive,
+		"drop_table":        RiskDestructive,
+		"unknown_tool":      RiskDestructive,
+	} {
+		if got := chain.RiskOf(name); got != want {
+			t.Errorf("RiskOf(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestConnectionToolsKeyedByConnectionID(t *testing.T) {
+	first, second := createTestDatabase(t), createTestDatabase(t)
+
+	saved := map[uint]models.DatabaseConnection{
+		1: {Name: "first", Engine: dbmgr.EngineSQLite, Path: first},
+		2: {Name: "second", Engine: dbmgr.EngineSQLite, Path: second},
+	}
+	original := findConnection
+	findConnection = func(id uint) (models.DatabaseConnection, error) {
+		conn, ok := saved[id]
+		if !ok {
+			return conn, errors.New("not found")
+		}
+		return conn, nil
+	}
+	t.Cleanup(func() { findConnection = original })
+
+	receiver := NewToolCallReceiver()
+	defer receiver.Close()
+
+	call := func(name string, args map[string]interface{}) ToolCallResult {
+		return receiver.ProcessToolCall(ToolCall{ID: name, Function: FunctionCall{Name: name, Arguments: args}})
+	}
+
+	if r := call("query_connection", map[string]interface{}{"connection_id": float64(2), "query": "DELETE FROM users"}); r.Success {
+		t.Fatal("expected write statement to be rejected")
+	}
+	if r := call("query_connection", map[string]interface{}{"connection_id": float64(2), "query": "SELECT 1; DELETE FROM users"}); r.Success {
+		t.Fatal("expected stacked statements to be rejected")
+	}
+	if r := call("query_connection", map[string]interface{}{"connection_id": float64(3), "query": "SELECT 1"}); r.Success || !strings.Contains(r.Error, "not found") {
+		t.Fatalf("expected unknown connection error, got %+v", r)
+	}
+
+	for _, id := range []float64{1, 2} {
+		r := call("query_connection", map[string]interface{}{"connection_id": id, "query": "SELECT COUNT(*) AS total FROM users"})
+		if !r.Success {
+			t.Fatalf("connection %v: %s", id, r.Error)
+		}
+	}
+	if len(receiver.connections) != 2 {
+		t.Errorf("expected 2 open connections, got %d", len(receiver.connections))
+	}
+
+	if r := call("describe_connection_table", map[string]interface{}{"connection_id": "1", "table_name": "users"}); !r.Success {
+		t.Errorf("describe table: %s", r.Error)
+	}
+}
+
+func TestConnectSQLiteDatabaseRestrictsPaths(t *testing.T) {
+	allowed := createTestDatabase(t)
+	panelDB := seedTestDatabase(t, filepath.Join(filepath.Dir(allowed), "data.db"))
+	outside := seedTestDatabase(t, filepath.Join(t.TempDir(), "other.db"))
+	saved := seedTestDatabase(t, filepath.Join(t.TempDir(), "saved.db"))
+	savedOutside := seedTestDatabase(t, filepath.Join(t.TempDir(), "legacy.db"))
+	link := filepath.Join(filepath.Dir(allowed), "link.db")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	config.AppConfig.Database.Path = panelDB
+	config.AppConfig.Query.SQLiteDirs = append(config.AppConfig.Query.SQLiteDirs, filepath.Dir(saved))
+
+	original := savedSQLitePaths
+	savedSQLitePaths = func() ([]string, error) { return []string{saved, savedOutside}, nil }
+	t.Cleanup(func() { savedSQLitePaths = original })
+
+	receiver := NewToolCallReceiver()
+	defer receiver.Close()
+
+	for path, want := range map[string]bool{
+		allowed:      true,
+		saved:        true,
+		savedOutside: false,
+		panelDB:      false,
+		outside:      false,
+		link:         false,
+		filepath.Join(filepath.Dir(allowed), "missing.db"): false,
+	} {
+		r := receiver.ProcessToolCall(ToolCall{ID: "connect", Function: FunctionCall{
+			Name:      "connect_sqlite_database",
+			Arguments: map[string]interface{}{"database_path": path},
+		}})
+		if r.Success != want {
+			t.Errorf("connect %s: expected success=%v, got %+v", path, want, r)
+		}
+	}
+}
+
+func TestBackupDatabaseConfinedToBackupRoot(t *testing.T) {
+	dbPath := createTestDatabase(t)
+	root := t.TempDir()
+	config.AppConfig.Backup.Root = root
+
+	receiver := NewToolCallReceiver()
+	defer receiver.Close()
+
+	call := func(name string, args map[string]interface{}) ToolCallResult {
+		return receiver.ProcessToolCall(ToolCall{ID: name, Function: FunctionCall{Name: name, Arguments: args}})
+	}
+	if r := call("connect_sqlite_database", map[string]interface{}{"database_path": dbPath}); !r.Success {
+		t.Fatalf("connect: %s", r.Error)
+	}
+
+	for _, path := range []string{"../escape.db", filepath.Join(t.TempDir(), "outside.db")} {
+		if r := call("backup_database", map[string]interface{}{"backup_path": path}); r.Success {
+			t.Errorf("backup to %s: expected rejection", path)
+		}
+	}
+
+	if r := call("backup_database", map[string]interface{}{"backup_path": "ai/app.db"}); !r.Success {
+		t.Fatalf("backup inside root: %s", r.Error)
+	}
+	if _, err := os.Stat(filepath.Join(root, "ai", "app.db")); err != nil {
+		t.Fatalf("expected backup file in the backup root: %v", err)
+	}
+}
+
+func TestQueryConnectionRejectsUnsafeReads(t *testing.T) {
+	dbPath := createTestDatabase(t)
+
+	original := findConnection
+	findConnection = func(id uint) (models.DatabaseConnection, error) {
+		return models.DatabaseConnection{Name: "app", Engine: dbmgr.EngineSQLite, Path: dbPath}, nil
+	}
+	t.Cleanup(func() { findConnection = original })
+
+	receiver := NewToolCallReceiver()
+	defer receiver.Close()
+
+	for query, ok := range map[string]bool{
+		"SELECT COUNT(*) AS total FROM users":                       true,
+		"SELECT * FROM users INTO OUTFILE '/tmp/users.csv'":         false,
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity":    false,
+		"WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d": false,
+		"EXPLAIN ANALYZE DELETE FROM users":                         false,
+		`SELECT 'a\'; DELETE FROM users; --'`:                       false,
+	} {
+		r := receiver.ProcessToolCall(ToolCall{ID: "q", Function: FunctionCall{
+			Name:      "query_connection",
+			Arguments: map[string]interface{}{"connection_id": float64(1), "query": query},
+		}})
+		if r.Success != ok {
+			t.Errorf("query_connection %q: expected success=%v, got %+v", query, ok, r)
+		}
+	}
+
+	for command, ok := range map[string]bool{"HGETALL user:1": true, "FLUSHALL": false, "set k v": false} {
+		if err := dbmgr2.CheckReadOnlyQuery(dbmgr.EngineRedis, command); (err == nil) != ok {
+			t.Errorf("redis %q: expected ok=%v, got %v", command, ok, err)
+		}
+	}
+}
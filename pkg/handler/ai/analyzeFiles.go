@@ -1,7 +1,6 @@
 package ai
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ai"
@@ -16,16 +15,18 @@ type AnalyzeFilesRequest struct {
 
 // AnalyzeFiles 智能文件分析
 // @Summary 智能文件分析和清理建议
-// @Description 使用Kimi AI分析文件列表，识别可清理的文件、重复文件和存储优化建议
+// @Description 使用AI分析文件列表，识别可清理的文件、重复文件和存储优化建议
 // @Tags AI助手
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body AnalyzeFilesRequest true "文件分析请求"
-// @Success 200 {object} handler.Response{data=[]map[string]string} "分析结果，包含清理建议、文件分类等"
+// @Success 200 {object} handler.Response{data=[]string} "建议删除的文件，均为绝对路径"
 // @Failure 400 {object} handler.Response "请求参数错误"
 // @Failure 401 {object} handler.Response "未授权"
-// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Failure 429 {object} handler.Response "本月AI用量已达到配额"
+// @Failure 502 {object} handler.Response "AI服务调用失败或返回格式错误"
+// @Failure 503 {object} handler.Response "AI服务未配置"
 // @Router /auth/ai/files [post]
 func AnalyzeFiles(c *gin.Context) {
 	var request AnalyzeFilesRequest
@@ -34,14 +35,17 @@ func AnalyzeFiles(c *gin.Context) {
 		return
 	}
 
-	// 使用 Kimi AI 进行文件分析
-	analyzeLogJSON := ai.NewChatWithKimi(ai.DirCleanPrompt, request.Files)
-	var analyzeLogResponse []map[string]string
-	err := json.Unmarshal([]byte(analyzeLogJSON), &analyzeLogResponse)
+	client, _, err := userClient(c, "files")
 	if err != nil {
-		handler.Respond(c, http.StatusInternalServerError, err, nil)
+		respondAIError(c, err)
 		return
 	}
-	handler.Respond(c, http.StatusOK, nil, analyzeLogResponse)
+
+	var paths []string
+	if err := client.ChatJSON(c.Request.Context(), ai.DirCleanTask, request.Files, &paths); err != nil {
+		respondAIError(c, err)
+		return
+	}
+	handler.Respond(c, http.StatusOK, nil, paths)
 	return
 }
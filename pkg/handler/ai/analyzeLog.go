@@ -1,7 +1,6 @@
 package ai
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ai"
@@ -16,16 +15,18 @@ type AnalyzeLogRequest struct {
 
 // AnalyzeLog 分析日志
 // @Summary 智能日志分析
-// @Description 使用Kimi AI分析日志内容，识别错误模式、异常情况和潜在问题
+// @Description 使用AI分析日志内容，识别错误模式、异常情况和潜在问题
 // @Tags AI助手
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body AnalyzeLogRequest true "日志分析请求"
-// @Success 200 {object} handler.Response{data=[]map[string]string} "分析结果，包含问题识别、建议解决方案等"
+// @Success 200 {object} handler.Response{data=[]ai.Problem} "分析结果，包含发现的问题及解决方式"
 // @Failure 400 {object} handler.Response "请求参数错误"
 // @Failure 401 {object} handler.Response "未授权"
-// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Failure 429 {object} handler.Response "本月AI用量已达到配额"
+// @Failure 502 {object} handler.Response "AI服务调用失败或返回格式错误"
+// @Failure 503 {object} handler.Response "AI服务未配置"
 // @Router /auth/ai/log [post]
 func AnalyzeLog(c *gin.Context) {
 	var request AnalyzeLogRequest
@@ -34,14 +35,17 @@ func AnalyzeLog(c *gin.Context) {
 		return
 	}
 
-	// 使用 Kimi AI 进行日志分析
-	analyzeLogJSON := ai.NewChatWithKimi(ai.LogAnalyzer, request.LogContent)
-	var analyzeLogResponse []map[string]string
-	err := json.Unmarshal([]byte(analyzeLogJSON), &analyzeLogResponse)
+	client, _, err := userClient(c, "log")
 	if err != nil {
-		handler.Respond(c, http.StatusInternalServerError, err, nil)
+		respondAIError(c, err)
 		return
 	}
-	handler.Respond(c, http.StatusOK, nil, analyzeLogResponse)
+
+	var problems []ai.Problem
+	if err := client.ChatJSON(c.Request.Context(), ai.LogAnalysisTask, request.LogContent, &problems); err != nil {
+		respondAIError(c, err)
+		return
+	}
+	handler.Respond(c, http.StatusOK, nil, problems)
 	return
 }
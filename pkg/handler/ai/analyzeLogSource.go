@@ -0,0 +1,226 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ai"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/logdigest"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/file"
+	"github.com/gin-gonic/gin"
+)
+
+// AnalyzeLogSourceRequest 服务器日志分析请求，source 为 file 时需指定 path，journal 时需指定 unit，docker 时需指定 container
+type AnalyzeLogSourceRequest struct {
+	Source    string `json:"source" binding:"required,oneof=file journal docker" example:"file"`
+	Path      string `json:"path" example:"/var/log/nginx/error.log"`
+	Unit      string `json:"unit" example:"nginx.service"`
+	Container string `json:"container" example:"web"`
+	Since     string `json:"since" example:"2h"` // 时间窗口起点，相对时长或 RFC3339 时间
+	Until     string `json:"until" example:""`   // 时间窗口终点，为空表示当前
+	Tail      int    `json:"tail" binding:"min=0" example:"5000"`
+}
+
+// AnalyzeLogSourceResponse 服务器日志分析结果
+type AnalyzeLogSourceResponse struct {
+	Stats   logdigest.Stats `json:"stats"`
+	Results []ai.Problem    `json:"results"`
+}
+
+// AnalyzeLogSource 分析服务器上的日志
+// @Summary 智能分析服务器日志
+// @Description 直接读取服务器上的日志文件、systemd 单元日志或容器日志，可按时间窗口或末尾行数截取。相似的行先聚合，大日志分块逐一总结后再合并，日志文件同样受文件管理的受保护路径限制
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body AnalyzeLogSourceRequest true "日志来源"
+// @Success 200 {object} handler.Response{data=AnalyzeLogSourceResponse} "分析结果，包含问题和解决方式"
+// @Failure 400 {object} handler.Response "请求参数错误或日志无法读取"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "受保护的路径"
+// @Failure 429 {object} handler.Response "本月AI用量已达到配额"
+// @Failure 502 {object} handler.Response "AI服务调用失败或返回格式错误"
+// @Failure 503 {object} handler.Response "AI服务未配置"
+// @Router /auth/ai/log/source [post]
+func AnalyzeLogSource(c *gin.Context) {
+	var request AnalyzeLogSourceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		handler.Respond(c, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	src, err := request.source(time.Now())
+	if err != nil {
+		handler.Respond(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if src.Kind == logdigest.SourceFile {
+		resolved, err := resolveLogPath(src.Path)
+		if errors.Is(err, errProtectedLogPath) {
+			handler.Respond(c, http.StatusForbidden, err.Error(), nil)
+			return
+		}
+		if err != nil {
+			handler.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		src.Path = resolved
+	}
+
+	client, _, err := userClient(c, "log_source")
+	if err != nil {
+		respondAIError(c, err)
+		return
+	}
+
+	aiConfig := config.AppConfig.AI
+	maxBytes := int64(aiConfig.LogMaxMB) * 1024 * 1024
+	reader, err := logdigest.Open(c.Request.Context(), src, maxBytes)
+	if err != nil {
+		handler.Respond(c, http.StatusBadRequest, "读取日志失败: "+err.Error(), nil)
+		return
+	}
+	digest, err := logdigest.Build(reader, src, logdigest.Options{
+		MaxBytes:   maxBytes,
+		ChunkChars: aiConfig.LogChunkChars,
+		MaxChunks:  aiConfig.LogMaxChunks,
+		FilterTime: src.Kind == logdigest.SourceFile,
+	})
+	reader.Close()
+	if err != nil {
+		handler.Respond(c, http.StatusBadRequest, "读取日志失败: "+err.Error(), nil)
+		return
+	}
+
+	response := AnalyzeLogSourceResponse{Stats: digest.Stats, Results: []ai.Problem{}}
+	if len(digest.Chunks) == 0 {
+		handler.Respond(c, http.StatusOK, "时间范围内没有日志", response)
+		return
+	}
+
+	if err := summarizeLogChunks(c.Request.Context(), client, digest, aiConfig.LogConcurrency, &response.Results); err != nil {
+		respondAIError(c, err)
+		return
+	}
+	handler.Respond(c, http.StatusOK, nil, response)
+}
+
+// source 将请求转换为日志来源
+func (r AnalyzeLogSourceRequest) source(now time.Time) (logdigest.Source, error) {
+	since, err := logdigest.ParseTime(r.Since, now)
+	if err != nil {
+		return logdigest.Source{}, err
+	}
+	until, err := logdigest.ParseTime(r.Until, now)
+	if err != nil {
+		return logdigest.Source{}, err
+	}
+
+	src := logdigest.Source{
+		Kind:      r.Source,
+		Path:      strings.TrimSpace(r.Path),
+		Unit:      strings.TrimSpace(r.Unit),
+		Container: strings.TrimSpace(r.Container),
+		Since:     since,
+		Until:     until,
+		Tail:      r.Tail,
+	}
+	return src, src.Validate()
+}
+
+// errProtectedLogPath 日志文件位于受保护的路径
+var errProtectedLogPath = errors.New("受保护的路径，不允许读取")
+
+// resolveLogPath 解析日志文件的真实路径，链接本身和指向的目标都必须不在受保护的路径中，
+// 面板自身的数据库和配置文件包含密钥，同样拒绝
+func resolveLogPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("日志文件 %s 无法访问: %v", path, err)
+	}
+	resolved, err := file.ResolvePath(abs)
+	if errors.Is(err, file.ErrProtectedPath) {
+		return "", errProtectedLogPath
+	}
+	if err == nil {
+		_, err = os.Stat(resolved)
+	}
+	if err != nil {
+		return "", fmt.Errorf("日志文件 %s 无法访问: %v", path, err)
+	}
+
+	for _, panelFile := range []string{config.AppConfig.Database.Path, "config.toml"} {
+		if panelFile == "" {
+			continue
+		}
+		if panelPath, err := realPath(panelFile); err == nil && panelPath == resolved {
+			return "", errProtectedLogPath
+		}
+	}
+	return resolved, nil
+}
+
+// summarizeLogChunks 以 map-reduce 方式分析日志并将问题列表解析到 problems：只有一个分块时直接分析，
+// 否则先并发总结每个分块，再合并为最终结果
+func summarizeLogChunks(ctx context.Context, client *ai.Client, digest logdigest.Digest, concurrency int, problems *[]ai.Problem) error {
+	header := fmt.Sprintf("（共 %d 行，聚合为 %d 类", digest.Lines, digest.Distinct)
+	if digest.Truncated {
+		header += "，日志过大，仅分析了最重要的部分"
+	}
+	header += "）\n"
+
+	if len(digest.Chunks) == 1 {
+		return client.ChatJSON(ctx, ai.LogAnalysisTask, header+digest.Chunks[0], problems)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	summaries := make([]string, len(digest.Chunks))
+	semaphore := make(chan struct{}, max(concurrency, 1))
+
+	// 记录最先出现的错误并取消其余分块，之后的错误多为取消导致
+	var firstErr error
+	var once sync.Once
+
+	var wg sync.WaitGroup
+	for i, chunk := range digest.Chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			summary, err := client.Chat(ctx, ai.LogChunkSummarizer, chunk)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			summaries[i] = summary
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+
+	var combined strings.Builder
+	combined.WriteString(header)
+	for i, summary := range summaries {
+		fmt.Fprintf(&combined, "片段 %d/%d：\n%s\n\n", i+1, len(summaries), strings.TrimSpace(summary))
+	}
+	return client.ChatJSON(ctx, ai.LogReduceTask, combined.String(), problems)
+}
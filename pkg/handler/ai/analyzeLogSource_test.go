@@ -0,0 +1,128 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ai"
+	"github.com/gin-gonic/gin"
+	"github.com/sashabaranov/go-openai"
+)
+
+// setupLogSource 将 AI 配置指向 mock 服务并挂载日志分析接口
+func setupLogSource(t *testing.T, replies ...string) (*gin.Engine, *mockLLM) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	setupAIDatabase(t)
+
+	mock := &mockLLM{}
+	for _, reply := range replies {
+		mock.replies = append(mock.replies, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: reply})
+	}
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+	config.AppConfig = &config.Config{
+		Database: config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "data.db")},
+		AI: config.AIConfig{
+			Provider:       "custom",
+			BaseURL:        server.URL,
+			Model:          "mock-model",
+			LogMaxMB:       1,
+			LogChunkChars:  600,
+			LogMaxChunks:   8,
+			LogConcurrency: 2,
+		},
+	}
+
+	r := gin.New()
+	r.POST("/log/source", AnalyzeLogSource)
+	return r, mock
+}
+
+func postLogSource(r *gin.Engine, request AnalyzeLogSourceRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest(http.MethodPost, "/log/source", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAnalyzeLogSourceMapReduce(t *testing.T) {
+	r, mock := setupLogSource(t, "上游连接被拒绝", "磁盘空间不足", `[{"problem":"上游服务不可用","solution":"检查 3000 端口的服务"}]`)
+
+	path := filepath.Join(t.TempDir(), "error.log")
+	var log strings.Builder
+	for i := 0; i < 12; i++ {
+		fmt.Fprintf(&log, "2024/01/01 12:00:00 [error] distinct failure %s while reading upstream\n", strings.Repeat("x", i+1))
+	}
+	if err := os.WriteFile(path, []byte(log.String()), 0644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	w := postLogSource(r, AnalyzeLogSourceRequest{Source: "file", Path: path})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data AnalyzeLogSourceResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Data.Stats.ChunkCount != 2 || resp.Data.Stats.Lines != 12 {
+		t.Fatalf("expected 12 lines in 2 chunks, got %+v", resp.Data.Stats)
+	}
+	if len(resp.Data.Results) != 1 || resp.Data.Results[0].Problem != "上游服务不可用" {
+		t.Fatalf("unexpected results %+v", resp.Data.Results)
+	}
+
+	if len(mock.requests) != 3 {
+		t.Fatalf("expected 2 chunk summaries and 1 reduce call, got %d requests", len(mock.requests))
+	}
+	for _, req := range mock.requests[:2] {
+		if !strings.HasPrefix(req.Messages[0].Content, ai.LogChunkSummarizer) {
+			t.Errorf("chunk request should use the summarizer prompt")
+		}
+	}
+	reduce := mock.requests[2].Messages[0].Content
+	if !strings.HasPrefix(reduce, ai.LogReducer) || !strings.Contains(reduce, "片段 2/2") {
+		t.Errorf("reduce request should combine the chunk summaries, got %q", reduce)
+	}
+}
+
+func TestAnalyzeLogSourceRejectsProtectedPaths(t *testing.T) {
+	r, mock := setupLogSource(t)
+
+	link := filepath.Join(t.TempDir(), "status.log")
+	if err := os.Symlink("/proc/self/status", link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	if err := os.WriteFile(config.AppConfig.Database.Path, []byte("panel"), 0600); err != nil {
+		t.Fatalf("write database: %v", err)
+	}
+
+	for _, path := range []string{"/proc/self/status", link, config.AppConfig.Database.Path} {
+		if w := postLogSource(r, AnalyzeLogSourceRequest{Source: "file", Path: path}); w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+	if w := postLogSource(r, AnalyzeLogSourceRequest{Source: "journal", Unit: "nginx; reboot"}); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid unit: expected 400, got %d", w.Code)
+	}
+	if len(mock.requests) != 0 {
+		t.Errorf("rejected requests must not reach the AI provider")
+	}
+}
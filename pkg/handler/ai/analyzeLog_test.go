@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func postAnalyzeLog(r *gin.Engine, content string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(AnalyzeLogRequest{LogContent: content})
+	req := httptest.NewRequest(http.MethodPost, "/log", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAnalyzeLogRepairsInvalidOutput(t *testing.T) {
+	r, mock := setupLogSource(t,
+		"发现以下问题：\n```json\n[{\"problem\":\"弱密码\"}]\n```",
+		"```json\n[{\"problem\":\"弱密码\",\"solution\":\"修改密码\"}]\n```",
+	)
+	r.POST("/log", AnalyzeLog)
+
+	w := postAnalyzeLog(r, "sshd: Failed password for root")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"solution":"修改密码"`) {
+		t.Fatalf("unexpected response %s", w.Body.String())
+	}
+
+	if len(mock.requests) != 2 {
+		t.Fatalf("expected one repair retry, got %d requests", len(mock.requests))
+	}
+	repair := mock.requests[1].Messages
+	if len(repair) != 3 || !strings.Contains(repair[2].Content, "缺少字段 solution") || !strings.Contains(repair[2].Content, `"required":["problem","solution"]`) {
+		t.Fatalf("repair request should carry the validation error and schema, got %+v", repair)
+	}
+}
+
+func TestAnalyzeLogRejectsOutputAfterRetry(t *testing.T) {
+	r, mock := setupLogSource(t, "日志一切正常", "确实没有问题")
+	r.POST("/log", AnalyzeLog)
+
+	w := postAnalyzeLog(r, "nginx: start worker processes")
+	if w.Code != http.StatusBadGateway || !strings.Contains(w.Body.String(), "AI 返回内容格式错误") {
+		t.Fatalf("expected 502 format error, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mock.requests) != 2 {
+		t.Fatalf("expected exactly one retry, got %d requests", len(mock.requests))
+	}
+}
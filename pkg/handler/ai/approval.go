@@ -0,0 +1,263 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/audit"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// approvalTTL 待审批的工具调用超过该时间未处理即过期
+const approvalTTL = 24 * time.Hour
+
+// approvalGate 返回一个 ToolGate：高风险工具调用不立即执行，而是写入待审批队列，并告知模型等待人工审批
+func approvalGate(c *gin.Context, receiver *ToolCallReceiver) ToolGate {
+	username := c.GetString("username")
+
+	return func(call ToolCall, risk RiskLevel) (ToolCallResult, bool) {
+		if risk != RiskDestructive {
+			return ToolCallResult{}, true
+		}
+
+		arguments, _ := json.Marshal(call.Function.Arguments)
+		target, _ := json.Marshal(receiver.Target())
+
+		approval := models.AIToolApproval{
+			Username:   username,
+			ToolCallID: call.ID,
+			ToolName:   call.Function.Name,
+			Arguments:  string(arguments),
+			Target:     string(target),
+			Risk:       string(risk),
+			Status:     models.AIApprovalPending,
+		}
+		if err := database.DbConn.Create(&approval).Error; err != nil {
+			return ToolCallResult{
+				ToolCallID: call.ID,
+				Error:      "failed to queue tool call for approval: " + err.Error(),
+			}, false
+		}
+
+		audit.Record(c, models.AuditAIToolQueued, approvalTarget(approval), true, approval.Arguments)
+
+		return ToolCallResult{
+			ToolCallID: call.ID,
+			Result: map[string]interface{}{
+				"pending_approval": true,
+				"approval_id":      approval.ID,
+			},
+			Error: "this is a destructive operation and has been queued for human approval; it has NOT been executed yet",
+		}, false
+	}
+}
+
+// approvalTarget 审计日志中标识一条审批记录
+func approvalTarget(approval models.AIToolApproval) string {
+	return fmt.Sprintf("%s#%d", approval.ToolName, approval.ID)
+}
+
+// expireApprovals 将超时未审批的记录标记为过期
+func expireApprovals() {
+	database.DbConn.Model(&models.AIToolApproval{}).
+		Where("status = ? AND created_at < ?", models.AIApprovalPending, time.Now().Add(-approvalTTL)).
+		Update("status", models.AIApprovalExpired)
+}
+
+// claimApproval 将待审批记录原子地切换到新状态，避免同一条记录被重复处理
+func claimApproval(c *gin.Context, status string) (*models.AIToolApproval, bool) {
+	expireApprovals()
+
+	var approval models.AIToolApproval
+	if err := database.DbConn.First(&approval, c.Param("id")).Error; err != nil {
+		handler.Respond(c, http.StatusNotFound, "审批记录不存在", nil)
+		return nil, false
+	}
+	if approval.Username == c.GetString("username") {
+		handler.Respond(c, http.StatusForbidden, "不能审批自己提交的调用", nil)
+		return nil, false
+	}
+
+	now := time.Now()
+	result := database.DbConn.Model(&models.AIToolApproval{}).
+		Where("id = ? AND status = ?", approval.ID, models.AIApprovalPending).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"decided_by": c.GetString("username"),
+			"decided_at": now,
+		})
+	if result.Error != nil {
+		handler.Respond(c, http.StatusInternalServerError, result.Error.Error(), nil)
+		return nil, false
+	}
+	if result.RowsAffected == 0 {
+		database.DbConn.First(&approval, approval.ID)
+		handler.Respond(c, http.StatusConflict, "该调用已处理，当前状态: "+approval.Status, nil)
+		return nil, false
+	}
+
+	approval.Status = status
+	approval.DecidedBy = c.GetString("username")
+	approval.DecidedAt = &now
+	return &approval, true
+}
+
+// runApproval 在记录的数据库目标上执行已批准的工具调用
+func runApproval(approval *models.AIToolApproval) ToolCallResult {
+	call := ToolCall{
+		ID:   approval.ToolCallID,
+		Type: "function",
+		Function: FunctionCall{
+			Name: approval.ToolName,
+		},
+	}
+	if err := json.Unmarshal([]byte(approval.Arguments), &call.Function.Arguments); err != nil || call.Function.Arguments == nil {
+		return ToolCallResult{ToolCallID: call.ID, Error: "invalid stored arguments"}
+	}
+
+	var target ToolTarget
+	if err := json.Unmarshal([]byte(approval.Target), &target); err != nil {
+		return ToolCallResult{ToolCallID: call.ID, Error: "invalid stored target"}
+	}
+
+	receiver := NewToolCallReceiver()
+	defer receiver.Close()
+
+	if err := receiver.RestoreTarget(target); err != nil {
+		return ToolCallResult{ToolCallID: call.ID, Error: err.Error()}
+	}
+	return receiver.ProcessToolCalls([]ToolCall{call})[0]
+}
+
+// GetToolApprovals 获取AI工具调用审批列表
+// @Summary 获取AI工具调用审批列表
+// @Description 获取AI助手提交的高风险工具调用，超过24小时未审批的记录自动过期
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "按状态过滤: pending, executed, failed, rejected, expired"
+// @Success 200 {object} handler.Response{data=[]models.AIToolApproval} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "权限不足"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/ai/approvals [get]
+func GetToolApprovals(c *gin.Context) {
+	expireApprovals()
+
+	query := database.DbConn.Order("id DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var approvals []models.AIToolApproval
+	if err := query.Find(&approvals).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, approvals)
+}
+
+// ApproveToolCall 批准并执行AI工具调用
+// @Summary 批准AI工具调用
+// @Description 批准一条待审批的高风险工具调用，并立即在原数据库上执行，审批结果写入审计日志
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "审批记录ID"
+// @Param request body models.AIApprovalDecisionRequest false "审批说明"
+// @Success 200 {object} handler.Response{data=models.AIToolApproval} "已执行，status 为 executed 或 failed"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "权限不足或审批自己提交的调用"
+// @Failure 404 {object} handler.Response "审批记录不存在"
+// @Failure 409 {object} handler.Response "该调用已处理或已过期"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/ai/approvals/{id}/approve [post]
+func ApproveToolCall(c *gin.Context) {
+	var request models.AIApprovalDecisionRequest
+	_ = c.ShouldBindJSON(&request)
+
+	approval, ok := claimApproval(c, models.AIApprovalExecuting)
+	if !ok {
+		return
+	}
+
+	// 执行或保存失败（包括 panic）时不能停留在 executing，否则记录既无法重试也无法再处理
+	settled := false
+	defer func() {
+		if !settled {
+			database.DbConn.Model(&models.AIToolApproval{}).
+				Where("id = ? AND status = ?", approval.ID, models.AIApprovalExecuting).
+				Update("status", models.AIApprovalFailed)
+		}
+	}()
+
+	result := runApproval(approval)
+	encoded, _ := json.Marshal(result)
+
+	approval.Reason = request.Reason
+	approval.Result = string(encoded)
+	approval.Status = models.AIApprovalExecuted
+	if !result.Success {
+		approval.Status = models.AIApprovalFailed
+	}
+	if err := database.DbConn.Save(approval).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	settled = true
+
+	detail := approval.Arguments
+	if request.Reason != "" {
+		detail = request.Reason + ": " + detail
+	}
+	if !result.Success {
+		detail += " 执行失败: " + result.Error
+	}
+	audit.Record(c, models.AuditAIToolApproved, approvalTarget(*approval), result.Success, detail)
+
+	handler.Respond(c, http.StatusOK, nil, approval)
+}
+
+// RejectToolCall 拒绝AI工具调用
+// @Summary 拒绝AI工具调用
+// @Description 拒绝一条待审批的高风险工具调用，该调用不会被执行，审批结果写入审计日志
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "审批记录ID"
+// @Param request body models.AIApprovalDecisionRequest false "拒绝原因"
+// @Success 200 {object} handler.Response{data=models.AIToolApproval} "已拒绝"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "权限不足或审批自己提交的调用"
+// @Failure 404 {object} handler.Response "审批记录不存在"
+// @Failure 409 {object} handler.Response "该调用已处理或已过期"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/ai/approvals/{id}/reject [post]
+func RejectToolCall(c *gin.Context) {
+	var request models.AIApprovalDecisionRequest
+	_ = c.ShouldBindJSON(&request)
+
+	approval, ok := claimApproval(c, models.AIApprovalRejected)
+	if !ok {
+		return
+	}
+
+	approval.Reason = request.Reason
+	if err := database.DbConn.Model(approval).Update("reason", request.Reason).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	audit.Record(c, models.AuditAIToolRejected, approvalTarget(*approval), true, request.Reason)
+
+	handler.Respond(c, http.StatusOK, "已拒绝", approval)
+}
@@ -0,0 +1,159 @@
+package ai
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/dbmgr"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupApprovalRouter 初始化审批表并挂载审批接口，X-User 请求头模拟已认证用户
+func setupApprovalRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "panel.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.AIToolApproval{}, &models.AuditLog{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	original := database.DbConn
+	database.DbConn = db
+	t.Cleanup(func() { database.DbConn = original })
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("username", c.GetHeader("X-User")) })
+	r.POST("/approvals/:id/approve", ApproveToolCall)
+	r.POST("/approvals/:id/reject", RejectToolCall)
+	return r
+}
+
+// queueDropTable 写入一条由 requester 提交的 drop_table 待审批记录
+func queueDropTable(t *testing.T, requester, dbPath string) models.AIToolApproval {
+	t.Helper()
+	arguments, _ := json.Marshal(map[string]interface{}{"table_name": "users"})
+	target, _ := json.Marshal(ToolTarget{Engine: dbmgr.EngineSQLite, Path: dbPath})
+
+	approval := models.AIToolApproval{
+		Username:   requester,
+		ToolCallID: "call_1",
+		ToolName:   "drop_table",
+		Arguments:  string(arguments),
+		Target:     string(target),
+		Risk:       string(RiskDestructive),
+		Status:     models.AIApprovalPending,
+	}
+	if err := database.DbConn.Create(&approval).Error; err != nil {
+		t.Fatalf("queue approval: %v", err)
+	}
+	return approval
+}
+
+func decide(r *gin.Engine, user string, approval models.AIToolApproval, action string) int {
+	req := httptest.NewRequest(http.MethodPost, "/approvals/"+strconv.FormatUint(uint64(approval.ID), 10)+"/"+action, nil)
+	req.Header.Set("X-User", user)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func approvalStatus(t *testing.T, id uint) string {
+	t.Helper()
+	var approval models.AIToolApproval
+	if err := database.DbConn.First(&approval, id).Error; err != nil {
+		t.Fatalf("load approval: %v", err)
+	}
+	return approval.Status
+}
+
+func tableExists(t *testing.T, dbPath string) bool {
+	t.Helper()
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'users'").Scan(&count)
+	return count == 1
+}
+
+func TestApproveToolCallExecutes(t *testing.T) {
+	r := setupApprovalRouter(t)
+	dbPath := createTestDatabase(t)
+	approval := queueDropTable(t, "operator", dbPath)
+
+	if code := decide(r, "admin", approval, "approve"); code != http.StatusOK {
+		t.Fatalf("approve: expected 200, got %d", code)
+	}
+	if status := approvalStatus(t, approval.ID); status != models.AIApprovalExecuted {
+		t.Fatalf("expected executed, got %s", status)
+	}
+	if tableExists(t, dbPath) {
+		t.Fatal("expected the approved drop_table to run")
+	}
+
+	if code := decide(r, "admin", approval, "approve"); code != http.StatusConflict {
+		t.Fatalf("second approve: expected 409, got %d", code)
+	}
+}
+
+func TestRejectToolCallDoesNotExecute(t *testing.T) {
+	r := setupApprovalRouter(t)
+	dbPath := createTestDatabase(t)
+	approval := queueDropTable(t, "operator", dbPath)
+
+	if code := decide(r, "admin", approval, "reject"); code != http.StatusOK {
+		t.Fatalf("reject: expected 200, got %d", code)
+	}
+	if status := approvalStatus(t, approval.ID); status != models.AIApprovalRejected {
+		t.Fatalf("expected rejected, got %s", status)
+	}
+	if !tableExists(t, dbPath) {
+		t.Fatal("rejected call must not run")
+	}
+}
+
+func TestSelfApprovalIsRejected(t *testing.T) {
+	r := setupApprovalRouter(t)
+	dbPath := createTestDatabase(t)
+	approval := queueDropTable(t, "admin", dbPath)
+
+	for _, action := range []string{"approve", "reject"} {
+		if code := decide(r, "admin", approval, action); code != http.StatusForbidden {
+			t.Fatalf("self %s: expected 403, got %d", action, code)
+		}
+	}
+	if status := approvalStatus(t, approval.ID); status != models.AIApprovalPending {
+		t.Fatalf("expected the approval to stay pending, got %s", status)
+	}
+	if !tableExists(t, dbPath) {
+		t.Fatal("self-approved call must not run")
+	}
+}
+
+func TestApproveToolCallMarksFailure(t *testing.T) {
+	r := setupApprovalRouter(t)
+	// 目标数据库不在允许的目录中，执行失败后记录不能停留在 executing
+	approval := queueDropTable(t, "operator", filepath.Join(t.TempDir(), "missing.db"))
+
+	if code := decide(r, "admin", approval, "approve"); code != http.StatusOK {
+		t.Fatalf("approve: expected 200, got %d", code)
+	}
+	if status := approvalStatus(t, approval.ID); status != models.AIApprovalFailed {
+		t.Fatalf("expected failed, got %s", status)
+	}
+}
@@ -0,0 +1,440 @@
+package ai
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/dbmgr"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/safepath"
+	dbmgr2 "github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/dbmgr"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+)
+
+// findConnection 按 ID 加载已保存的数据库连接，测试中可替换
+var findConnection = func(id uint) (models.DatabaseConnection, error) {
+	var conn models.DatabaseConnection
+	err := database.DbConn.First(&conn, id).Error
+	return conn, err
+}
+
+// savedSQLitePaths 加载已保存的 SQLite 连接的文件路径，测试中可替换
+var savedSQLitePaths = func() ([]string, error) {
+	var paths []string
+	err := database.DbConn.Model(&models.DatabaseConnection{}).
+		Where("engine = ?", dbmgr.EngineSQLite).Pluck("path", &paths).Error
+	return paths, err
+}
+
+// realPath 返回解析符号链接后的绝对路径
+func realPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// resolveSQLitePath 校验 AI 工具请求打开的 SQLite 文件：文件必须已存在，且位于配置的目录内或属于已保存的连接，
+// 已保存的连接同样需要通过连接保存时的检查，面板自身的数据库始终拒绝
+func resolveSQLitePath(path string) (string, error) {
+	resolved, err := realPath(path)
+	if err != nil {
+		return "", fmt.Errorf("database file %s is not accessible: %v", path, err)
+	}
+
+	if dbmgr2.IsPanelDatabase(resolved) {
+		return "", fmt.Errorf("access to the panel database is not allowed")
+	}
+
+	for _, dir := range config.AppConfig.AI.SQLiteDirs {
+		if root, err := realPath(dir); err == nil && safepath.Within(root, resolved) {
+			return resolved, nil
+		}
+	}
+
+	saved, err := savedSQLitePaths()
+	if err != nil {
+		return "", fmt.Errorf("failed to load saved connections: %v", err)
+	}
+	for _, savedPath := range saved {
+		if candidate, err := realPath(savedPath); err == nil && candidate == resolved {
+			if _, err := dbmgr2.CheckSQLitePath(resolved); err != nil {
+				return "", err
+			}
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("database file %s is not in an allowed directory or a saved connection", path)
+}
+
+// connectionParameters 生成以 connection_id 为首个参数的 JSON Schema
+func connectionParameters(properties map[string]interface{}, required ...string) map[string]interface{} {
+	props := map[string]interface{}{
+		"connection_id": map[string]interface{}{
+			"type":        "integer",
+			"description": "已保存的数据库连接ID，可通过 list_database_connections 获取",
+		},
+	}
+	for name, schema := range properties {
+		props[name] = schema
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+		"required":   append([]string{"connection_id"}, required...),
+	}
+}
+
+// databaseParameter 可选的数据库名参数
+var databaseParameter = map[string]interface{}{
+	"type":        "string",
+	"description": "数据库名（Redis 为库序号），不填使用连接的默认数据库",
+}
+
+// connectionTools 基于已保存连接的工具，支持 MySQL、PostgreSQL、Redis 与 SQLite，同一对话可同时操作多个连接
+func connectionTools() []ToolDefinition {
+	return []ToolDefinition{
+		{
+			Type: "function",
+			Risk: RiskRead,
+			Function: FunctionDefinition{
+				Name:        "list_database_connections",
+				Description: "列出面板中已保存的数据库连接（ID、名称、引擎、地址），后续工具通过连接ID指定要操作的数据库",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Risk: RiskRead,
+			Function: FunctionDefinition{
+				Name:        "list_connection_databases",
+				Description: "列出指定连接上的所有数据库",
+				Parameters:  connectionParameters(nil),
+			},
+		},
+		{
+			Type: "function",
+			Risk: RiskRead,
+			Function: FunctionDefinition{
+				Name:        "list_connection_tables",
+				Description: "列出指定连接某个数据库中的表，Redis 返回键列表",
+				Parameters: connectionParameters(map[string]interface{}{
+					"database": databaseParameter,
+				}),
+			},
+		},
+		{
+			Type: "function",
+			Risk: RiskRead,
+			Function: FunctionDefinition{
+				Name:        "describe_connection_table",
+				Description: "获取指定连接中表的字段结构，PostgreSQL 可使用 schema.table 形式",
+				Parameters: connectionParameters(map[string]interface{}{
+					"database": databaseParameter,
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+				}, "table_name"),
+			},
+		},
+		{
+			Type: "function",
+			Risk: RiskRead,
+			Function: FunctionDefinition{
+				Name:        "query_connection",
+				Description: "在指定连接上执行单条只读查询：SQL 数据库仅允许 SELECT/SHOW/DESCRIBE/EXPLAIN 并在只读事务中执行，结果行数和执行时间受限；Redis 仅允许 GET、HGETALL、SCAN 等只读命令",
+				Parameters: connectionParameters(map[string]interface{}{
+					"database": databaseParameter,
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "SQL 查询语句或 Redis 命令",
+					},
+				}, "query"),
+			},
+		},
+		{
+			Type: "function",
+			Risk: RiskRead,
+			Function: FunctionDefinition{
+				Name:        "get_connection_server_status",
+				Description: "获取指定连接的服务器状态，如版本、运行时间、连接数",
+				Parameters:  connectionParameters(nil),
+			},
+		},
+		{
+			Type: "function",
+			Risk: RiskRead,
+			Function: FunctionDefinition{
+				Name:        "get_connection_slowlog",
+				Description: "获取指定连接的慢查询记录：MySQL 读取 mysql.slow_log，PostgreSQL 读取 pg_stat_statements，Redis 读取 SLOWLOG",
+				Parameters: connectionParameters(map[string]interface{}{
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "返回条数，默认10",
+					},
+				}),
+			},
+		},
+	}
+}
+
+// connectionIDArg 解析 connection_id 参数，兼容 JSON 数字和字符串
+func connectionIDArg(args map[string]interface{}) (uint, error) {
+	switch v := args["connection_id"].(type) {
+	case float64:
+		if v >= 1 && v == float64(uint(v)) {
+			return uint(v), nil
+		}
+	case string:
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil && id > 0 {
+			return uint(id), nil
+		}
+	}
+	return 0, fmt.Errorf("connection_id parameter is required and must be a positive integer")
+}
+
+// connectionManager 返回指定连接和数据库的管理器，同一对话内复用已建立的连接
+func (tcr *ToolCallReceiver) connectionManager(args map[string]interface{}) (dbmgr.DatabaseManager, error) {
+	id, err := connectionIDArg(args)
+	if err != nil {
+		return nil, err
+	}
+	db, _ := args["database"].(string)
+
+	key := fmt.Sprintf("%d/%s", id, db)
+	if manager, ok := tcr.connections[key]; ok {
+		return manager, nil
+	}
+
+	conn, err := findConnection(id)
+	if err != nil {
+		return nil, fmt.Errorf("database connection %d not found", id)
+	}
+
+	manager, err := dbmgr2.OpenManager(conn, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s (%s): %v", conn.Name, conn.Engine, err)
+	}
+
+	if tcr.connections == nil {
+		tcr.connections = make(map[string]dbmgr.DatabaseManager)
+	}
+	tcr.connections[key] = manager
+	return manager, nil
+}
+
+// handleListConnections handles listing saved database connections
+func (tcr *ToolCallReceiver) handleListConnections(toolCall ToolCall) ToolCallResult {
+	result := ToolCallResult{ToolCallID: toolCall.ID}
+
+	var connections []models.DatabaseConnection
+	if err := database.DbConn.Order("id").Find(&connections).Error; err != nil {
+		result.Error = fmt.Sprintf("failed to list connections: %v", err)
+		return result
+	}
+
+	items := make([]map[string]interface{}, 0, len(connections))
+	for _, conn := range connections {
+		items = append(items, map[string]interface{}{
+			"id":       conn.ID,
+			"name":     conn.Name,
+			"engine":   conn.Engine,
+			"host":     conn.Host,
+			"port":     conn.Port,
+			"database": conn.Database,
+			"path":     conn.Path,
+		})
+	}
+
+	result.Success = true
+	result.Result = items
+
+	return result
+}
+
+// handleListConnectionDatabases handles listing databases of a saved connection
+func (tcr *ToolCallReceiver) handleListConnectionDatabases(toolCall ToolCall) ToolCallResult {
+	result := ToolCallResult{ToolCallID: toolCall.ID}
+
+	manager, err := tcr.connectionManager(toolCall.Function.Arguments)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	databases, err := manager.ListDatabases()
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to list databases: %v", err)
+		return result
+	}
+
+	result.Success = true
+	result.Result = databases
+
+	return result
+}
+
+// handleListConnectionTables handles listing tables of a saved connection
+func (tcr *ToolCallReceiver) handleListConnectionTables(toolCall ToolCall) ToolCallResult {
+	result := ToolCallResult{ToolCallID: toolCall.ID}
+
+	manager, err := tcr.connectionManager(toolCall.Function.Arguments)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// 管理器已按 database 参数建立连接，这里列出其当前数据库
+	tables, err := manager.ListTables("")
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to list tables: %v", err)
+		return result
+	}
+
+	result.Success = true
+	result.Result = tables
+
+	return result
+}
+
+// handleDescribeConnectionTable handles describing a table of a saved connection
+func (tcr *ToolCallReceiver) handleDescribeConnectionTable(toolCall ToolCall) ToolCallResult {
+	result := ToolCallResult{ToolCallID: toolCall.ID}
+
+	tableName, ok := toolCall.Function.Arguments["table_name"].(string)
+	if !ok {
+		result.Error = "table_name parameter is required and must be a string"
+		return result
+	}
+
+	manager, err := tcr.connectionManager(toolCall.Function.Arguments)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	describer, ok := manager.(dbmgr.TableDescriber)
+	if !ok {
+		result.Error = fmt.Sprintf("%s does not support describing tables", manager.Engine())
+		return result
+	}
+
+	schema, err := describer.GetTableSchema(tableName)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to get table schema: %v", err)
+		return result
+	}
+
+	result.Success = true
+	result.Result = schema
+
+	return result
+}
+
+// handleQueryConnection handles running a read-only query on a saved connection
+func (tcr *ToolCallReceiver) handleQueryConnection(toolCall ToolCall) ToolCallResult {
+	result := ToolCallResult{ToolCallID: toolCall.ID}
+
+	query, ok := toolCall.Function.Arguments["query"].(string)
+	if !ok {
+		result.Error = "query parameter is required and must be a string"
+		return result
+	}
+
+	manager, err := tcr.connectionManager(toolCall.Function.Arguments)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// 语句分类拦截写操作，SQL 引擎另在只读事务中执行，分类遗漏的写操作也会被数据库拒绝
+	if err := dbmgr2.CheckReadOnlyQuery(manager.Engine(), query); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	rows, truncated, err := dbmgr2.QueryReadOnly(manager, query)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to execute query: %v", err)
+		return result
+	}
+
+	result.Success = true
+	result.Result = map[string]interface{}{
+		"rows":      rows,
+		"count":     len(rows),
+		"truncated": truncated,
+	}
+
+	return result
+}
+
+// handleConnectionServerStatus handles reading the server status of a saved connection
+func (tcr *ToolCallReceiver) handleConnectionServerStatus(toolCall ToolCall) ToolCallResult {
+	result := ToolCallResult{ToolCallID: toolCall.ID}
+
+	manager, err := tcr.connectionManager(toolCall.Function.Arguments)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	reader, ok := manager.(dbmgr.ServerStatusReader)
+	if !ok {
+		result.Error = fmt.Sprintf("%s does not report server status", manager.Engine())
+		return result
+	}
+
+	status, err := reader.GetServerStatus()
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to get server status: %v", err)
+		return result
+	}
+
+	result.Success = true
+	result.Result = status
+
+	return result
+}
+
+// handleConnectionSlowLog handles reading the slow log of a saved connection
+func (tcr *ToolCallReceiver) handleConnectionSlowLog(toolCall ToolCall) ToolCallResult {
+	result := ToolCallResult{ToolCallID: toolCall.ID}
+
+	manager, err := tcr.connectionManager(toolCall.Function.Arguments)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	reader, ok := manager.(dbmgr.SlowLogReader)
+	if !ok {
+		result.Error = fmt.Sprintf("%s does not expose a slow log", manager.Engine())
+		return result
+	}
+
+	count := int64(10)
+	if v, ok := toolCall.Function.Arguments["count"].(float64); ok && v > 0 {
+		count = int64(v)
+	}
+
+	entries, err := reader.GetSlowLog(count)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to get slow log: %v", err)
+		return result
+	}
+
+	result.Success = true
+	result.Result = entries
+
+	return result
+}
@@ -0,0 +1,229 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/middleware"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sashabaranov/go-openai"
+	"gorm.io/gorm"
+)
+
+const (
+	// ConversationDatabase 数据库助手对话
+	ConversationDatabase = "database"
+	// maxHistoryMessages 继续对话时最多发送给模型的历史消息数，超出时从较早的提问处截断
+	maxHistoryMessages = 60
+	// maxTitleLength 对话标题的最大字符数
+	maxTitleLength = 50
+)
+
+// isAdmin 当前用户是否为管理员
+func isAdmin(c *gin.Context) bool {
+	value, _ := c.Get("claims")
+	claims, ok := value.(*middleware.Claims)
+	return ok && models.RoleAtLeast(claims.Role, models.RoleAdmin)
+}
+
+// createConversation 以第一条提问为标题创建对话
+func createConversation(username, kind, message string) (*models.AIConversation, error) {
+	title := []rune(message)
+	if len(title) > maxTitleLength {
+		title = append(title[:maxTitleLength], '…')
+	}
+
+	conversation := &models.AIConversation{Username: username, Kind: kind, Title: string(title)}
+	if err := database.DbConn.Create(conversation).Error; err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// findConversation 按ID读取对话并检查归属，allowAdmin 时管理员可以访问其他用户的对话。
+// 对话不存在或无权访问时写入 404 响应，不暴露对话是否存在
+func findConversation(c *gin.Context, id uint, allowAdmin bool) (*models.AIConversation, bool) {
+	var conversation models.AIConversation
+	err := database.DbConn.First(&conversation, id).Error
+	if err != nil || (conversation.Username != c.GetString("username") && !(allowAdmin && isAdmin(c))) {
+		handler.Respond(c, http.StatusNotFound, "对话不存在", nil)
+		return nil, false
+	}
+	return &conversation, true
+}
+
+// conversationHistory 将对话中已保存的消息转换为发送给模型的历史记录
+func conversationHistory(conversationID uint) ([]openai.ChatCompletionMessage, error) {
+	var messages []models.AIMessage
+	if err := database.DbConn.Where("conversation_id = ?", conversationID).Order("id").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	// 工具结果必须紧跟发起调用的回复，只能从用户提问处截断
+	start := 0
+	for i := range messages {
+		if len(messages)-i <= maxHistoryMessages && messages[i].Role == openai.ChatMessageRoleUser {
+			start = i
+			break
+		}
+	}
+	if len(messages)-start > maxHistoryMessages {
+		start = len(messages)
+	}
+
+	history := make([]openai.ChatCompletionMessage, 0, len(messages)-start)
+	for _, message := range messages[start:] {
+		history = append(history, toOpenAIMessage(message))
+	}
+	return history, nil
+}
+
+// toOpenAIMessage 将保存的消息还原为模型消息
+func toOpenAIMessage(message models.AIMessage) openai.ChatCompletionMessage {
+	converted := openai.ChatCompletionMessage{Role: message.Role, Content: message.Content}
+
+	if message.ToolCalls != "" {
+		var calls []ToolCall
+		_ = json.Unmarshal([]byte(message.ToolCalls), &calls)
+		for _, call := range calls {
+			arguments, _ := json.Marshal(call.Function.Arguments)
+			converted.ToolCalls = append(converted.ToolCalls, openai.ToolCall{
+				ID:   call.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      call.Function.Name,
+					Arguments: string(arguments),
+				},
+			})
+		}
+	}
+
+	if message.ToolResult != "" {
+		var result ToolCallResult
+		_ = json.Unmarshal([]byte(message.ToolResult), &result)
+		converted.Content = message.ToolResult
+		converted.ToolCallID = result.ToolCallID
+	}
+	return converted
+}
+
+// saveTranscript 将本轮对话的消息追加到对话中
+func saveTranscript(conversationID uint, transcript []AgentMessage) error {
+	if len(transcript) == 0 {
+		return nil
+	}
+
+	messages := make([]models.AIMessage, 0, len(transcript))
+	for _, entry := range transcript {
+		message := models.AIMessage{ConversationID: conversationID, Role: entry.Role, Content: entry.Content}
+		if len(entry.ToolCalls) > 0 {
+			calls, _ := json.Marshal(entry.ToolCalls)
+			message.ToolCalls = string(calls)
+		}
+		if entry.ToolResult != nil {
+			result, _ := json.Marshal(entry.ToolResult)
+			message.ToolResult = string(result)
+		}
+		messages = append(messages, message)
+	}
+
+	return database.DbConn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&messages).Error; err != nil {
+			return err
+		}
+		// 更新时间用于对话列表排序
+		return tx.Model(&models.AIConversation{ID: conversationID}).Update("updated_at", time.Now()).Error
+	})
+}
+
+// GetConversations 获取AI对话列表
+// @Summary 获取AI对话列表
+// @Description 获取当前用户的AI对话，按最近更新时间排序，不包含消息内容
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=[]models.AIConversation} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/ai/conversations [get]
+func GetConversations(c *gin.Context) {
+	var conversations []models.AIConversation
+	if err := database.DbConn.Where("username = ?", c.GetString("username")).Order("updated_at DESC").Find(&conversations).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	handler.Respond(c, http.StatusOK, nil, conversations)
+}
+
+// GetConversation 获取AI对话详情
+// @Summary 获取AI对话详情
+// @Description 获取对话及其全部消息，包括工具调用和执行结果。管理员可以查看所有用户的对话
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "对话ID"
+// @Success 200 {object} handler.Response{data=models.AIConversation} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "对话不存在"
+// @Router /auth/ai/conversations/{id} [get]
+func GetConversation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Respond(c, http.StatusNotFound, "对话不存在", nil)
+		return
+	}
+	conversation, ok := findConversation(c, uint(id), true)
+	if !ok {
+		return
+	}
+
+	if err := database.DbConn.Where("conversation_id = ?", conversation.ID).Order("id").Find(&conversation.Messages).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	handler.Respond(c, http.StatusOK, nil, conversation)
+}
+
+// DeleteConversation 删除AI对话
+// @Summary 删除AI对话
+// @Description 删除对话及其全部消息，用量记录保留。管理员可以删除所有用户的对话
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "对话ID"
+// @Success 200 {object} handler.Response "删除成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "对话不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/ai/conversations/{id} [delete]
+func DeleteConversation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Respond(c, http.StatusNotFound, "对话不存在", nil)
+		return
+	}
+	conversation, ok := findConversation(c, uint(id), true)
+	if !ok {
+		return
+	}
+
+	err = database.DbConn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("conversation_id = ?", conversation.ID).Delete(&models.AIMessage{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(conversation).Error
+	})
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	handler.Respond(c, http.StatusOK, "删除成功", nil)
+}
@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// DatabaseAgentRequest 数据库助手请求结构
+type DatabaseAgentRequest struct {
+	Message  string `json:"message" binding:"required" example:"连接 /var/lib/app/data.db 并统计 users 表的行数"`
+	MaxSteps int    `json:"max_steps" binding:"omitempty,min=1,max=20" example:"10"` // 最多与模型交互的轮数，默认10
+	// ConversationID 继续已有的对话，为空时创建新对话
+	ConversationID uint `json:"conversation_id" example:"0"`
+}
+
+// DatabaseAgentResponse 数据库助手响应，conversation_id 用于继续提问
+type DatabaseAgentResponse struct {
+	AgentResult
+	ConversationID uint `json:"conversation_id"`
+}
+
+// DatabaseAgent 数据库助手
+// @Summary AI数据库助手
+// @Description 与AI进行多轮函数调用对话：模型返回的工具调用会在服务器上执行，结果回传给模型直到给出最终回复，返回本轮的对话记录。高风险工具调用不会立即执行，而是进入待审批队列。对话保存在面板中，传入 conversation_id 可继续提问
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body DatabaseAgentRequest true "数据库助手请求"
+// @Success 200 {object} handler.Response{data=DatabaseAgentResponse} "执行完成，completed=false 表示达到最大轮数"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "对话不存在"
+// @Failure 429 {object} handler.Response{data=DatabaseAgentResponse} "本月AI用量已达到配额"
+// @Failure 502 {object} handler.Response{data=DatabaseAgentResponse} "AI服务调用失败，data 中为已产生的对话记录"
+// @Failure 503 {object} handler.Response "AI服务未配置"
+// @Router /auth/ai/db [post]
+func DatabaseAgent(c *gin.Context) {
+	var request DatabaseAgentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	client, meter, err := userClient(c, "db")
+	if err != nil {
+		respondAIError(c, err)
+		return
+	}
+
+	var conversation *models.AIConversation
+	if request.ConversationID != 0 {
+		var ok bool
+		if conversation, ok = findConversation(c, request.ConversationID, false); !ok {
+			return
+		}
+	} else if conversation, err = createConversation(c.GetString("username"), ConversationDatabase, request.Message); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "创建对话失败: "+err.Error(), nil)
+		return
+	}
+	history, err := conversationHistory(conversation.ID)
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "读取对话失败: "+err.Error(), nil)
+		return
+	}
+	meter.conversationID = &conversation.ID
+
+	receiver := NewToolCallReceiver()
+	defer receiver.Close()
+
+	agent := NewDatabaseAgent(client, receiver)
+	agent.Gate = approvalGate(c, receiver)
+	agent.History = history
+	if request.MaxSteps > 0 {
+		agent.MaxSteps = min(request.MaxSteps, maxAgentSteps)
+	}
+
+	result, err := agent.Run(c.Request.Context(), request.Message)
+	// 失败时同样保存已产生的消息，以便查看和继续提问
+	if saveErr := saveTranscript(conversation.ID, result.Transcript); saveErr != nil {
+		log.Printf("保存 AI 对话失败: %v", saveErr)
+	}
+
+	response := DatabaseAgentResponse{AgentResult: result, ConversationID: conversation.ID}
+	if err != nil {
+		handler.Respond(c, aiErrorStatus(err), aiErrorMessage(err), response)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, response)
+}
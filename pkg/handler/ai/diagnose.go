@@ -0,0 +1,152 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ai"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/system"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// severityRank 诊断问题的排序，越严重越靠前
+var severityRank = map[string]int{
+	ai.SeverityCritical: 0,
+	ai.SeverityWarning:  1,
+	ai.SeverityInfo:     2,
+}
+
+// RemediationAction 处理操作对应的面板接口，前端确认后按 method、path 和 body 调用
+type RemediationAction struct {
+	Action string                 `json:"action" example:"kill_process"`
+	Label  string                 `json:"label" example:"终止进程 1234 (java)"`
+	Method string                 `json:"method" example:"POST"`
+	Path   string                 `json:"path" example:"/api/auth/system/process/kill"`
+	Body   map[string]interface{} `json:"body,omitempty"`
+	Reason string                 `json:"reason"`
+}
+
+// HealthFinding 诊断发现的问题
+type HealthFinding struct {
+	Severity string              `json:"severity" example:"critical"`
+	Title    string              `json:"title" example:"内存即将耗尽"`
+	Detail   string              `json:"detail"`
+	Evidence string              `json:"evidence"`
+	Actions  []RemediationAction `json:"actions"`
+}
+
+// DiagnoseResponse 健康诊断结果，findings 按严重程度排序
+type DiagnoseResponse struct {
+	Snapshot models.HealthSnapshot `json:"snapshot"`
+	Findings []HealthFinding       `json:"findings"`
+}
+
+// DiagnoseSystem AI系统健康诊断
+// @Summary AI系统健康诊断
+// @Description 采集 CPU、内存、磁盘、资源占用最高的进程和相关日志的末尾，由AI诊断问题并按严重程度排序。建议的处理操作对应面板已有的接口（终止进程、重启 nginx 等），不会自动执行，终止系统关键进程或面板自身的建议会被丢弃
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=DiagnoseResponse} "诊断完成"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 429 {object} handler.Response "本月AI用量已达到配额"
+// @Failure 502 {object} handler.Response "AI服务调用失败或返回格式错误"
+// @Failure 503 {object} handler.Response "AI服务未配置"
+// @Router /auth/ai/diagnose [post]
+func DiagnoseSystem(c *gin.Context) {
+	client, _, err := userClient(c, "diagnose")
+	if err != nil {
+		respondAIError(c, err)
+		return
+	}
+
+	snapshot := system.CollectHealthSnapshot(c.Request.Context())
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	var findings []ai.Finding
+	if err := client.ChatJSON(c.Request.Context(), ai.HealthDiagnosisTask, string(content), &findings); err != nil {
+		respondAIError(c, err)
+		return
+	}
+
+	response := DiagnoseResponse{Snapshot: snapshot, Findings: make([]HealthFinding, 0, len(findings))}
+	for _, finding := range findings {
+		result := HealthFinding{
+			Severity: finding.Severity,
+			Title:    finding.Title,
+			Detail:   finding.Detail,
+			Evidence: finding.Evidence,
+			Actions:  []RemediationAction{},
+		}
+		for _, action := range finding.Actions {
+			if remediation, ok := remediationFor(action, snapshot); ok {
+				result.Actions = append(result.Actions, remediation)
+			}
+		}
+		response.Findings = append(response.Findings, result)
+	}
+	sort.SliceStable(response.Findings, func(i, j int) bool {
+		return severityRank[response.Findings[i].Severity] < severityRank[response.Findings[j].Severity]
+	})
+
+	handler.Respond(c, http.StatusOK, nil, response)
+}
+
+// remediationFor 将模型建议的操作转换为面板接口，参数无效或操作不安全时返回 false
+func remediationFor(action ai.FindingAction, snapshot models.HealthSnapshot) (RemediationAction, bool) {
+	remediation := RemediationAction{Action: action.Action, Method: http.MethodPost, Reason: action.Reason}
+
+	switch action.Action {
+	case ai.ActionKillProcess:
+		if !system.ProcessExists(action.PID) || system.IsCriticalProcess(action.PID) || action.PID == os.Getpid() {
+			return remediation, false
+		}
+		remediation.Label = fmt.Sprintf("终止进程 %d", action.PID)
+		if name := processName(snapshot, action.PID); name != "" {
+			remediation.Label += " (" + name + ")"
+		}
+		remediation.Path = "/api/auth/system/process/kill"
+		remediation.Body = map[string]interface{}{"pid": action.PID, "signal": "TERM"}
+	case ai.ActionRestartNginx:
+		remediation.Label = "重启 Nginx"
+		remediation.Path = "/api/auth/nginx/restart"
+	case ai.ActionReloadNginx:
+		remediation.Label = "重新加载 Nginx 配置"
+		remediation.Path = "/api/auth/nginx/reload"
+	case ai.ActionTestNginx:
+		remediation.Label = "测试 Nginx 配置"
+		remediation.Path = "/api/auth/nginx/test"
+	case ai.ActionAnalyzeLog:
+		if action.Path == "" {
+			return remediation, false
+		}
+		remediation.Label = "分析日志 " + action.Path
+		remediation.Path = "/api/auth/ai/log/source"
+		remediation.Body = map[string]interface{}{"source": "file", "path": action.Path, "since": "24h"}
+	default:
+		return remediation, false
+	}
+	return remediation, true
+}
+
+// processName 在快照中查找进程名
+func processName(snapshot models.HealthSnapshot, pid int) string {
+	for _, list := range [][]models.ProcessInfo{snapshot.TopCPU, snapshot.TopMemory} {
+		for _, process := range list {
+			if process.PId == pid {
+				return process.Name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ai"
+)
+
+func TestDiagnoseSystemRanksFindingsAndFiltersActions(t *testing.T) {
+	sleeper := exec.Command("sleep", "30")
+	if err := sleeper.Start(); err != nil {
+		t.Fatalf("start sleep: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sleeper.Process.Kill()
+		_ = sleeper.Wait()
+	})
+
+	findings := []ai.Finding{
+		{Severity: ai.SeverityWarning, Title: "nginx 上游超时", Detail: "error.log 中大量 upstream timed out", Actions: []ai.FindingAction{
+			{Action: ai.ActionReloadNginx, Reason: "应用修改后的超时设置"},
+			{Action: ai.ActionAnalyzeLog, Path: "/var/log/nginx/error.log"},
+		}},
+		{Severity: ai.SeverityCritical, Title: "进程占满 CPU", Detail: "sleep 占用 CPU", Actions: []ai.FindingAction{
+			{Action: ai.ActionKillProcess, PID: sleeper.Process.Pid, Reason: "释放 CPU"},
+			{Action: ai.ActionKillProcess, PID: 1},
+			{Action: ai.ActionKillProcess, PID: os.Getpid()},
+			{Action: ai.ActionKillProcess, PID: 1 << 30},
+		}},
+	}
+	reply, _ := json.Marshal(findings)
+	r, mock := setupLogSource(t, string(reply))
+	r.POST("/diagnose", DiagnoseSystem)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/diagnose", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data DiagnoseResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	got := resp.Data.Findings
+	if len(got) != 2 || got[0].Severity != ai.SeverityCritical || got[1].Severity != ai.SeverityWarning {
+		t.Fatalf("findings should be ranked by severity, got %+v", got)
+	}
+	if len(got[0].Actions) != 1 {
+		t.Fatalf("only the kill action for an ordinary existing process should remain, got %+v", got[0].Actions)
+	}
+	kill := got[0].Actions[0]
+	if kill.Path != "/api/auth/system/process/kill" || kill.Body["pid"] != float64(sleeper.Process.Pid) || kill.Body["signal"] != "TERM" {
+		t.Errorf("unexpected kill action %+v", kill)
+	}
+	if len(got[1].Actions) != 2 || got[1].Actions[0].Path != "/api/auth/nginx/reload" || got[1].Actions[1].Body["path"] != "/var/log/nginx/error.log" {
+		t.Errorf("unexpected nginx actions %+v", got[1].Actions)
+	}
+
+	if resp.Data.Snapshot.Memory.Total == 0 || len(resp.Data.Snapshot.TopMemory) == 0 {
+		t.Errorf("expected a populated snapshot, got %+v", resp.Data.Snapshot)
+	}
+	prompt := mock.requests[0].Messages[0].Content
+	if !strings.HasPrefix(prompt, ai.HealthDiagnosis) || !strings.Contains(prompt, `"topMemory"`) {
+		t.Errorf("the snapshot should be sent with the diagnosis prompt")
+	}
+}
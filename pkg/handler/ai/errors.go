@@ -0,0 +1,41 @@
+package ai
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ai"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// aiErrorStatus 将AI调用错误转换为状态码：未配置返回 503，超出配额返回 429，调用失败或回复格式错误返回 502
+func aiErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ai.ErrNotConfigured):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// aiErrorMessage 将AI调用错误转换为提示信息
+func aiErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ai.ErrNotConfigured):
+		return "AI 服务未配置，请在 config.toml 的 [ai] 中设置服务商和模型"
+	case errors.Is(err, ErrQuotaExceeded):
+		return "本月 AI 用量已达到配额，请联系管理员"
+	case errors.Is(err, ai.ErrInvalidOutput):
+		return "AI 返回内容格式错误: " + err.Error()
+	default:
+		return "AI 服务调用失败: " + err.Error()
+	}
+}
+
+// respondAIError 将AI调用错误转换为响应
+func respondAIError(c *gin.Context, err error) {
+	handler.Respond(c, aiErrorStatus(err), aiErrorMessage(err), nil)
+}
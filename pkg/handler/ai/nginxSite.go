@@ -0,0 +1,160 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ai"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/textdiff"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/nginx"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// maxNginxAttempts 生成的配置未通过 nginx -t 时，带上错误输出重新生成，总共最多生成的次数
+const maxNginxAttempts = 2
+
+// GenerateNginxSiteRequest AI生成网站配置请求
+type GenerateNginxSiteRequest struct {
+	Description string `json:"description" binding:"required" example:"SPA 部署在 /，/api 反向代理到 3000 端口并支持 WebSocket，静态资源长期缓存"`
+	// Site 当前的网站设置，name 对应 sites-available 中的配置文件，文件不存在时视为新网站
+	Site models.NginxSite `json:"site"`
+}
+
+// GenerateNginxSiteResponse AI生成的网站配置，不会写入文件
+type GenerateNginxSiteResponse struct {
+	Config      string `json:"config"`
+	Explanation string `json:"explanation"`
+	Valid       bool   `json:"valid"`      // 是否通过 nginx -t
+	TestOutput  string `json:"testOutput"` // nginx -t 的输出
+	ConfigPath  string `json:"configPath"` // 对比的网站配置文件，为空表示新网站
+	Diff        string `json:"diff"`       // 相对当前配置文件的统一格式差异
+}
+
+// nginxSiteOutput 模型回复的结构，对应 ai.NginxSiteTask
+type nginxSiteOutput struct {
+	Config      string `json:"config"`
+	Explanation string `json:"explanation"`
+}
+
+// GenerateNginxSite AI生成网站配置
+// @Summary AI生成Nginx网站配置
+// @Description 根据自然语言描述和当前网站设置生成网站配置，返回前先在临时目录中用 nginx -t 校验，未通过时带上错误输出重新生成一次，并给出相对当前配置文件的差异。生成的配置不会写入文件
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body GenerateNginxSiteRequest true "网站需求描述及当前设置"
+// @Success 200 {object} handler.Response{data=GenerateNginxSiteResponse} "生成完成，valid=false 表示仍未通过 nginx -t"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 429 {object} handler.Response "本月AI用量已达到配额"
+// @Failure 500 {object} handler.Response "读取当前配置失败"
+// @Failure 502 {object} handler.Response "AI服务调用失败或返回格式错误"
+// @Failure 503 {object} handler.Response "AI服务未配置或未安装nginx"
+// @Router /auth/ai/nginx [post]
+func GenerateNginxSite(c *gin.Context) {
+	var request GenerateNginxSiteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	response := GenerateNginxSiteResponse{}
+	var current string
+	if request.Site.Name != "" {
+		path, err := nginx.SiteConfigPath(request.Site.Name)
+		if err != nil {
+			handler.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			current = string(data)
+			response.ConfigPath = path
+		case !os.IsNotExist(err):
+			handler.Respond(c, http.StatusInternalServerError, "读取当前配置失败: "+err.Error(), nil)
+			return
+		}
+	}
+
+	client, _, err := userClient(c, "nginx")
+	if err != nil {
+		respondAIError(c, err)
+		return
+	}
+
+	// 新网站没有配置文件时，以按网站设置生成的配置作为参考
+	baseline := current
+	if baseline == "" && request.Site.Domain != "" {
+		baseline = nginx.GenerateSiteConfig(request.Site)
+	}
+
+	var previous nginxSiteOutput
+	for attempt := 0; attempt < maxNginxAttempts; attempt++ {
+		var output nginxSiteOutput
+		content := nginxSiteContent(request.Description, baseline, previous.Config, response.TestOutput)
+		if err := client.ChatJSON(c.Request.Context(), ai.NginxSiteTask, content, &output); err != nil {
+			respondAIError(c, err)
+			return
+		}
+
+		testOutput, err := nginx.CheckSiteConfig(c.Request.Context(), output.Config)
+		if errors.Is(err, nginx.ErrNginxNotInstalled) {
+			handler.Respond(c, http.StatusServiceUnavailable, err.Error(), nil)
+			return
+		}
+		if err != nil && testOutput == "" {
+			testOutput = err.Error()
+		}
+
+		response.Config = output.Config
+		response.Explanation = output.Explanation
+		response.Valid = err == nil
+		response.TestOutput = testOutput
+		if response.Valid {
+			break
+		}
+		previous = output
+	}
+
+	fromName := response.ConfigPath
+	if fromName == "" {
+		fromName = "/dev/null"
+	}
+	toName := response.ConfigPath
+	if toName == "" {
+		toName = "site.conf"
+	}
+	response.Diff = textdiff.Unified(fromName, toName, current, ensureNewline(response.Config))
+
+	handler.Respond(c, http.StatusOK, nil, response)
+}
+
+// nginxSiteContent 拼接发送给模型的需求、当前配置，以及上一次未通过校验的配置和 nginx -t 的输出
+func nginxSiteContent(description, baseline, previous, testOutput string) string {
+	var content strings.Builder
+	fmt.Fprintf(&content, "需求：%s\n\n", description)
+	if baseline != "" {
+		fmt.Fprintf(&content, "当前配置：\n%s\n\n", baseline)
+	} else {
+		content.WriteString("当前没有配置，这是一个新网站。\n\n")
+	}
+	if previous != "" {
+		fmt.Fprintf(&content, "上一次生成的配置：\n%s\n\nnginx -t 的错误：\n%s\n", previous, testOutput)
+	}
+	return content.String()
+}
+
+// ensureNewline 保证内容以换行结尾，与配置文件的格式一致
+func ensureNewline(text string) string {
+	if text == "" || strings.HasSuffix(text, "\n") {
+		return text
+	}
+	return text + "\n"
+}
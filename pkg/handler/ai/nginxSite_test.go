@@ -0,0 +1,112 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeNginx 在 PATH 最前面放置一个模拟的 nginx：配置中出现 bad_directive 时按 nginx -t 的格式报错
+func fakeNginx(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := `#!/bin/sh
+conf="$6"
+site="$(dirname "$conf")/site.conf"
+if grep -q bad_directive "$site"; then
+	echo "nginx: [emerg] unknown directive \"bad_directive\" in $site:3" >&2
+	echo "nginx: configuration file $conf test failed" >&2
+	exit 1
+fi
+`
+	if err := os.WriteFile(filepath.Join(dir, "nginx"), []byte(script), 0755); err != nil {
+		t.Fatalf("write fake nginx: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func nginxReply(config, explanation string) string {
+	data, _ := json.Marshal(nginxSiteOutput{Config: config, Explanation: explanation})
+	return string(data)
+}
+
+func postNginxSite(r *gin.Engine, request GenerateNginxSiteRequest) (*httptest.ResponseRecorder, GenerateNginxSiteResponse) {
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest(http.MethodPost, "/nginx", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp struct {
+		Data GenerateNginxSiteResponse `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp.Data
+}
+
+func TestGenerateNginxSiteRetriesFailedTest(t *testing.T) {
+	fakeNginx(t)
+	valid := "server {\n    listen 80;\n    server_name spa.example.com;\n    location /api/ {\n        proxy_pass http://127.0.0.1:3000;\n    }\n}"
+	r, mock := setupLogSource(t,
+		nginxReply("server {\n    listen 80;\n    bad_directive on;\n}", "第一次"),
+		nginxReply(valid, "去掉了不存在的指令"),
+	)
+	r.POST("/nginx", GenerateNginxSite)
+
+	w, data := postNginxSite(r, GenerateNginxSiteRequest{
+		Description: "SPA on /, API proxied to :3000",
+		Site:        models.NginxSite{Name: "spa-example-test", Domain: "spa.example.com"},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !data.Valid || data.Config != valid || data.Explanation != "去掉了不存在的指令" {
+		t.Fatalf("unexpected result %+v", data)
+	}
+	if !strings.HasPrefix(data.Diff, "--- /dev/null\n+++ site.conf\n@@ -0,0 +1,7 @@\n+server {\n") {
+		t.Errorf("new site should diff against an empty file, got:\n%s", data.Diff)
+	}
+
+	if len(mock.requests) != 2 {
+		t.Fatalf("expected one regeneration, got %d requests", len(mock.requests))
+	}
+	first := mock.requests[0].Messages[0].Content
+	if !strings.Contains(first, "server_name spa.example.com") {
+		t.Errorf("a new site should be described by its generated config, got %q", first)
+	}
+	retry := mock.requests[1].Messages[0].Content
+	if !strings.Contains(retry, `unknown directive "bad_directive" in site.conf:3`) || !strings.Contains(retry, "bad_directive on;") {
+		t.Errorf("retry should include the failed config and the nginx -t error, got %q", retry)
+	}
+}
+
+func TestGenerateNginxSiteRejectsUnsafeInput(t *testing.T) {
+	fakeNginx(t)
+	leak := "server {\n    listen 80;\n    include /etc/shadow;\n}"
+	r, mock := setupLogSource(t, nginxReply(leak, "a"), nginxReply(leak, "b"))
+	r.POST("/nginx", GenerateNginxSite)
+
+	if w, _ := postNginxSite(r, GenerateNginxSiteRequest{Description: "x", Site: models.NginxSite{Name: "../../etc/passwd"}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("path traversal in the site name: expected 400, got %d", w.Code)
+	}
+	if len(mock.requests) != 0 {
+		t.Fatal("rejected requests must not reach the AI provider")
+	}
+
+	w, data := postNginxSite(r, GenerateNginxSiteRequest{Description: "x"})
+	if w.Code != http.StatusOK || data.Valid || !strings.Contains(data.TestOutput, "/etc/shadow") {
+		t.Fatalf("include outside /etc/nginx must fail the check, got %d %+v", w.Code, data)
+	}
+	if len(mock.requests) != 2 {
+		t.Errorf("expected one regeneration, got %d requests", len(mock.requests))
+	}
+}
@@ -0,0 +1,227 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ai"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ws"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamRetryInterval 发送缓冲区已满时重试的间隔
+	streamRetryInterval = 50 * time.Millisecond
+	// streamDeliverTimeout 结束消息等待发送缓冲区腾出空间的最长时间
+	streamDeliverTimeout = 5 * time.Second
+)
+
+// 服务端推送的消息类型
+const (
+	StreamMessageDelta     = "delta"     // data 为新生成的内容片段
+	StreamMessageDone      = "done"      // data 为完整回复
+	StreamMessageCancelled = "cancelled" // 分析已被客户端取消，data 为已生成的内容
+	StreamMessageError     = "error"     // data 为错误信息
+)
+
+// streamPrompts 流式分析支持的类型及对应提示词
+var streamPrompts = map[string]string{
+	"log":   ai.LogAnalyzer,
+	"files": ai.DirCleanPrompt,
+}
+
+// StreamRequest 客户端通过 WebSocket 发送的请求，type 为 log、files 或 cancel
+type StreamRequest struct {
+	Type    string `json:"type" example:"log"`
+	Content string `json:"content" example:"[ERROR] 2024-01-01 12:00:00 Database connection failed"`
+}
+
+// streamUserKey 握手请求的 Context 中保存当前用户名的键
+type streamUserKey struct{}
+
+// streamSession 一次正在进行的流式分析
+type streamSession struct {
+	cancel context.CancelFunc
+}
+
+// StreamHandler 将AI回复逐段推送到浏览器的 WebSocket 处理器，每个连接同时只进行一次分析，连接关闭时取消
+type StreamHandler struct {
+	mutex    sync.Mutex
+	sessions map[string]*streamSession
+}
+
+// NewStreamHandler 创建流式分析处理器
+func NewStreamHandler() *StreamHandler {
+	return &StreamHandler{
+		sessions: make(map[string]*streamSession),
+	}
+}
+
+// RegisterStreamHandler 注册流式分析 WebSocket 处理器
+func RegisterStreamHandler(path string) http.Handler {
+	return ws.RegisterHandler(path, NewStreamHandler())
+}
+
+// StreamWebSocket 流式分析的 WebSocket 接口，握手时记录当前用户，用量计入该用户
+func StreamWebSocket(path string) gin.HandlerFunc {
+	streamHandler := RegisterStreamHandler(path)
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), streamUserKey{}, c.GetString("username"))
+		streamHandler.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+	}
+}
+
+// HandleConnection 实现 ws.ConnectionHandler
+func (h *StreamHandler) HandleConnection(conn *ws.Connection) error {
+	return nil
+}
+
+// HandleMessage 处理分析或取消请求，分析在后台进行，以便继续接收取消请求
+func (h *StreamHandler) HandleMessage(conn *ws.Connection, messageType int, data []byte) error {
+	if messageType != websocket.TextMessage {
+		return deliver(conn, ws.Message{Type: StreamMessageError, Data: "仅支持文本消息"})
+	}
+
+	var request StreamRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return deliver(conn, ws.Message{Type: StreamMessageError, Data: "请求格式错误: " + err.Error()})
+	}
+
+	if request.Type == "cancel" {
+		h.cancel(conn.ID)
+		return nil
+	}
+
+	prompt, ok := streamPrompts[request.Type]
+	if !ok {
+		return deliver(conn, ws.Message{Type: StreamMessageError, Data: "不支持的分析类型: " + request.Type})
+	}
+	if strings.TrimSpace(request.Content) == "" {
+		return deliver(conn, ws.Message{Type: StreamMessageError, Data: "分析内容不能为空"})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &streamSession{cancel: cancel}
+
+	h.mutex.Lock()
+	if _, running := h.sessions[conn.ID]; running {
+		h.mutex.Unlock()
+		cancel()
+		return deliver(conn, ws.Message{Type: StreamMessageError, Data: "已有分析正在进行，请等待完成或先取消"})
+	}
+	h.sessions[conn.ID] = session
+	h.mutex.Unlock()
+
+	go h.stream(ctx, conn, session, request.Type, prompt, request.Content)
+	return nil
+}
+
+// HandleClose 连接关闭时取消正在进行的分析
+func (h *StreamHandler) HandleClose(conn *ws.Connection) error {
+	h.cancel(conn.ID)
+	return nil
+}
+
+// cancel 取消连接上正在进行的分析
+func (h *StreamHandler) cancel(connID string) {
+	h.mutex.Lock()
+	session := h.sessions[connID]
+	h.mutex.Unlock()
+
+	if session != nil {
+		session.cancel()
+	}
+}
+
+// finish 分析结束后释放会话
+func (h *StreamHandler) finish(connID string, session *streamSession) {
+	h.mutex.Lock()
+	if h.sessions[connID] == session {
+		delete(h.sessions, connID)
+	}
+	h.mutex.Unlock()
+	session.cancel()
+}
+
+// stream 调用AI流式接口并将内容片段推送到连接
+func (h *StreamHandler) stream(ctx context.Context, conn *ws.Connection, session *streamSession, kind, prompt, content string) {
+	defer h.finish(conn.ID, session)
+
+	var username string
+	if conn.Request != nil {
+		username, _ = conn.Request.Context().Value(streamUserKey{}).(string)
+	}
+	client, _, err := newUserClient(username, kind)
+	if err != nil {
+		_ = deliver(conn, ws.Message{Type: StreamMessageError, Data: aiErrorMessage(err)})
+		return
+	}
+
+	sender := &deltaSender{conn: conn}
+	reply, err := client.ChatStream(ctx, prompt, content, sender.send)
+	if errors.Is(err, ws.ErrConnectionClosed) {
+		return
+	}
+	if flushErr := sender.flush(); flushErr != nil {
+		return
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		_ = deliver(conn, ws.Message{Type: StreamMessageCancelled, Data: reply})
+	case err != nil:
+		_ = deliver(conn, ws.Message{Type: StreamMessageError, Data: aiErrorMessage(err)})
+	default:
+		_ = deliver(conn, ws.Message{Type: StreamMessageDone, Data: reply})
+	}
+}
+
+// deltaSender 推送内容片段，发送缓冲区已满时合并到下一个片段，不丢弃内容也不阻塞读取AI回复
+type deltaSender struct {
+	conn    *ws.Connection
+	pending strings.Builder
+}
+
+// send 推送片段，连接已关闭时返回错误以中止AI请求
+func (s *deltaSender) send(delta string) error {
+	s.pending.WriteString(delta)
+
+	err := s.conn.SendMessage(ws.Message{Type: StreamMessageDelta, Data: s.pending.String()})
+	switch {
+	case err == nil:
+		s.pending.Reset()
+		return nil
+	case errors.Is(err, ws.ErrSendBufferFull):
+		return nil
+	default:
+		return err
+	}
+}
+
+// flush 推送合并后尚未发出的内容
+func (s *deltaSender) flush() error {
+	if s.pending.Len() == 0 {
+		return nil
+	}
+	err := deliver(s.conn, ws.Message{Type: StreamMessageDelta, Data: s.pending.String()})
+	s.pending.Reset()
+	return err
+}
+
+// deliver 发送消息，发送缓冲区已满时在 streamDeliverTimeout 内重试
+func deliver(conn *ws.Connection, message ws.Message) error {
+	deadline := time.Now().Add(streamDeliverTimeout)
+	for {
+		err := conn.SendMessage(message)
+		if !errors.Is(err, ws.ErrSendBufferFull) || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(streamRetryInterval)
+	}
+}
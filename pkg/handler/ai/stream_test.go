@@ -0,0 +1,159 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ws"
+	"github.com/gorilla/websocket"
+	"github.com/sashabaranov/go-openai"
+)
+
+// mockStreamLLM is a local OpenAI-compatible server that streams the scripted chunks as server-sent events and,
+// when hold is set, keeps the response open after the last chunk until the client goes away
+type mockStreamLLM struct {
+	chunks  []string
+	hold    bool
+	aborted chan struct{}
+}
+
+func (m *mockStreamLLM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	flusher := w.(http.Flusher)
+
+	for _, chunk := range m.chunks {
+		data, _ := json.Marshal(openai.ChatCompletionStreamResponse{
+			ID: "mock",
+			Choices: []openai.ChatCompletionStreamChoice{
+				{Delta: openai.ChatCompletionStreamChoiceDelta{Content: chunk}},
+			},
+		})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+
+	if m.hold {
+		<-r.Context().Done()
+		close(m.aborted)
+		return
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	flusher.Flush()
+}
+
+func dialStream(t *testing.T, mock *mockStreamLLM) *websocket.Conn {
+	t.Helper()
+
+	setupAIDatabase(t)
+	llm := httptest.NewServer(mock)
+	t.Cleanup(llm.Close)
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+	config.AppConfig = &config.Config{AI: config.AIConfig{Provider: "custom", BaseURL: llm.URL, Model: "mock-model"}}
+
+	server := httptest.NewServer(RegisterStreamHandler("/ai-test/" + t.Name()))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func readStreamMessage(t *testing.T, conn *websocket.Conn) ws.Message {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message ws.Message
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	return message
+}
+
+func TestStreamPushesDeltasUntilDone(t *testing.T) {
+	conn := dialStream(t, &mockStreamLLM{chunks: []string{"[{\"problem\":", "\"弱密码\"", "}]"}})
+
+	if err := conn.WriteJSON(StreamRequest{Type: "log", Content: "sshd: Failed password for root"}); err != nil {
+		t.Fatalf("write request: %v", err)
+	}
+
+	var streamed strings.Builder
+	for {
+		message := readStreamMessage(t, conn)
+		if message.Type == StreamMessageDelta {
+			streamed.WriteString(message.Data.(string))
+			continue
+		}
+		if message.Type != StreamMessageDone {
+			t.Fatalf("expected done, got %s: %v", message.Type, message.Data)
+		}
+		if message.Data != `[{"problem":"弱密码"}]` || streamed.String() != message.Data {
+			t.Fatalf("unexpected reply %q, streamed %q", message.Data, streamed.String())
+		}
+		return
+	}
+}
+
+func TestStreamCancelledWhenSocketCloses(t *testing.T) {
+	mock := &mockStreamLLM{chunks: []string{"partial"}, hold: true, aborted: make(chan struct{})}
+	conn := dialStream(t, mock)
+
+	if err := conn.WriteJSON(StreamRequest{Type: "files", Content: "/tmp/a.log"}); err != nil {
+		t.Fatalf("write request: %v", err)
+	}
+	if message := readStreamMessage(t, conn); message.Type != StreamMessageDelta || message.Data != "partial" {
+		t.Fatalf("expected first delta, got %s: %v", message.Type, message.Data)
+	}
+
+	_ = conn.Close()
+	select {
+	case <-mock.aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("AI request was not cancelled after the socket closed")
+	}
+}
+
+func TestStreamCancelMessage(t *testing.T) {
+	mock := &mockStreamLLM{chunks: []string{"partial"}, hold: true, aborted: make(chan struct{})}
+	conn := dialStream(t, mock)
+
+	if err := conn.WriteJSON(StreamRequest{Type: "log", Content: "kernel: Out of memory"}); err != nil {
+		t.Fatalf("write request: %v", err)
+	}
+	readStreamMessage(t, conn)
+
+	if err := conn.WriteJSON(StreamRequest{Type: "cancel"}); err != nil {
+		t.Fatalf("write cancel: %v", err)
+	}
+	message := readStreamMessage(t, conn)
+	if message.Type != StreamMessageCancelled || message.Data != "partial" {
+		t.Fatalf("expected cancelled with partial reply, got %s: %v", message.Type, message.Data)
+	}
+	<-mock.aborted
+}
+
+func TestStreamRejectsInvalidRequests(t *testing.T) {
+	conn := dialStream(t, &mockStreamLLM{})
+
+	for _, request := range []StreamRequest{
+		{Type: "shell", Content: "rm -rf /"},
+		{Type: "log", Content: "  "},
+	} {
+		if err := conn.WriteJSON(request); err != nil {
+			t.Fatalf("write request: %v", err)
+		}
+		if message := readStreamMessage(t, conn); message.Type != StreamMessageError {
+			t.Errorf("%+v: expected error, got %s: %v", request, message.Type, message.Data)
+		}
+	}
+}
@@ -4,8 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/dbmgr"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/safepath"
+	dbmgr2 "github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/dbmgr"
 )
 
 // ToolCall represents a tool call from AI
@@ -29,9 +35,18 @@ type ToolCallResult struct {
 	Error      string      `json:"error,omitempty"`
 }
 
+// ToolTarget describes the database a receiver is connected to, so that a deferred tool call can be replayed against it
+type ToolTarget struct {
+	Engine string `json:"engine,omitempty"`
+	Path   string `json:"path,omitempty"` // SQLite database file path
+}
+
 // ToolCallReceiver handles incoming tool calls from AI
 type ToolCallReceiver struct {
-	sqliteManager *dbmgr.SQLiteManager
+	manager dbmgr.DatabaseManager
+	target  ToolTarget
+	// connections holds managers opened from saved connections, keyed by "<connection id>/<database>"
+	connections map[string]dbmgr.DatabaseManager
 }
 
 // NewToolCallReceiver creates a new tool call receiver
@@ -39,6 +54,50 @@ func NewToolCallReceiver() *ToolCallReceiver {
 	return &ToolCallReceiver{}
 }
 
+// NewToolCallReceiverWithManager creates a tool call receiver bound to an already connected manager of any engine
+func NewToolCallReceiverWithManager(manager dbmgr.DatabaseManager) *ToolCallReceiver {
+	return &ToolCallReceiver{manager: manager}
+}
+
+// Target returns the database the receiver is currently connected to
+func (tcr *ToolCallReceiver) Target() ToolTarget {
+	return tcr.target
+}
+
+// RestoreTarget reconnects the receiver to a previously recorded target
+func (tcr *ToolCallReceiver) RestoreTarget(target ToolTarget) error {
+	switch target.Engine {
+	case "":
+		return nil
+	case dbmgr.EngineSQLite:
+		result := tcr.handleConnectDatabase(ToolCall{
+			Function: FunctionCall{Arguments: map[string]interface{}{"database_path": target.Path}},
+		})
+		if !result.Success {
+			return fmt.Errorf("%s", result.Error)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported tool target engine: %s", target.Engine)
+	}
+}
+
+// Close disconnects the active database connection and every saved connection opened by the receiver
+func (tcr *ToolCallReceiver) Close() error {
+	var err error
+	if tcr.manager != nil {
+		err = tcr.manager.Disconnect()
+		tcr.manager = nil
+	}
+	for key, manager := range tcr.connections {
+		if closeErr := manager.Disconnect(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		delete(tcr.connections, key)
+	}
+	return err
+}
+
 // ProcessToolCall processes a single tool call
 func (tcr *ToolCallReceiver) ProcessToolCall(toolCall ToolCall) ToolCallResult {
 	result := ToolCallResult{
@@ -67,6 +126,20 @@ func (tcr *ToolCallReceiver) ProcessToolCall(toolCall ToolCall) ToolCallResult {
 		result = tcr.handleBackupDatabase(toolCall)
 	case "vacuum_database":
 		result = tcr.handleVacuumDatabase(toolCall)
+	case "list_database_connections":
+		result = tcr.handleListConnections(toolCall)
+	case "list_connection_databases":
+		result = tcr.handleListConnectionDatabases(toolCall)
+	case "list_connection_tables":
+		result = tcr.handleListConnectionTables(toolCall)
+	case "describe_connection_table":
+		result = tcr.handleDescribeConnectionTable(toolCall)
+	case "query_connection":
+		result = tcr.handleQueryConnection(toolCall)
+	case "get_connection_server_status":
+		result = tcr.handleConnectionServerStatus(toolCall)
+	case "get_connection_slowlog":
+		result = tcr.handleConnectionSlowLog(toolCall)
 	default:
 		result.Error = fmt.Sprintf("unknown tool function: %s", toolCall.Function.Name)
 	}
@@ -103,17 +176,26 @@ func (tcr *ToolCallReceiver) handleConnectDatabase(toolCall ToolCall) ToolCallRe
 		return result
 	}
 
-	config := dbmgr.SQLiteConfig{
-		DatabasePath: databasePath,
+	databasePath, err := resolveSQLitePath(databasePath)
+	if err != nil {
+		result.Error = err.Error()
+		return result
 	}
 
-	tcr.sqliteManager = dbmgr.NewSQLiteManager(config)
-	err := tcr.sqliteManager.Connect()
+	manager, err := dbmgr.Open(dbmgr.EngineSQLite, dbmgr.ConnectionConfig{
+		Path: databasePath,
+	})
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to connect to database: %v", err)
 		return result
 	}
 
+	if tcr.manager != nil {
+		_ = tcr.manager.Disconnect()
+	}
+	tcr.manager = manager
+	tcr.target = ToolTarget{Engine: dbmgr.EngineSQLite, Path: databasePath}
+
 	result.Success = true
 	result.Result = map[string]interface{}{
 		"message": fmt.Sprintf("Successfully connected to SQLite database at %s", databasePath),
@@ -127,12 +209,12 @@ func (tcr *ToolCallReceiver) handleConnectDatabase(toolCall ToolCall) ToolCallRe
 func (tcr *ToolCallReceiver) handleGetDatabaseInfo(toolCall ToolCall) ToolCallResult {
 	result := ToolCallResult{ToolCallID: toolCall.ID}
 
-	if tcr.sqliteManager == nil {
+	if tcr.manager == nil {
 		result.Error = "no active database connection"
 		return result
 	}
 
-	info, err := tcr.sqliteManager.GetDatabaseInfo()
+	info, err := tcr.manager.ListDatabases()
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to get database info: %v", err)
 		return result
@@ -148,12 +230,12 @@ func (tcr *ToolCallReceiver) handleGetDatabaseInfo(toolCall ToolCall) ToolCallRe
 func (tcr *ToolCallReceiver) handleListTables(toolCall ToolCall) ToolCallResult {
 	result := ToolCallResult{ToolCallID: toolCall.ID}
 
-	if tcr.sqliteManager == nil {
+	if tcr.manager == nil {
 		result.Error = "no active database connection"
 		return result
 	}
 
-	tables, err := tcr.sqliteManager.GetTables()
+	tables, err := tcr.manager.ListTables("")
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to list tables: %v", err)
 		return result
@@ -169,7 +251,7 @@ func (tcr *ToolCallReceiver) handleListTables(toolCall ToolCall) ToolCallResult
 func (tcr *ToolCallReceiver) handleGetTableSchema(toolCall ToolCall) ToolCallResult {
 	result := ToolCallResult{ToolCallID: toolCall.ID}
 
-	if tcr.sqliteManager == nil {
+	if tcr.manager == nil {
 		result.Error = "no active database connection"
 		return result
 	}
@@ -180,7 +262,13 @@ func (tcr *ToolCallReceiver) handleGetTableSchema(toolCall ToolCall) ToolCallRes
 		return result
 	}
 
-	schema, err := tcr.sqliteManager.GetTableSchema(tableName)
+	describer, ok := tcr.manager.(dbmgr.TableDescriber)
+	if !ok {
+		result.Error = fmt.Sprintf("%s does not support describing tables", tcr.manager.Engine())
+		return result
+	}
+
+	schema, err := describer.GetTableSchema(tableName)
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to get table schema: %v", err)
 		return result
@@ -196,7 +284,7 @@ func (tcr *ToolCallReceiver) handleGetTableSchema(toolCall ToolCall) ToolCallRes
 func (tcr *ToolCallReceiver) handleExecuteQuery(toolCall ToolCall) ToolCallResult {
 	result := ToolCallResult{ToolCallID: toolCall.ID}
 
-	if tcr.sqliteManager == nil {
+	if tcr.manager == nil {
 		result.Error = "no active database connection"
 		return result
 	}
@@ -207,7 +295,13 @@ func (tcr *ToolCallReceiver) handleExecuteQuery(toolCall ToolCall) ToolCallResul
 		return result
 	}
 
-	queryResult, err := tcr.sqliteManager.ExecuteQuery(query)
+	// Writes must go through execute_statement, which is gated as destructive
+	if err := dbmgr2.CheckReadOnlyQuery(tcr.manager.Engine(), query); err != nil {
+		result.Error = fmt.Sprintf("%v; use execute_statement for writes", err)
+		return result
+	}
+
+	queryResult, truncated, err := dbmgr2.QueryReadOnly(tcr.manager, query)
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to execute query: %v", err)
 		return result
@@ -215,8 +309,9 @@ func (tcr *ToolCallReceiver) handleExecuteQuery(toolCall ToolCall) ToolCallResul
 
 	result.Success = true
 	result.Result = map[string]interface{}{
-		"rows":  queryResult,
-		"count": len(queryResult),
+		"rows":      queryResult,
+		"count":     len(queryResult),
+		"truncated": truncated,
 	}
 
 	return result
@@ -226,7 +321,7 @@ func (tcr *ToolCallReceiver) handleExecuteQuery(toolCall ToolCall) ToolCallResul
 func (tcr *ToolCallReceiver) handleExecuteStatement(toolCall ToolCall) ToolCallResult {
 	result := ToolCallResult{ToolCallID: toolCall.ID}
 
-	if tcr.sqliteManager == nil {
+	if tcr.manager == nil {
 		result.Error = "no active database connection"
 		return result
 	}
@@ -237,7 +332,7 @@ func (tcr *ToolCallReceiver) handleExecuteStatement(toolCall ToolCall) ToolCallR
 		return result
 	}
 
-	rowsAffected, err := tcr.sqliteManager.ExecuteStatement(statement)
+	rowsAffected, err := tcr.manager.Execute(statement)
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to execute statement: %v", err)
 		return result
@@ -256,7 +351,7 @@ func (tcr *ToolCallReceiver) handleExecuteStatement(toolCall ToolCall) ToolCallR
 func (tcr *ToolCallReceiver) handleCreateTable(toolCall ToolCall) ToolCallResult {
 	result := ToolCallResult{ToolCallID: toolCall.ID}
 
-	if tcr.sqliteManager == nil {
+	if tcr.manager == nil {
 		result.Error = "no active database connection"
 		return result
 	}
@@ -266,8 +361,12 @@ func (tcr *ToolCallReceiver) handleCreateTable(toolCall ToolCall) ToolCallResult
 		result.Error = "create_sql parameter is required and must be a string"
 		return result
 	}
+	if err := checkCreateTable(createSQL); err != nil {
+		result.Error = err.Error()
+		return result
+	}
 
-	err := tcr.sqliteManager.CreateTable(createSQL)
+	_, err := tcr.manager.Execute(createSQL)
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to create table: %v", err)
 		return result
@@ -281,11 +380,24 @@ func (tcr *ToolCallReceiver) handleCreateTable(toolCall ToolCall) ToolCallResult
 	return result
 }
 
+// checkCreateTable accepts only a single CREATE TABLE statement
+func checkCreateTable(createSQL string) error {
+	statement := strings.TrimRight(strings.TrimSpace(createSQL), "; \t\n")
+	fields := strings.Fields(statement)
+	if len(fields) < 3 || !strings.EqualFold(fields[0], "CREATE") || !strings.EqualFold(fields[1], "TABLE") {
+		return fmt.Errorf("create_sql must be a CREATE TABLE statement")
+	}
+	if strings.Contains(statement, ";") {
+		return fmt.Errorf("only a single CREATE TABLE statement is allowed")
+	}
+	return nil
+}
+
 // handleDropTable handles dropping tables
 func (tcr *ToolCallReceiver) handleDropTable(toolCall ToolCall) ToolCallResult {
 	result := ToolCallResult{ToolCallID: toolCall.ID}
 
-	if tcr.sqliteManager == nil {
+	if tcr.manager == nil {
 		result.Error = "no active database connection"
 		return result
 	}
@@ -296,7 +408,13 @@ func (tcr *ToolCallReceiver) handleDropTable(toolCall ToolCall) ToolCallResult {
 		return result
 	}
 
-	err := tcr.sqliteManager.DropTable(tableName)
+	dropper, ok := tcr.manager.(dbmgr.TableDropper)
+	if !ok {
+		result.Error = fmt.Sprintf("%s does not support dropping tables", tcr.manager.Engine())
+		return result
+	}
+
+	err := dropper.DropTable(tableName)
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to drop table: %v", err)
 		return result
@@ -314,7 +432,7 @@ func (tcr *ToolCallReceiver) handleDropTable(toolCall ToolCall) ToolCallResult {
 func (tcr *ToolCallReceiver) handleBackupDatabase(toolCall ToolCall) ToolCallResult {
 	result := ToolCallResult{ToolCallID: toolCall.ID}
 
-	if tcr.sqliteManager == nil {
+	if tcr.manager == nil {
 		result.Error = "no active database connection"
 		return result
 	}
@@ -325,7 +443,23 @@ func (tcr *ToolCallReceiver) handleBackupDatabase(toolCall ToolCall) ToolCallRes
 		return result
 	}
 
-	err := tcr.sqliteManager.BackupDatabase(backupPath)
+	backupper, ok := tcr.manager.(dbmgr.Backupper)
+	if !ok {
+		result.Error = fmt.Sprintf("%s does not support backups", tcr.manager.Engine())
+		return result
+	}
+
+	backupPath, err := safepath.Resolve(config.AppConfig.Backup.Root, backupPath)
+	if err != nil {
+		result.Error = fmt.Sprintf("invalid backup path: %v", err)
+		return result
+	}
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+		result.Error = fmt.Sprintf("failed to create backup directory: %v", err)
+		return result
+	}
+
+	err = backupper.Backup("", backupPath)
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to backup database: %v", err)
 		return result
@@ -344,12 +478,18 @@ func (tcr *ToolCallReceiver) handleBackupDatabase(toolCall ToolCall) ToolCallRes
 func (tcr *ToolCallReceiver) handleVacuumDatabase(toolCall ToolCall) ToolCallResult {
 	result := ToolCallResult{ToolCallID: toolCall.ID}
 
-	if tcr.sqliteManager == nil {
+	if tcr.manager == nil {
 		result.Error = "no active database connection"
 		return result
 	}
 
-	err := tcr.sqliteManager.VacuumDatabase()
+	vacuumer, ok := tcr.manager.(dbmgr.Vacuumer)
+	if !ok {
+		result.Error = fmt.Sprintf("%s does not support vacuum", tcr.manager.Engine())
+		return result
+	}
+
+	err := vacuumer.VacuumDatabase()
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to vacuum database: %v", err)
 		return result
@@ -3,11 +3,27 @@ package ai
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/sqlguard"
+	"github.com/sashabaranov/go-openai"
+)
+
+// RiskLevel classifies how dangerous executing a tool is
+type RiskLevel string
+
+const (
+	// RiskRead tools only read data and run without confirmation
+	RiskRead RiskLevel = "read"
+	// RiskWrite tools modify data in a recoverable way
+	RiskWrite RiskLevel = "write"
+	// RiskDestructive tools may irreversibly destroy data and require human approval
+	RiskDestructive RiskLevel = "destructive"
 )
 
 // ToolDefinition defines a tool that can be called by AI
 type ToolDefinition struct {
 	Type     string             `json:"type"`
+	Risk     RiskLevel          `json:"risk"`
 	Function FunctionDefinition `json:"function"`
 }
 
@@ -29,9 +45,10 @@ func GenerateToolChain() *ToolChain {
 		Tools: []ToolDefinition{
 			{
 				Type: "function",
+				Risk: RiskRead,
 				Function: FunctionDefinition{
 					Name:        "connect_sqlite_database",
-					Description: "连接到SQLite数据库",
+					Description: "连接到SQLite数据库，仅允许配置的目录或已保存连接中的数据库文件",
 					Parameters: map[string]interface{}{
 						"type": "object",
 						"properties": map[string]interface{}{
@@ -46,6 +63,7 @@ func GenerateToolChain() *ToolChain {
 			},
 			{
 				Type: "function",
+				Risk: RiskRead,
 				Function: FunctionDefinition{
 					Name:        "get_database_info",
 					Description: "获取数据库基本信息，包括大小、表数量等",
@@ -57,6 +75,7 @@ func GenerateToolChain() *ToolChain {
 			},
 			{
 				Type: "function",
+				Risk: RiskRead,
 				Function: FunctionDefinition{
 					Name:        "list_tables",
 					Description: "列出数据库中的所有表及其信息",
@@ -68,6 +87,7 @@ func GenerateToolChain() *ToolChain {
 			},
 			{
 				Type: "function",
+				Risk: RiskRead,
 				Function: FunctionDefinition{
 					Name:        "get_table_schema",
 					Description: "获取指定表的结构信息",
@@ -85,9 +105,10 @@ func GenerateToolChain() *ToolChain {
 			},
 			{
 				Type: "function",
+				Risk: RiskRead,
 				Function: FunctionDefinition{
 					Name:        "execute_query",
-					Description: "执行SQL查询语句（SELECT）",
+					Description: "执行单条只读SQL查询（SELECT/SHOW/DESCRIBE/EXPLAIN），在只读事务中执行，结果行数和执行时间受限",
 					Parameters: map[string]interface{}{
 						"type": "object",
 						"properties": map[string]interface{}{
@@ -102,6 +123,7 @@ func GenerateToolChain() *ToolChain {
 			},
 			{
 				Type: "function",
+				Risk: RiskDestructive,
 				Function: FunctionDefinition{
 					Name:        "execute_statement",
 					Description: "执行SQL语句（INSERT, UPDATE, DELETE）",
@@ -119,6 +141,7 @@ func GenerateToolChain() *ToolChain {
 			},
 			{
 				Type: "function",
+				Risk: RiskWrite,
 				Function: FunctionDefinition{
 					Name:        "create_table",
 					Description: "创建新表",
@@ -136,6 +159,7 @@ func GenerateToolChain() *ToolChain {
 			},
 			{
 				Type: "function",
+				Risk: RiskDestructive,
 				Function: FunctionDefinition{
 					Name:        "drop_table",
 					Description: "删除表",
@@ -153,6 +177,7 @@ func GenerateToolChain() *ToolChain {
 			},
 			{
 				Type: "function",
+				Risk: RiskDestructive,
 				Function: FunctionDefinition{
 					Name:        "backup_database",
 					Description: "备份数据库",
@@ -161,7 +186,7 @@ func GenerateToolChain() *ToolChain {
 						"properties": map[string]interface{}{
 							"backup_path": map[string]interface{}{
 								"type":        "string",
-								"description": "备份文件保存路径",
+								"description": "备份文件保存路径，位于备份根目录内，相对路径视为相对备份根目录",
 							},
 						},
 						"required": []string{"backup_path"},
@@ -170,6 +195,7 @@ func GenerateToolChain() *ToolChain {
 			},
 			{
 				Type: "function",
+				Risk: RiskDestructive,
 				Function: FunctionDefinition{
 					Name:        "vacuum_database",
 					Description: "优化数据库，清理空间并重建索引",
@@ -181,6 +207,7 @@ func GenerateToolChain() *ToolChain {
 			},
 		},
 	}
+	toolChain.Tools = append(toolChain.Tools, connectionTools()...)
 
 	return toolChain
 }
@@ -195,6 +222,35 @@ func (tc *ToolChain) GetToolByName(name string) (*ToolDefinition, error) {
 	return nil, fmt.Errorf("tool '%s' not found", name)
 }
 
+// RiskOf returns the risk level of a tool; unknown tools are treated as destructive
+func (tc *ToolChain) RiskOf(name string) RiskLevel {
+	tool, err := tc.GetToolByName(name)
+	if err != nil || tool.Risk == "" {
+		return RiskDestructive
+	}
+	return tool.Risk
+}
+
+// RiskOfCall returns the risk level of a concrete call. Tools that take free-form SQL are classified by their
+// arguments as well, so a write statement passed to a read tool is still treated as destructive
+func (tc *ToolChain) RiskOfCall(call ToolCall) RiskLevel {
+	risk := tc.RiskOf(call.Function.Name)
+
+	switch call.Function.Name {
+	case "execute_query":
+		query, _ := call.Function.Arguments["query"].(string)
+		if sqlguard.CheckReadOnly(query) != nil {
+			return RiskDestructive
+		}
+	case "create_table":
+		createSQL, _ := call.Function.Arguments["create_sql"].(string)
+		if checkCreateTable(createSQL) != nil {
+			return RiskDestructive
+		}
+	}
+	return risk
+}
+
 // ToJSON converts the tool chain to JSON format
 func (tc *ToolChain) ToJSON() (string, error) {
 	jsonData, err := json.MarshalIndent(tc, "", "  ")
@@ -224,3 +280,24 @@ func GeneratePromptWithTools(userQuery string) (string, []ToolDefinition, error)
 
 	return prompt, toolChain.Tools, nil
 }
+
+// OpenAITools converts the tool chain to the function-calling format of OpenAI-compatible APIs
+func (tc *ToolChain) OpenAITools() []openai.Tool {
+	tools := make([]openai.Tool, 0, len(tc.Tools))
+	for _, tool := range tc.Tools {
+		description := tool.Function.Description
+		if tool.Risk == RiskDestructive {
+			description += "（高风险操作，调用后需经人工审批才会执行）"
+		}
+
+		tools = append(tools, openai.Tool{
+			Type: openai.ToolType(tool.Type),
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Function.Name,
+				Description: description,
+				Parameters:  tool.Function.Parameters,
+			},
+		})
+	}
+	return tools
+}
@@ -0,0 +1,244 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/audit"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/ai"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sashabaranov/go-openai"
+)
+
+// ErrQuotaExceeded 用户本月的 AI 用量已达到配额
+var ErrQuotaExceeded = errors.New("monthly AI token quota exceeded")
+
+// usageMeter 实现 ai.Meter：用量记在用户名下，请求前检查用户的月度配额
+type usageMeter struct {
+	username       string
+	endpoint       string
+	conversationID *uint
+}
+
+// Allow 本月用量达到配额时拒绝请求
+func (m *usageMeter) Allow(ctx context.Context) error {
+	quota := monthlyQuota(m.username)
+	if quota <= 0 {
+		return nil
+	}
+	if monthlyUsage(m.username, time.Now()) >= quota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Record 写入一条用量记录
+func (m *usageMeter) Record(model string, usage openai.Usage, estimated bool) {
+	record := models.AIUsage{
+		Username:         m.username,
+		Endpoint:         m.endpoint,
+		ConversationID:   m.conversationID,
+		Model:            model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		Estimated:        estimated,
+	}
+	if err := database.DbConn.Create(&record).Error; err != nil {
+		log.Printf("记录 AI 用量失败: %v", err)
+	}
+}
+
+// newUserClient 创建计入 username 用量的客户端，endpoint 标识调用来源
+func newUserClient(username, endpoint string) (*ai.Client, *usageMeter, error) {
+	client, err := ai.DefaultClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	meter := &usageMeter{username: username, endpoint: endpoint}
+	return client.WithMeter(meter), meter, nil
+}
+
+// userClient 创建计入当前登录用户用量的客户端
+func userClient(c *gin.Context, endpoint string) (*ai.Client, *usageMeter, error) {
+	return newUserClient(c.GetString("username"), endpoint)
+}
+
+// monthStart 返回 t 所在月份的第一天零点
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// monthlyQuota 返回用户的月度配额，0 表示不限
+func monthlyQuota(username string) int64 {
+	var quota models.AIQuota
+	if result := database.DbConn.Where("username = ?", username).Limit(1).Find(&quota); result.Error != nil {
+		return 0
+	}
+	return quota.MonthlyTokens
+}
+
+// monthlyUsage 返回用户在 t 所在月份已使用的 token 数
+func monthlyUsage(username string, t time.Time) int64 {
+	start := monthStart(t)
+
+	var total int64
+	database.DbConn.Model(&models.AIUsage{}).
+		Select("COALESCE(SUM(total_tokens), 0)").
+		Where("username = ? AND created_at >= ? AND created_at < ?", username, start, start.AddDate(0, 1, 0)).
+		Scan(&total)
+	return total
+}
+
+// parseMonth 解析 YYYY-MM 格式的月份，为空时返回当前月份
+func parseMonth(value string) (time.Time, error) {
+	if value == "" {
+		return monthStart(time.Now()), nil
+	}
+	return time.ParseInLocation("2006-01", value, time.Local)
+}
+
+// usageSummaries 汇总指定月份的用量，username 为空时返回所有用户
+func usageSummaries(month time.Time, username string) ([]models.AIUsageSummary, error) {
+	query := database.DbConn.Model(&models.AIUsage{}).
+		Select("username, COUNT(*) AS requests, SUM(prompt_tokens) AS prompt_tokens, "+
+			"SUM(completion_tokens) AS completion_tokens, SUM(total_tokens) AS total_tokens").
+		Where("created_at >= ? AND created_at < ?", month, month.AddDate(0, 1, 0)).
+		Group("username").
+		Order("total_tokens DESC")
+	if username != "" {
+		query = query.Where("username = ?", username)
+	}
+
+	summaries := make([]models.AIUsageSummary, 0)
+	if err := query.Scan(&summaries).Error; err != nil {
+		return nil, err
+	}
+
+	var quotas []models.AIQuota
+	if err := database.DbConn.Find(&quotas).Error; err != nil {
+		return nil, err
+	}
+	quotaOf := make(map[string]int64, len(quotas))
+	for _, quota := range quotas {
+		quotaOf[quota.Username] = quota.MonthlyTokens
+	}
+
+	for i := range summaries {
+		summaries[i].MonthlyQuota = quotaOf[summaries[i].Username]
+	}
+	if username != "" && len(summaries) == 0 {
+		summaries = append(summaries, models.AIUsageSummary{Username: username, MonthlyQuota: quotaOf[username]})
+	}
+	return summaries, nil
+}
+
+// GetAIUsage 获取AI用量
+// @Summary 获取所有用户的AI用量
+// @Description 按用户汇总指定月份的 token 用量及其月度配额
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param month query string false "月份，格式 YYYY-MM，默认当前月份"
+// @Success 200 {object} handler.Response{data=[]models.AIUsageSummary} "获取成功"
+// @Failure 400 {object} handler.Response "月份格式错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "权限不足"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/ai/usage [get]
+func GetAIUsage(c *gin.Context) {
+	month, err := parseMonth(c.Query("month"))
+	if err != nil {
+		handler.Respond(c, http.StatusBadRequest, "月份格式错误，应为 YYYY-MM", nil)
+		return
+	}
+
+	summaries, err := usageSummaries(month, "")
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	handler.Respond(c, http.StatusOK, nil, summaries)
+}
+
+// GetMyAIUsage 获取当前用户的AI用量
+// @Summary 获取当前用户的AI用量
+// @Description 获取当前用户指定月份的 token 用量及月度配额
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param month query string false "月份，格式 YYYY-MM，默认当前月份"
+// @Success 200 {object} handler.Response{data=models.AIUsageSummary} "获取成功"
+// @Failure 400 {object} handler.Response "月份格式错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/ai/usage/me [get]
+func GetMyAIUsage(c *gin.Context) {
+	month, err := parseMonth(c.Query("month"))
+	if err != nil {
+		handler.Respond(c, http.StatusBadRequest, "月份格式错误，应为 YYYY-MM", nil)
+		return
+	}
+
+	summaries, err := usageSummaries(month, c.GetString("username"))
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	handler.Respond(c, http.StatusOK, nil, summaries[0])
+}
+
+// SetAIQuota 设置用户的AI配额
+// @Summary 设置用户的AI月度配额
+// @Description 设置用户每月可使用的 token 数，达到配额后该用户的 AI 请求返回 429，0 表示不限
+// @Tags AI助手
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param username path string true "用户名"
+// @Param request body models.AIQuotaRequest true "配额"
+// @Success 200 {object} handler.Response{data=models.AIQuota} "设置成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "权限不足"
+// @Failure 404 {object} handler.Response "用户不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/ai/quotas/{username} [put]
+func SetAIQuota(c *gin.Context) {
+	var request models.AIQuotaRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	username := c.Param("username")
+	var count int64
+	database.DbConn.Model(&models.User{}).Where("username = ?", username).Count(&count)
+	if count == 0 {
+		handler.Respond(c, http.StatusNotFound, "用户不存在", nil)
+		return
+	}
+
+	quota := models.AIQuota{Username: username}
+	if err := database.DbConn.Where("username = ?", username).FirstOrInit(&quota).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	quota.MonthlyTokens = request.MonthlyTokens
+	if err := database.DbConn.Save(&quota).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	audit.Record(c, models.AuditAIQuotaChanged, username, true, strconv.FormatInt(quota.MonthlyTokens, 10))
+	handler.Respond(c, http.StatusOK, nil, quota)
+}
@@ -0,0 +1,175 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/middleware"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sashabaranov/go-openai"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupAIDatabase 初始化对话、用量和配额表
+func setupAIDatabase(t *testing.T) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "panel.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.AuditLog{}, &models.AIToolApproval{},
+		&models.AIConversation{}, &models.AIMessage{}, &models.AIUsage{}, &models.AIQuota{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	original := database.DbConn
+	database.DbConn = db
+	t.Cleanup(func() { database.DbConn = original })
+}
+
+// setupConversationRouter 将 AI 配置指向 mock 服务并挂载对话和用量接口，X-User 和 X-Role 请求头模拟已认证用户
+func setupConversationRouter(t *testing.T, replies ...string) (*gin.Engine, *mockLLM) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	setupAIDatabase(t)
+
+	mock := &mockLLM{}
+	for _, reply := range replies {
+		mock.replies = append(mock.replies, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: reply})
+	}
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+	config.AppConfig = &config.Config{AI: config.AIConfig{Provider: "custom", BaseURL: server.URL, Model: "mock-model"}}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("username", c.GetHeader("X-User"))
+		c.Set("claims", &middleware.Claims{Username: c.GetHeader("X-User"), Role: c.GetHeader("X-Role")})
+	})
+	r.POST("/db", DatabaseAgent)
+	r.GET("/conversations/:id", GetConversation)
+	r.GET("/usage", GetAIUsage)
+	r.PUT("/quotas/:username", SetAIQuota)
+	return r, mock
+}
+
+func sendAs(r *gin.Engine, user, role, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", user)
+	req.Header.Set("X-Role", role)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decodeAgentResponse(t *testing.T, w *httptest.ResponseRecorder) DatabaseAgentResponse {
+	t.Helper()
+	var resp struct {
+		Data DatabaseAgentResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp.Data
+}
+
+func TestDatabaseAgentContinuesConversation(t *testing.T) {
+	r, mock := setupConversationRouter(t, "users 表共有 3 行", "其中 1 个是管理员")
+
+	w := sendAs(r, "ops", models.RoleOperator, http.MethodPost, "/db", DatabaseAgentRequest{Message: "users 表有多少行"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("first question: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	conversationID := decodeAgentResponse(t, w).ConversationID
+	if conversationID == 0 {
+		t.Fatal("expected a conversation id")
+	}
+
+	// 其他用户不能继续该对话
+	w = sendAs(r, "mallory", models.RoleOperator, http.MethodPost, "/db", DatabaseAgentRequest{Message: "其中有几个管理员", ConversationID: conversationID})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("other user: expected 404, got %d", w.Code)
+	}
+
+	w = sendAs(r, "ops", models.RoleOperator, http.MethodPost, "/db", DatabaseAgentRequest{Message: "其中有几个管理员", ConversationID: conversationID})
+	if w.Code != http.StatusOK || decodeAgentResponse(t, w).Answer != "其中 1 个是管理员" {
+		t.Fatalf("follow-up: unexpected response %d: %s", w.Code, w.Body.String())
+	}
+
+	followUp := mock.requests[1].Messages
+	if len(followUp) != 4 || followUp[1].Content != "users 表有多少行" || followUp[2].Content != "users 表共有 3 行" || followUp[3].Content != "其中有几个管理员" {
+		t.Fatalf("follow-up should include the earlier turn, got %+v", followUp)
+	}
+
+	// 管理员可以查看，其他用户不能
+	if w := sendAs(r, "mallory", models.RoleOperator, http.MethodGet, "/conversations/1", nil); w.Code != http.StatusNotFound {
+		t.Errorf("other user: expected 404, got %d", w.Code)
+	}
+	w = sendAs(r, "root", models.RoleAdmin, http.MethodGet, "/conversations/1", nil)
+	var detail struct {
+		Data models.AIConversation `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &detail)
+	if w.Code != http.StatusOK || len(detail.Data.Messages) != 4 || detail.Data.Username != "ops" {
+		t.Fatalf("admin: unexpected conversation %d: %s", w.Code, w.Body.String())
+	}
+
+	var usage []models.AIUsage
+	database.DbConn.Find(&usage)
+	if len(usage) != 2 || usage[0].Username != "ops" || usage[0].ConversationID == nil || *usage[0].ConversationID != conversationID || usage[0].TotalTokens == 0 {
+		t.Fatalf("expected 2 usage records for ops, got %+v", usage)
+	}
+}
+
+func TestQuotaBlocksFurtherRequests(t *testing.T) {
+	r, mock := setupConversationRouter(t, "users 表共有 3 行", "不应被调用")
+
+	if w := sendAs(r, "root", models.RoleAdmin, http.MethodPut, "/quotas/ops", models.AIQuotaRequest{MonthlyTokens: 1}); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown user: expected 404, got %d", w.Code)
+	}
+	database.DbConn.Create(&models.User{Username: "ops", Password: "x", Role: models.RoleOperator})
+	if w := sendAs(r, "root", models.RoleAdmin, http.MethodPut, "/quotas/ops", models.AIQuotaRequest{MonthlyTokens: 1}); w.Code != http.StatusOK {
+		t.Fatalf("set quota: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := sendAs(r, "ops", models.RoleOperator, http.MethodPost, "/db", DatabaseAgentRequest{Message: "users 表有多少行"}); w.Code != http.StatusOK {
+		t.Fatalf("first request: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := sendAs(r, "ops", models.RoleOperator, http.MethodPost, "/db", DatabaseAgentRequest{Message: "再查一次"}); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("over quota: expected 429, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mock.requests) != 1 {
+		t.Fatalf("requests over quota must not reach the provider, got %d", len(mock.requests))
+	}
+
+	w := sendAs(r, "root", models.RoleAdmin, http.MethodGet, "/usage", nil)
+	var resp struct {
+		Data []models.AIUsageSummary `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Data) != 1 || resp.Data[0].Username != "ops" || resp.Data[0].Requests != 1 || resp.Data[0].MonthlyQuota != 1 || resp.Data[0].TotalTokens == 0 {
+		t.Fatalf("unexpected usage summary %s", w.Body.String())
+	}
+	if w := sendAs(r, "root", models.RoleAdmin, http.MethodGet, "/usage?month=2024-13", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid month: expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditLogs 获取审计日志
+// @Summary 获取审计日志
+// @Description 按时间倒序获取审计日志，可按用户名和事件类型筛选
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param username query string false "用户名"
+// @Param action query string false "事件类型，如 login.failed"
+// @Param limit query int false "返回条数，默认100，最大1000"
+// @Param offset query int false "偏移量"
+// @Success 200 {object} handler.Response{data=object{logs=[]models.AuditLog,total=int}} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "权限不足"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/audit [get]
+func GetAuditLogs(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := database.DbConn.Model(&models.AuditLog{})
+	if username := c.Query("username"); username != "" {
+		query = query.Where("username = ?", username)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, gin.H{"logs": logs, "total": total})
+}
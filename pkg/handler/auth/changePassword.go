@@ -17,7 +17,7 @@ type ChangePasswordRequest struct {
 
 // ChangePassword 修改密码
 // @Summary 修改密码
-// @Description 修改当前用户密码
+// @Description 修改当前用户密码，成功后其他设备上的会话将被撤销
 // @Tags 认证
 // @Accept json
 // @Produce json
@@ -66,5 +66,11 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
+	// 密码修改后撤销其他设备上的会话，仅保留当前会话
+	if _, err := RevokeUserSessions(user.Username, c.GetString("session_id")); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "撤销其他会话失败", nil)
+		return
+	}
+
 	handler.Respond(c, http.StatusOK, "密码修改成功", nil)
 }
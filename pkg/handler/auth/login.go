@@ -3,21 +3,17 @@ package auth
 import (
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
-	"time"
 
-	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
-	"github.com/EtaPanel-dev/EtaPanel/core/pkg/middleware"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // LoginRequest 登录请求参数
 type LoginRequest struct {
-	Username string `json:"username" binding:"required" example:"demo" `
+	Username string `json:"username" binding:"required" example:"admin" `
 	Password string `json:"password" binding:"required" example:"Abc123456" `
 }
 
@@ -29,7 +25,7 @@ type LoginResponse struct {
 
 // Login 登录
 // @Summary 用户登录
-// @Description 通过用户名和密码进行登录，返回JWT token
+// @Description 通过用户名和密码进行登录，返回JWT token；若已启用两步验证则返回挑战token（two_factor_required=true）
 // @Tags 认证
 // @Accept json
 // @Produce json
@@ -37,6 +33,7 @@ type LoginResponse struct {
 // @Success 200 {object} handler.Response{data=LoginResponse} "登录成功"
 // @Failure 400 {object} handler.Response "请求参数错误"
 // @Failure 401 {object} handler.Response "用户名或密码错误"
+// @Failure 429 {object} handler.Response "失败次数过多，请稍后重试"
 // @Failure 500 {object} handler.Response "服务器内部错误"
 // @Router /public/login [post]
 func Login(c *gin.Context) {
@@ -47,41 +44,45 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// 失败次数过多时按退避时间拒绝
+	if !allowLoginAttempt(c, loginData.Username) {
+		return
+	}
+
 	// 从数据库查找用户
 	var user models.User
 	if err := database.DbConn.Where("username = ?", loginData.Username).First(&user).Error; err != nil {
+		recordLoginFailure(c, loginData.Username, "用户不存在")
 		handler.Respond(c, http.StatusUnauthorized, "用户名或密码错误", nil)
 		return
 	}
 
 	// 验证密码
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginData.Password)); err != nil {
+		recordLoginFailure(c, loginData.Username, "密码错误")
 		handler.Respond(c, http.StatusUnauthorized, "用户名或密码错误", nil)
 		return
 	}
 
-	// 生成JWT token，使用middleware中的Claims结构
-	expirationTime := time.Now().Add(24 * time.Hour)
-	claims := &middleware.Claims{
-		Username: loginData.Username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	// 已启用两步验证时先返回挑战token，由 /public/login/2fa 完成登录
+	if user.TOTPEnabled {
+		releaseLoginAttempt(c, loginData.Username)
+		challenge, err := issueChallenge(user.Username)
+		if err != nil {
+			handler.Respond(c, http.StatusInternalServerError, "生成密钥失败", nil)
+			return
+		}
+		handler.Respond(c, http.StatusOK, "需要两步验证", challenge)
+		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	jwtSecret := []byte(config.AppConfig.JWT.Secret)
-	tokenString, err := token.SignedString(jwtSecret)
+	response, err := issueSession(c, user)
 	if err != nil {
+		releaseLoginAttempt(c, loginData.Username)
 		handler.Respond(c, http.StatusInternalServerError, "生成密钥失败", nil)
 		return
 	}
 
-	response := LoginResponse{
-		Token:     tokenString,
-		ExpiresAt: expirationTime.Unix(),
-	}
-
+	recordLoginSuccess(c, user.Username)
 	handler.Respond(c, http.StatusOK, "登录成功", response)
 }
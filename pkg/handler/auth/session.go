@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/middleware"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// sessionTTL 会话有效期
+const sessionTTL = 24 * time.Hour
+
+// issueSession 为用户创建服务端会话并签发绑定该会话的JWT
+func issueSession(c *gin.Context, user models.User) (LoginResponse, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return LoginResponse{}, err
+	}
+
+	now := time.Now()
+	session := models.AuthToken{
+		TokenID:    hex.EncodeToString(idBytes),
+		Username:   user.Username,
+		UserAgent:  c.Request.UserAgent(),
+		ClientIP:   c.ClientIP(),
+		ExpiresAt:  now.Add(sessionTTL),
+		LastSeenAt: now,
+	}
+
+	// 生成JWT token，使用middleware中的Claims结构
+	claims := &middleware.Claims{
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        session.TokenID,
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(config.AppConfig.JWT.Secret))
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	// 顺带清理已过期的会话
+	database.DbConn.Unscoped().Where("expires_at < ?", now).Delete(&models.AuthToken{})
+
+	if err := database.DbConn.Create(&session).Error; err != nil {
+		return LoginResponse{}, err
+	}
+
+	return LoginResponse{
+		Token:     tokenString,
+		ExpiresAt: session.ExpiresAt.Unix(),
+	}, nil
+}
+
+// RevokeUserSessions 撤销用户的所有有效会话，exceptTokenID 非空时保留该会话
+func RevokeUserSessions(username, exceptTokenID string) (int64, error) {
+	query := database.DbConn.Model(&models.AuthToken{}).
+		Where("username = ? AND revoked_at IS NULL", username)
+	if exceptTokenID != "" {
+		query = query.Where("token_id <> ?", exceptTokenID)
+	}
+
+	result := query.Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// GetSessions 获取当前用户的会话列表
+// @Summary 获取会话列表
+// @Description 获取当前用户所有未过期且未撤销的登录会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=[]models.AuthToken} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/sessions [get]
+func GetSessions(c *gin.Context) {
+	username := c.GetString("username")
+	currentID := c.GetString("session_id")
+
+	var sessions []models.AuthToken
+	if err := database.DbConn.
+		Where("username = ? AND revoked_at IS NULL AND expires_at > ?", username, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].TokenID == currentID
+	}
+
+	handler.Respond(c, http.StatusOK, nil, sessions)
+}
+
+// RevokeSession 撤销指定会话
+// @Summary 撤销会话
+// @Description 撤销当前用户的指定会话，该会话的token立即失效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "会话ID"
+// @Success 200 {object} handler.Response "撤销成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "会话不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	var session models.AuthToken
+	if err := database.DbConn.
+		Where("id = ? AND username = ?", c.Param("id"), c.GetString("username")).
+		First(&session).Error; err != nil {
+		handler.Respond(c, http.StatusNotFound, "会话不存在", nil)
+		return
+	}
+
+	if session.RevokedAt == nil {
+		if err := database.DbConn.Model(&session).Update("revoked_at", time.Now()).Error; err != nil {
+			handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+	}
+
+	handler.Respond(c, http.StatusOK, "会话已撤销", nil)
+}
+
+// RevokeAllSessions 退出所有设备
+// @Summary 退出所有设备
+// @Description 撤销当前用户的全部会话（包括当前会话）
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=object{revoked=int}} "撤销成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/sessions/revoke-all [post]
+func RevokeAllSessions(c *gin.Context) {
+	revoked, err := RevokeUserSessions(c.GetString("username"), "")
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "已退出所有设备", gin.H{"revoked": revoked})
+}
+
+// Logout 退出登录
+// @Summary 退出登录
+// @Description 撤销当前会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response "退出成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/logout [post]
+func Logout(c *gin.Context) {
+	if err := database.DbConn.Model(&models.AuthToken{}).
+		Where("token_id = ?", c.GetString("session_id")).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "已退出登录", nil)
+}
+
+// GetCurrentUser 获取当前登录用户
+// @Summary 获取当前用户
+// @Description 获取当前登录用户的信息和角色
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=models.User} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Router /auth/me [get]
+func GetCurrentUser(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, user)
+}
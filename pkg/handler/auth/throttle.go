@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/audit"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// attemptMu 串行化失败计数的读写。登录尝试在校验密码前即预占一次失败计数，
+// 并发请求会看到已预占的计数并被退避拒绝，无法同时通过检查
+var attemptMu sync.Mutex
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func userKey(username string) string {
+	return "user:" + username
+}
+
+// backoffDelay 计算第 failures 次失败后需要等待的时间，按指数增长并封顶
+func backoffDelay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	security := config.AppConfig.Security
+	seconds := float64(security.BackoffBaseSeconds) * math.Pow(2, float64(failures-1))
+	if seconds > float64(security.BackoffMaxSeconds) {
+		seconds = float64(security.BackoffMaxSeconds)
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// loadAttempt 读取失败记录，超过过期窗口且未锁定的记录视为清零
+func loadAttempt(key string, now time.Time) models.LoginAttempt {
+	// 使用 Find 避免记录不存在时 gorm 输出 record not found 日志
+	attempt := models.LoginAttempt{Key: key}
+	if result := database.DbConn.Where("throttle_key = ?", key).Limit(1).Find(&attempt); result.Error != nil || result.RowsAffected == 0 {
+		return models.LoginAttempt{Key: key}
+	}
+
+	window := time.Duration(config.AppConfig.Security.LockoutMinutes) * time.Minute
+	if !attempt.IsLocked() && now.Sub(attempt.LastFailureAt) > window {
+		attempt.Failures = 0
+		attempt.LockedUntil = nil
+	}
+	return attempt
+}
+
+// retryAfter 返回失败记录需要等待的时间，locked 表示处于锁定状态
+func retryAfter(attempt models.LoginAttempt, now time.Time) (time.Duration, bool) {
+	if attempt.IsLocked() {
+		return attempt.LockedUntil.Sub(now), true
+	}
+	return attempt.LastFailureAt.Add(backoffDelay(attempt.Failures)).Sub(now), false
+}
+
+// reserveAttempt 检查IP和用户名是否允许本次尝试，允许时原子地预占一次失败计数；
+// 用户名的预占计数达到阈值时立即锁定，尝试成功后由 releaseLoginAttempt 撤销
+func reserveAttempt(ip, username string) (wait time.Duration, locked bool) {
+	attemptMu.Lock()
+	defer attemptMu.Unlock()
+
+	now := time.Now()
+	keys := []string{ipKey(ip), userKey(username)}
+	attempts := make([]models.LoginAttempt, 0, len(keys))
+	for _, key := range keys {
+		attempt := loadAttempt(key, now)
+		if d, isLocked := retryAfter(attempt, now); d > wait {
+			wait, locked = d, isLocked
+		}
+		attempts = append(attempts, attempt)
+	}
+	if wait > 0 {
+		return wait, locked
+	}
+
+	security := config.AppConfig.Security
+	for _, attempt := range attempts {
+		attempt.Failures++
+		attempt.LastFailureAt = now
+
+		// 仅锁定用户名，IP 只做退避，避免共享出口的用户互相影响
+		if attempt.Key == userKey(username) && attempt.Failures >= security.MaxLoginFailures {
+			lockedUntil := now.Add(time.Duration(security.LockoutMinutes) * time.Minute)
+			attempt.LockedUntil = &lockedUntil
+		}
+
+		if err := database.DbConn.Save(&attempt).Error; err != nil {
+			log.Printf("保存登录失败记录失败: %v", err)
+		}
+	}
+	return 0, false
+}
+
+// allowLoginAttempt 检查并预占本次登录尝试，不允许时直接写入 429 响应。
+// 返回 true 后调用方必须以 recordLoginFailure、recordLoginSuccess 或 releaseLoginAttempt 之一结束本次尝试
+func allowLoginAttempt(c *gin.Context, username string) bool {
+	wait, locked := reserveAttempt(c.ClientIP(), username)
+	if wait <= 0 {
+		return true
+	}
+
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+
+	message := fmt.Sprintf("登录尝试过于频繁，请 %d 秒后重试", seconds)
+	if locked {
+		message = fmt.Sprintf("登录失败次数过多，账号已被临时锁定，请 %d 秒后重试", seconds)
+	}
+
+	audit.Log(models.AuditLog{
+		Username: username,
+		Action:   models.AuditLoginThrottled,
+		ClientIP: c.ClientIP(),
+		Detail:   message,
+	})
+
+	handler.Respond(c, http.StatusTooManyRequests, message, nil)
+	return false
+}
+
+// recordLoginFailure 确认本次尝试失败，失败计数已在预占时写入，这里记录审计日志
+func recordLoginFailure(c *gin.Context, username, reason string) {
+	ip := c.ClientIP()
+
+	audit.Log(models.AuditLog{
+		Username: username,
+		Action:   models.AuditLoginFailed,
+		ClientIP: ip,
+		Detail:   reason,
+	})
+
+	attemptMu.Lock()
+	attempt := loadAttempt(userKey(username), time.Now())
+	attemptMu.Unlock()
+
+	if attempt.IsLocked() {
+		audit.Log(models.AuditLog{
+			Username: username,
+			Action:   models.AuditLoginLocked,
+			ClientIP: ip,
+			Detail:   fmt.Sprintf("连续失败 %d 次，锁定至 %s", attempt.Failures, attempt.LockedUntil.Format(time.RFC3339)),
+		})
+	}
+}
+
+// releaseLoginAttempt 撤销本次尝试预占的失败计数，用于未判定成败的尝试（如需要两步验证或服务器错误）
+func releaseLoginAttempt(c *gin.Context, username string) {
+	attemptMu.Lock()
+	defer attemptMu.Unlock()
+
+	now := time.Now()
+	security := config.AppConfig.Security
+	for _, key := range []string{ipKey(c.ClientIP()), userKey(username)} {
+		attempt := loadAttempt(key, now)
+		if attempt.ID == 0 || attempt.Failures == 0 {
+			continue
+		}
+
+		attempt.Failures--
+		if attempt.Failures == 0 {
+			database.DbConn.Delete(&attempt)
+			continue
+		}
+		if attempt.Failures < security.MaxLoginFailures {
+			attempt.LockedUntil = nil
+		}
+		if err := database.DbConn.Save(&attempt).Error; err != nil {
+			log.Printf("保存登录失败记录失败: %v", err)
+		}
+	}
+}
+
+// recordLoginSuccess 登录成功后撤销预占的IP计数并清除该用户名的失败记录，IP 的历史记录按窗口自然过期
+func recordLoginSuccess(c *gin.Context, username string) {
+	releaseLoginAttempt(c, username)
+
+	attemptMu.Lock()
+	database.DbConn.Where("throttle_key = ?", userKey(username)).Delete(&models.LoginAttempt{})
+	attemptMu.Unlock()
+
+	audit.Log(models.AuditLog{
+		Username: username,
+		Action:   models.AuditLoginSuccess,
+		ClientIP: c.ClientIP(),
+		Success:  true,
+	})
+}
+
+// GetLoginLockouts 获取登录失败与锁定记录
+// @Summary 获取登录锁定列表
+// @Description 获取仍在计数窗口内的登录失败记录，包括被锁定的用户名和处于退避中的IP
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=[]models.LoginAttempt} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "权限不足"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/security/lockouts [get]
+func GetLoginLockouts(c *gin.Context) {
+	window := time.Duration(config.AppConfig.Security.LockoutMinutes) * time.Minute
+	now := time.Now()
+
+	var attempts []models.LoginAttempt
+	if err := database.DbConn.
+		Where("last_failure_at > ? OR locked_until > ?", now.Add(-window), now).
+		Order("last_failure_at DESC").
+		Find(&attempts).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, attempts)
+}
+
+// ClearLoginLockout 清除登录锁定
+// @Summary 清除登录锁定
+// @Description 清除指定的登录失败记录，被锁定的用户名或IP可立即重新登录
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "记录ID"
+// @Success 200 {object} handler.Response "清除成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "权限不足"
+// @Failure 404 {object} handler.Response "记录不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/security/lockouts/{id} [delete]
+func ClearLoginLockout(c *gin.Context) {
+	attemptMu.Lock()
+	defer attemptMu.Unlock()
+
+	var attempt models.LoginAttempt
+	if err := database.DbConn.First(&attempt, c.Param("id")).Error; err != nil {
+		handler.Respond(c, http.StatusNotFound, "记录不存在", nil)
+		return
+	}
+
+	if err := database.DbConn.Delete(&attempt).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	audit.Record(c, models.AuditLockoutCleared, attempt.Key, true, fmt.Sprintf("清除前失败次数: %d", attempt.Failures))
+
+	handler.Respond(c, http.StatusOK, "锁定已清除", nil)
+}
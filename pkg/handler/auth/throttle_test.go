@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+)
+
+// backdateAttempts 将所有失败记录的时间前移，模拟等待
+func backdateAttempts(d time.Duration) {
+	var attempts []models.LoginAttempt
+	database.DbConn.Find(&attempts)
+	for _, attempt := range attempts {
+		attempt.LastFailureAt = attempt.LastFailureAt.Add(-d)
+		if attempt.LockedUntil != nil {
+			lockedUntil := attempt.LockedUntil.Add(-d)
+			attempt.LockedUntil = &lockedUntil
+		}
+		database.DbConn.Save(&attempt)
+	}
+}
+
+// maxFailures 与 setupAuthDB 中的 MaxLoginFailures 一致
+const maxFailures = 3
+
+func loadUserAttempt(t *testing.T, username string) models.LoginAttempt {
+	t.Helper()
+	var attempt models.LoginAttempt
+	if err := database.DbConn.Where("throttle_key = ?", userKey(username)).First(&attempt).Error; err != nil {
+		t.Fatalf("load attempt: %v", err)
+	}
+	return attempt
+}
+
+func TestLoginBackoff(t *testing.T) {
+	r := setupAuthDB(t)
+	createUser(t, "alice", "Abc123456")
+
+	if w, _ := postJSON(r, "/login", LoginRequest{Username: "alice", Password: "wrong"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("first failure: expected 401, got %d", w.Code)
+	}
+
+	w, _ := postJSON(r, "/login", LoginRequest{Username: "alice", Password: "Abc123456"})
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("immediate retry: expected 429 with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	backdateAttempts(2 * time.Second)
+	if w, _ := postJSON(r, "/login", LoginRequest{Username: "alice", Password: "Abc123456"}); w.Code != http.StatusOK {
+		t.Fatalf("after backoff: expected 200, got %d %s", w.Code, w.Body.String())
+	}
+
+	var count int64
+	database.DbConn.Model(&models.LoginAttempt{}).Where("throttle_key = ?", userKey("alice")).Count(&count)
+	if count != 0 {
+		t.Fatalf("expected user attempts cleared after success, got %d", count)
+	}
+}
+
+func TestLoginLockoutExpires(t *testing.T) {
+	r := setupAuthDB(t)
+	createUser(t, "alice", "Abc123456")
+
+	for i := 0; i < maxFailures; i++ {
+		if w, _ := postJSON(r, "/login", LoginRequest{Username: "alice", Password: "wrong"}); w.Code != http.StatusUnauthorized {
+			t.Fatalf("failure %d: expected 401, got %d", i+1, w.Code)
+		}
+		backdateAttempts(10 * time.Minute)
+	}
+
+	attempt := loadUserAttempt(t, "alice")
+	if !attempt.IsLocked() {
+		t.Fatalf("expected account locked after %d failures, got %+v", maxFailures, attempt)
+	}
+
+	// 锁定期间正确密码也被拒绝
+	database.DbConn.Model(&attempt).Update("last_failure_at", time.Now().Add(-time.Hour))
+	if w, _ := postJSON(r, "/login", LoginRequest{Username: "alice", Password: "Abc123456"}); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("while locked: expected 429, got %d", w.Code)
+	}
+
+	backdateAttempts(time.Hour)
+	if w, _ := postJSON(r, "/login", LoginRequest{Username: "alice", Password: "Abc123456"}); w.Code != http.StatusOK {
+		t.Fatalf("after lockout: expected 200, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestConcurrentLoginAttemptsAreReserved(t *testing.T) {
+	r := setupAuthDB(t)
+	createUser(t, "alice", "Abc123456")
+
+	const workers = 8
+	codes := make(chan int, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w, _ := postJSON(r, "/login", LoginRequest{Username: "alice", Password: "wrong"})
+			codes <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+	if counts[http.StatusUnauthorized] != 1 || counts[http.StatusTooManyRequests] != workers-1 {
+		t.Fatalf("expected one 401 and %d 429, got %v", workers-1, counts)
+	}
+
+	if attempt := loadUserAttempt(t, "alice"); attempt.Failures != 1 {
+		t.Fatalf("expected 1 recorded failure, got %d", attempt.Failures)
+	}
+}
+
+func TestTwoFactorChallengeDoesNotCountAsFailure(t *testing.T) {
+	r := setupAuthDB(t)
+	user := createUser(t, "alice", "Abc123456")
+	enableTwoFactor(t, &user)
+
+	if w, _ := postJSON(r, "/login", LoginRequest{Username: "alice", Password: "Abc123456"}); w.Code != http.StatusOK {
+		t.Fatalf("login: expected 200, got %d", w.Code)
+	}
+
+	var count int64
+	database.DbConn.Model(&models.LoginAttempt{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("expected the reservation released after the password check, got %d records", count)
+	}
+}
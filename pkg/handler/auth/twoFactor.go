@@ -0,0 +1,476 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/totp"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	// totpIssuer 认证器应用中显示的发行方名称
+	totpIssuer = "EtaPanel"
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// challengeTTL 两步登录挑战token有效期
+	challengeTTL = 5 * time.Minute
+	// challengeAudience 挑战token的受众，防止与会话token混用
+	challengeAudience = "etapanel-2fa-challenge"
+)
+
+// TwoFactorCodeRequest 两步验证码请求
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// TwoFactorDisableRequest 关闭两步验证请求
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required" example:"Abc123456"`
+	Code     string `json:"code" binding:"required" example:"123456"` // 验证码或恢复码
+}
+
+// TwoFactorSetupResponse 两步验证注册信息
+type TwoFactorSetupResponse struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	URI    string `json:"uri" example:"otpauth://totp/EtaPanel:admin?secret=JBSWY3DPEHPK3PXP&issuer=EtaPanel"`
+}
+
+// TwoFactorStatusResponse 两步验证状态
+type TwoFactorStatusResponse struct {
+	Enabled                bool  `json:"enabled"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// RecoveryCodesResponse 恢复码（仅在生成时返回一次明文）
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// LoginChallengeResponse 需要两步验证时的登录响应
+type LoginChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required" example:"true"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresAt         int64  `json:"expires_at" example:"1640995200"`
+}
+
+// LoginTwoFactorRequest 两步登录第二步请求
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required" example:"123456"` // 验证码或恢复码
+}
+
+// LoginTwoFactor 两步登录第二步
+// @Summary 两步验证登录
+// @Description 使用登录接口返回的挑战token和验证码（或恢复码）完成登录，返回JWT token
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body LoginTwoFactorRequest true "挑战token和验证码"
+// @Success 200 {object} handler.Response{data=LoginResponse} "登录成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "挑战token无效或验证码错误"
+// @Failure 429 {object} handler.Response "失败次数过多，请稍后重试"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /public/login/2fa [post]
+func LoginTwoFactor(c *gin.Context) {
+	var req LoginTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	username, err := parseChallenge(req.ChallengeToken)
+	if err != nil {
+		handler.Respond(c, http.StatusUnauthorized, "登录已过期，请重新输入用户名和密码", nil)
+		return
+	}
+
+	if !allowLoginAttempt(c, username) {
+		return
+	}
+
+	var user models.User
+	if err := database.DbConn.Where("username = ?", username).First(&user).Error; err != nil || !user.TOTPEnabled {
+		recordLoginFailure(c, username, "两步验证已关闭或用户不存在")
+		handler.Respond(c, http.StatusUnauthorized, "登录已过期，请重新输入用户名和密码", nil)
+		return
+	}
+
+	if ok, err := verifySecondFactor(&user, req.Code); err != nil {
+		releaseLoginAttempt(c, username)
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	} else if !ok {
+		recordLoginFailure(c, username, "两步验证码错误")
+		handler.Respond(c, http.StatusUnauthorized, "验证码错误", nil)
+		return
+	}
+
+	response, err := issueSession(c, user)
+	if err != nil {
+		releaseLoginAttempt(c, username)
+		handler.Respond(c, http.StatusInternalServerError, "生成密钥失败", nil)
+		return
+	}
+
+	recordLoginSuccess(c, user.Username)
+	handler.Respond(c, http.StatusOK, "登录成功", response)
+}
+
+// GetTwoFactorStatus 获取两步验证状态
+// @Summary 获取两步验证状态
+// @Description 获取当前用户是否启用两步验证及剩余恢复码数量
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=TwoFactorStatusResponse} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Router /auth/2fa [get]
+func GetTwoFactorStatus(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	status := TwoFactorStatusResponse{Enabled: user.TOTPEnabled}
+	if user.TOTPEnabled {
+		database.DbConn.Model(&models.RecoveryCode{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Count(&status.RecoveryCodesRemaining)
+	}
+
+	handler.Respond(c, http.StatusOK, nil, status)
+}
+
+// SetupTwoFactor 生成两步验证密钥
+// @Summary 生成两步验证密钥
+// @Description 生成新的TOTP密钥和otpauth链接（可生成二维码），需调用启用接口验证后才会生效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=TwoFactorSetupResponse} "生成成功"
+// @Failure 400 {object} handler.Response "已启用两步验证"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/2fa/setup [post]
+func SetupTwoFactor(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.TOTPEnabled {
+		handler.Respond(c, http.StatusBadRequest, "已启用两步验证，请先关闭后再重新绑定", nil)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "生成密钥失败", nil)
+		return
+	}
+
+	if err := database.DbConn.Model(&user).Update("totp_secret", secret).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, TwoFactorSetupResponse{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Username, secret),
+	})
+}
+
+// EnableTwoFactor 启用两步验证
+// @Summary 启用两步验证
+// @Description 使用认证器生成的验证码确认绑定并启用两步验证，返回一次性恢复码（仅显示一次）
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorCodeRequest true "验证码"
+// @Success 200 {object} handler.Response{data=RecoveryCodesResponse} "启用成功"
+// @Failure 400 {object} handler.Response "请求参数错误或验证码错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/2fa/enable [post]
+func EnableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.TOTPEnabled {
+		handler.Respond(c, http.StatusBadRequest, "已启用两步验证", nil)
+		return
+	}
+	if user.TOTPSecret == "" {
+		handler.Respond(c, http.StatusBadRequest, "请先生成两步验证密钥", nil)
+		return
+	}
+
+	step, valid := totp.Validate(user.TOTPSecret, req.Code, time.Now())
+	if !valid {
+		handler.Respond(c, http.StatusBadRequest, "验证码错误", nil)
+		return
+	}
+
+	var codes []string
+	err := database.DbConn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "两步验证已启用，请妥善保存恢复码", RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 验证密码和验证码（或恢复码）后关闭两步验证，并删除所有恢复码
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorDisableRequest true "密码和验证码"
+// @Success 200 {object} handler.Response "关闭成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权或验证失败"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	var req TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if !user.TOTPEnabled {
+		handler.Respond(c, http.StatusBadRequest, "未启用两步验证", nil)
+		return
+	}
+
+	if err := user.CheckPassword(req.Password); err != nil {
+		handler.Respond(c, http.StatusUnauthorized, "密码错误", nil)
+		return
+	}
+
+	if ok, err := verifySecondFactor(&user, req.Code); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	} else if !ok {
+		handler.Respond(c, http.StatusUnauthorized, "验证码错误", nil)
+		return
+	}
+
+	err := database.DbConn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "两步验证已关闭", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 验证验证码后重新生成恢复码，旧恢复码全部失效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorCodeRequest true "验证码"
+// @Success 200 {object} handler.Response{data=RecoveryCodesResponse} "生成成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权或验证码错误"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if !user.TOTPEnabled {
+		handler.Respond(c, http.StatusBadRequest, "未启用两步验证", nil)
+		return
+	}
+
+	// 仅接受认证器验证码，避免用恢复码无限续期
+	if ok, err := verifyTOTP(&user, req.Code); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	} else if !ok {
+		handler.Respond(c, http.StatusUnauthorized, "验证码错误", nil)
+		return
+	}
+
+	var codes []string
+	err := database.DbConn.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "恢复码已重新生成", RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// currentUser 加载当前登录用户，失败时直接写入响应
+func currentUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+	if err := database.DbConn.Where("username = ?", c.GetString("username")).First(&user).Error; err != nil {
+		handler.Respond(c, http.StatusUnauthorized, "用户不存在", nil)
+		return user, false
+	}
+	return user, true
+}
+
+// issueChallenge 签发两步登录挑战token
+func issueChallenge(username string) (LoginChallengeResponse, error) {
+	expiresAt := time.Now().Add(challengeTTL)
+	claims := jwt.RegisteredClaims{
+		Subject:   username,
+		Audience:  jwt.ClaimStrings{challengeAudience},
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.AppConfig.JWT.Secret))
+	if err != nil {
+		return LoginChallengeResponse{}, err
+	}
+
+	return LoginChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresAt:         expiresAt.Unix(),
+	}, nil
+}
+
+// parseChallenge 校验挑战token并返回用户名
+func parseChallenge(tokenString string) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.AppConfig.JWT.Secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(challengeAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return "", err
+	}
+	if claims.Subject == "" {
+		return "", errors.New("challenge token has no subject")
+	}
+	return claims.Subject, nil
+}
+
+// verifySecondFactor 校验认证器验证码，不匹配时尝试作为恢复码使用
+func verifySecondFactor(user *models.User, code string) (bool, error) {
+	if ok, err := verifyTOTP(user, code); ok || err != nil {
+		return ok, err
+	}
+	return consumeRecoveryCode(user, code)
+}
+
+// verifyTOTP 校验认证器验证码，同一时间步的验证码只能使用一次
+func verifyTOTP(user *models.User, code string) (bool, error) {
+	step, valid := totp.Validate(user.TOTPSecret, code, time.Now())
+	if !valid || step <= user.TOTPLastStep {
+		return false, nil
+	}
+
+	// 条件更新保证并发请求中只有一个能使用该验证码
+	result := database.DbConn.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	user.TOTPLastStep = step
+	return result.RowsAffected == 1, nil
+}
+
+// consumeRecoveryCode 使用一次性恢复码
+func consumeRecoveryCode(user *models.User, code string) (bool, error) {
+	result := database.DbConn.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, totp.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// replaceRecoveryCodes 删除旧恢复码并生成新的恢复码，返回明文
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	codes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	records := make([]models.RecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		records = append(records, models.RecoveryCode{UserID: userID, CodeHash: totp.HashRecoveryCode(code)})
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/totp"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testSecret = "auth-test-secret"
+
+// setupAuthDB 初始化测试配置和数据库，返回挂载登录接口的路由
+func setupAuthDB(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	config.AppConfig = &config.Config{
+		JWT: config.JWTConfig{Secret: testSecret},
+		Security: config.SecurityConfig{
+			MaxLoginFailures:   3,
+			LockoutMinutes:     15,
+			BackoffBaseSeconds: 1,
+			BackoffMaxSeconds:  300,
+		},
+	}
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.RecoveryCode{}, &models.AuthToken{}, &models.AuditLog{}, &models.LoginAttempt{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	database.DbConn = db
+
+	r := gin.New()
+	r.POST("/login", Login)
+	r.POST("/login/2fa", LoginTwoFactor)
+	return r
+}
+
+func createUser(t *testing.T, username, password string) models.User {
+	t.Helper()
+	user := models.User{Username: username, Password: password, Role: models.RoleAdmin}
+	if err := user.HashPassword(); err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	if err := database.DbConn.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// enableTwoFactor 为用户启用两步验证并返回密钥和恢复码
+func enableTwoFactor(t *testing.T, user *models.User) (string, []string) {
+	t.Helper()
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("generate secret: %v", err)
+	}
+	database.DbConn.Model(user).Updates(map[string]interface{}{"totp_secret": secret, "totp_enabled": true})
+
+	codes, err := replaceRecoveryCodes(database.DbConn, user.ID)
+	if err != nil {
+		t.Fatalf("recovery codes: %v", err)
+	}
+	return secret, codes
+}
+
+// resetAttempts 清除失败计数，避免退避影响后续请求
+func resetAttempts() {
+	database.DbConn.Where("1 = 1").Delete(&models.LoginAttempt{})
+}
+
+func postJSON(r *gin.Engine, path string, body interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp.Data
+}
+
+func TestLoginWithTwoFactorReturnsChallenge(t *testing.T) {
+	r := setupAuthDB(t)
+	user := createUser(t, "alice", "Abc123456")
+	secret, _ := enableTwoFactor(t, &user)
+
+	w, data := postJSON(r, "/login", LoginRequest{Username: "alice", Password: "Abc123456"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	if data["two_factor_required"] != true || data["challenge_token"] == "" {
+		t.Fatalf("expected a 2FA challenge, got %v", data)
+	}
+	if _, ok := data["token"]; ok {
+		t.Fatal("login must not return a session token before the second factor")
+	}
+	var sessions int64
+	database.DbConn.Model(&models.AuthToken{}).Count(&sessions)
+	if sessions != 0 {
+		t.Fatalf("expected no session before the second factor, got %d", sessions)
+	}
+
+	// 挑战token不能当作会话token使用，也不能用错误验证码换取会话
+	challenge := data["challenge_token"].(string)
+	if w, _ := postJSON(r, "/login/2fa", LoginTwoFactorRequest{ChallengeToken: challenge, Code: "000000"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong code: expected 401, got %d", w.Code)
+	}
+	resetAttempts()
+
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	w, data = postJSON(r, "/login/2fa", LoginTwoFactorRequest{ChallengeToken: challenge, Code: code})
+	if w.Code != http.StatusOK || data["token"] == nil {
+		t.Fatalf("second factor: expected session token, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRecoveryCodeWorksOnce(t *testing.T) {
+	r := setupAuthDB(t)
+	user := createUser(t, "alice", "Abc123456")
+	_, codes := enableTwoFactor(t, &user)
+
+	for i, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+		challenge, err := issueChallenge("alice")
+		if err != nil {
+			t.Fatalf("issue challenge: %v", err)
+		}
+		w, _ := postJSON(r, "/login/2fa", LoginTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: codes[0]})
+		if w.Code != want {
+			t.Fatalf("attempt %d: expected %d, got %d %s", i+1, want, w.Code, w.Body.String())
+		}
+		resetAttempts()
+	}
+
+	var remaining int64
+	database.DbConn.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.ID).Count(&remaining)
+	if remaining != int64(len(codes)-1) {
+		t.Errorf("expected %d unused recovery codes, got %d", len(codes)-1, remaining)
+	}
+}
+
+func TestChallengeTokenExpires(t *testing.T) {
+	r := setupAuthDB(t)
+	user := createUser(t, "alice", "Abc123456")
+	secret, _ := enableTwoFactor(t, &user)
+
+	sign := func(claims jwt.RegisteredClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return token
+	}
+
+	expired := sign(jwt.RegisteredClaims{
+		Subject:   "alice",
+		Audience:  jwt.ClaimStrings{challengeAudience},
+		IssuedAt:  jwt.NewNumericDate(time.Now().Add(-challengeTTL - time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+	})
+	wrongAudience := sign(jwt.RegisteredClaims{
+		Subject:   "alice",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	})
+	noExpiry := sign(jwt.RegisteredClaims{
+		Subject:  "alice",
+		Audience: jwt.ClaimStrings{challengeAudience},
+	})
+
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	for name, token := range map[string]string{"expired": expired, "wrong audience": wrongAudience, "no expiry": noExpiry} {
+		if _, err := parseChallenge(token); err == nil {
+			t.Errorf("%s: expected parseChallenge to fail", name)
+		}
+		if w, _ := postJSON(r, "/login/2fa", LoginTwoFactorRequest{ChallengeToken: token, Code: code}); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", name, w.Code)
+		}
+	}
+}
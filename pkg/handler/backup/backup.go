@@ -0,0 +1,347 @@
+package backup
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/backup"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetBackupPlans 获取备份计划列表
+// @Summary 获取备份计划列表
+// @Description 获取所有数据库定时备份计划及下次执行时间
+// @Tags 数据库备份
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=[]models.BackupPlan} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/backups/plans [get]
+func GetBackupPlans(c *gin.Context) {
+	var plans []models.BackupPlan
+	if err := database.DbConn.Find(&plans).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	for i := range plans {
+		plans[i].NextRunAt = backup.NextRun(plans[i].ID)
+	}
+
+	handler.Respond(c, http.StatusOK, nil, plans)
+}
+
+// CreateBackupPlan 创建备份计划
+// @Summary 创建备份计划
+// @Description 创建数据库定时备份计划，cron_spec 为标准5段 cron 表达式
+// @Tags 数据库备份
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.BackupPlanRequest true "备份计划"
+// @Success 201 {object} handler.Response{data=models.BackupPlan} "创建成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/backups/plans [post]
+func CreateBackupPlan(c *gin.Context) {
+	var req models.BackupPlanRequest
+	if !bindPlanRequest(c, &req) {
+		return
+	}
+
+	var plan models.BackupPlan
+	applyPlanRequest(&plan, req)
+
+	if err := database.DbConn.Create(&plan).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	if err := backup.Schedule(plan); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "备份计划调度失败: "+err.Error(), nil)
+		return
+	}
+	plan.NextRunAt = backup.NextRun(plan.ID)
+
+	handler.Respond(c, http.StatusCreated, nil, plan)
+}
+
+// UpdateBackupPlan 更新备份计划
+// @Summary 更新备份计划
+// @Description 更新指定ID的备份计划并重新调度
+// @Tags 数据库备份
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "计划ID"
+// @Param request body models.BackupPlanRequest true "备份计划"
+// @Success 200 {object} handler.Response{data=models.BackupPlan} "更新成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "计划不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/backups/plans/{id} [put]
+func UpdateBackupPlan(c *gin.Context) {
+	var req models.BackupPlanRequest
+	if !bindPlanRequest(c, &req) {
+		return
+	}
+
+	var plan models.BackupPlan
+	if err := database.DbConn.First(&plan, c.Param("id")).Error; err != nil {
+		handler.Respond(c, http.StatusNotFound, "备份计划不存在", nil)
+		return
+	}
+
+	applyPlanRequest(&plan, req)
+
+	if err := database.DbConn.Save(&plan).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	if err := backup.Schedule(plan); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "备份计划调度失败: "+err.Error(), nil)
+		return
+	}
+	plan.NextRunAt = backup.NextRun(plan.ID)
+
+	handler.Respond(c, http.StatusOK, nil, plan)
+}
+
+// DeleteBackupPlan 删除备份计划
+// @Summary 删除备份计划
+// @Description 删除指定ID的备份计划，已生成的备份文件和记录会保留
+// @Tags 数据库备份
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "计划ID"
+// @Success 200 {object} handler.Response "删除成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "计划不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/backups/plans/{id} [delete]
+func DeleteBackupPlan(c *gin.Context) {
+	var plan models.BackupPlan
+	if err := database.DbConn.First(&plan, c.Param("id")).Error; err != nil {
+		handler.Respond(c, http.StatusNotFound, "备份计划不存在", nil)
+		return
+	}
+
+	backup.Unschedule(plan.ID)
+
+	if err := database.DbConn.Delete(&plan).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "备份计划已删除", nil)
+}
+
+// RunBackupPlan 立即执行备份计划
+// @Summary 立即执行备份计划
+// @Description 立即执行一次指定的备份计划并应用保留策略
+// @Tags 数据库备份
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "计划ID"
+// @Success 200 {object} handler.Response{data=models.BackupRecord} "备份成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response{data=models.BackupRecord} "备份失败"
+// @Router /auth/backups/plans/{id}/run [post]
+func RunBackupPlan(c *gin.Context) {
+	var plan models.BackupPlan
+	if err := database.DbConn.First(&plan, c.Param("id")).Error; err != nil {
+		handler.Respond(c, http.StatusNotFound, "备份计划不存在", nil)
+		return
+	}
+
+	record, err := backup.RunPlan(plan.ID)
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "备份失败: "+err.Error(), record)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "备份成功", record)
+}
+
+// GetBackupRecords 获取备份记录
+// @Summary 获取备份记录
+// @Description 获取备份执行记录，可按计划或连接筛选
+// @Tags 数据库备份
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param plan_id query int false "计划ID"
+// @Param connection_id query int false "连接ID"
+// @Success 200 {object} handler.Response{data=[]models.BackupRecord} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/backups/records [get]
+func GetBackupRecords(c *gin.Context) {
+	query := database.DbConn.Order("started_at DESC")
+	if planID := c.Query("plan_id"); planID != "" {
+		query = query.Where("plan_id = ?", planID)
+	}
+	if connectionID := c.Query("connection_id"); connectionID != "" {
+		query = query.Where("connection_id = ?", connectionID)
+	}
+
+	var records []models.BackupRecord
+	if err := query.Find(&records).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, records)
+}
+
+// DownloadBackup 下载备份文件
+// @Summary 下载备份文件
+// @Description 下载指定备份记录对应的备份文件
+// @Tags 数据库备份
+// @Produce application/octet-stream
+// @Security BearerAuth
+// @Param id path int true "记录ID"
+// @Success 200 {file} binary "备份文件"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "记录或文件不存在"
+// @Router /auth/backups/records/{id}/download [get]
+func DownloadBackup(c *gin.Context) {
+	var record models.BackupRecord
+	if err := database.DbConn.First(&record, c.Param("id")).Error; err != nil {
+		handler.Respond(c, http.StatusNotFound, "备份记录不存在", nil)
+		return
+	}
+
+	if record.Status != models.BackupStatusSuccess {
+		handler.Respond(c, http.StatusNotFound, "备份未成功完成，没有可下载的文件", nil)
+		return
+	}
+	if _, err := os.Stat(record.FilePath); err != nil {
+		handler.Respond(c, http.StatusNotFound, "备份文件不存在", nil)
+		return
+	}
+
+	c.FileAttachment(record.FilePath, filepath.Base(record.FilePath))
+}
+
+// RestoreBackup 恢复备份
+// @Summary 恢复备份
+// @Description 将指定备份记录恢复到来源连接，可指定目标数据库
+// @Tags 数据库备份
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "记录ID"
+// @Param request body models.BackupRestoreRequest false "恢复参数"
+// @Success 200 {object} handler.Response "恢复成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "记录不存在"
+// @Failure 500 {object} handler.Response "恢复失败"
+// @Router /auth/backups/records/{id}/restore [post]
+func RestoreBackup(c *gin.Context) {
+	var req models.BackupRestoreRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+			return
+		}
+	}
+
+	var record models.BackupRecord
+	if err := database.DbConn.First(&record, c.Param("id")).Error; err != nil {
+		handler.Respond(c, http.StatusNotFound, "备份记录不存在", nil)
+		return
+	}
+
+	if err := backup.Restore(record, req.Database); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "恢复失败: "+err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "恢复成功", nil)
+}
+
+// DeleteBackupRecord 删除备份记录
+// @Summary 删除备份记录
+// @Description 删除指定备份记录及其备份文件
+// @Tags 数据库备份
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "记录ID"
+// @Success 200 {object} handler.Response "删除成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "记录不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/backups/records/{id} [delete]
+func DeleteBackupRecord(c *gin.Context) {
+	var record models.BackupRecord
+	if err := database.DbConn.First(&record, c.Param("id")).Error; err != nil {
+		handler.Respond(c, http.StatusNotFound, "备份记录不存在", nil)
+		return
+	}
+
+	if err := os.Remove(record.FilePath); err != nil && !os.IsNotExist(err) {
+		handler.Respond(c, http.StatusInternalServerError, "删除备份文件失败: "+err.Error(), nil)
+		return
+	}
+
+	if err := database.DbConn.Delete(&record).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "备份记录已删除", nil)
+}
+
+// bindPlanRequest 绑定并校验备份计划请求，失败时直接写入响应
+func bindPlanRequest(c *gin.Context, req *models.BackupPlanRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return false
+	}
+
+	if err := backup.ValidateSpec(req.CronSpec); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "cron 表达式无效: "+err.Error(), nil)
+		return false
+	}
+
+	destDir, err := backup.ResolvePath(req.DestDir)
+	if err != nil {
+		handler.Respond(c, http.StatusBadRequest, "备份目录必须位于备份根目录内: "+err.Error(), nil)
+		return false
+	}
+	req.DestDir = destDir
+
+	var conn models.DatabaseConnection
+	if err := database.DbConn.First(&conn, req.ConnectionID).Error; err != nil {
+		handler.Respond(c, http.StatusBadRequest, "数据库连接不存在", nil)
+		return false
+	}
+
+	return true
+}
+
+// applyPlanRequest 将请求内容写入备份计划
+func applyPlanRequest(plan *models.BackupPlan, req models.BackupPlanRequest) {
+	plan.Name = req.Name
+	plan.ConnectionID = req.ConnectionID
+	plan.Database = req.Database
+	plan.CronSpec = req.CronSpec
+	plan.DestDir = req.DestDir
+	plan.KeepCount = req.KeepCount
+	plan.KeepDays = req.KeepDays
+	plan.Enabled = req.Enabled
+}
@@ -0,0 +1,314 @@
+package dbmgr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	dbmgr2 "github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/dbmgr"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/safepath"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrPanelDatabase 连接指向面板自身的数据库
+	ErrPanelDatabase = errors.New("不允许访问面板自身的数据库")
+	// ErrSQLitePathNotAllowed SQLite 数据库文件不在允许的目录中
+	ErrSQLitePathNotAllowed = errors.New("SQLite 数据库文件必须位于 query.sqlite_dirs 配置的目录中")
+)
+
+// GetConnections 获取所有已保存的数据库连接
+// @Summary 获取数据库连接列表
+// @Description 获取所有已保存的数据库连接配置
+// @Tags 数据库管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=[]models.DatabaseConnection} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/databases [get]
+func GetConnections(c *gin.Context) {
+	var connections []models.DatabaseConnection
+	if err := database.DbConn.Find(&connections).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, connections)
+}
+
+// CreateConnection 创建数据库连接
+// @Summary 创建数据库连接
+// @Description 保存新的数据库连接配置
+// @Tags 数据库管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.DatabaseConnectionRequest true "数据库连接信息"
+// @Success 201 {object} handler.Response{data=models.DatabaseConnection} "创建成功"
+// @Failure 400 {object} handler.Response "请求参数错误或 SQLite 文件不在允许的目录中"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "指向面板自身的数据库"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/databases [post]
+func CreateConnection(c *gin.Context) {
+	var req models.DatabaseConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+	if !dbmgr2.Registered(req.Engine) {
+		handler.Respond(c, http.StatusBadRequest, fmt.Sprintf("不支持的数据库引擎: %s，可选: %s", req.Engine, strings.Join(dbmgr2.Engines(), ", ")), nil)
+		return
+	}
+
+	var conn models.DatabaseConnection
+	if err := applyConnectionRequest(&conn, req); err != nil {
+		respondConnectionError(c, err)
+		return
+	}
+
+	if err := database.DbConn.Create(&conn).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusCreated, nil, conn)
+}
+
+// UpdateConnection 更新数据库连接
+// @Summary 更新数据库连接
+// @Description 更新指定ID的数据库连接配置，密码留空则保持不变
+// @Tags 数据库管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "连接ID"
+// @Param request body models.DatabaseConnectionRequest true "数据库连接信息"
+// @Success 200 {object} handler.Response{data=models.DatabaseConnection} "更新成功"
+// @Failure 400 {object} handler.Response "请求参数错误或 SQLite 文件不在允许的目录中"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "指向面板自身的数据库"
+// @Failure 404 {object} handler.Response "连接不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/databases/{id} [put]
+func UpdateConnection(c *gin.Context) {
+	var req models.DatabaseConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+	if !dbmgr2.Registered(req.Engine) {
+		handler.Respond(c, http.StatusBadRequest, fmt.Sprintf("不支持的数据库引擎: %s，可选: %s", req.Engine, strings.Join(dbmgr2.Engines(), ", ")), nil)
+		return
+	}
+
+	conn, ok := loadConnection(c)
+	if !ok {
+		return
+	}
+
+	if err := applyConnectionRequest(&conn, req); err != nil {
+		respondConnectionError(c, err)
+		return
+	}
+
+	if err := database.DbConn.Save(&conn).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, conn)
+}
+
+// DeleteConnection 删除数据库连接
+// @Summary 删除数据库连接
+// @Description 删除指定ID的数据库连接配置，不会影响数据库本身
+// @Tags 数据库管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "连接ID"
+// @Success 200 {object} handler.Response "删除成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "连接不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/databases/{id} [delete]
+func DeleteConnection(c *gin.Context) {
+	conn, ok := loadConnection(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DbConn.Delete(&conn).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "数据库连接已删除", nil)
+}
+
+// TestConnection 测试数据库连接
+// @Summary 测试数据库连接
+// @Description 使用已保存的配置尝试连接数据库
+// @Tags 数据库管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "连接ID"
+// @Success 200 {object} handler.Response{data=object{engine=string,capabilities=[]string}} "连接成功，返回引擎支持的扩展能力"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "指向面板自身的数据库"
+// @Failure 404 {object} handler.Response "连接不存在"
+// @Failure 502 {object} handler.Response "连接失败"
+// @Router /auth/databases/{id}/test [post]
+func TestConnection(c *gin.Context) {
+	conn, ok := loadConnection(c)
+	if !ok {
+		return
+	}
+
+	mgr, err := OpenManager(conn, "")
+	if err != nil {
+		respondConnectionError(c, err)
+		return
+	}
+	defer mgr.Disconnect()
+
+	handler.Respond(c, http.StatusOK, "连接成功", gin.H{
+		"engine":       mgr.Engine(),
+		"capabilities": dbmgr2.Capabilities(mgr),
+	})
+}
+
+// applyConnectionRequest 校验请求并将内容写入连接模型
+func applyConnectionRequest(conn *models.DatabaseConnection, req models.DatabaseConnectionRequest) error {
+	if req.Engine == dbmgr2.EngineSQLite {
+		if _, err := CheckSQLitePath(req.Path); err != nil {
+			return err
+		}
+	}
+
+	conn.Name = req.Name
+	conn.Engine = req.Engine
+	conn.Host = req.Host
+	conn.Port = req.Port
+	conn.Username = req.Username
+	conn.Database = req.Database
+	conn.SSLMode = req.SSLMode
+	conn.Path = req.Path
+	conn.ReadOnly = req.ReadOnly
+	if req.Password != "" {
+		conn.Password = req.Password
+	}
+	return nil
+}
+
+// respondConnectionError 按错误类型写入响应：面板数据库 403，路径不允许 400，其余为连接失败 502
+func respondConnectionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrPanelDatabase):
+		handler.Respond(c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, ErrSQLitePathNotAllowed):
+		handler.Respond(c, http.StatusBadRequest, err.Error(), nil)
+	default:
+		handler.Respond(c, http.StatusBadGateway, "连接数据库失败: "+err.Error(), nil)
+	}
+}
+
+// IsPanelDatabase 路径是否为面板自身的数据库或其 -wal、-shm、-journal 文件，包括指向它们的符号链接和硬链接
+func IsPanelDatabase(path string) bool {
+	if config.AppConfig == nil || config.AppConfig.Database.Path == "" {
+		return false
+	}
+	panelDB, err := safepath.Canonical(config.AppConfig.Database.Path)
+	if err != nil {
+		return false
+	}
+	resolved, err := safepath.Canonical(path)
+	if err != nil {
+		return false
+	}
+
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		if resolved == panelDB+suffix {
+			return true
+		}
+		panelInfo, err := os.Stat(panelDB + suffix)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(resolved); err == nil && os.SameFile(info, panelInfo) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSQLitePath 校验 SQLite 连接的数据库文件并返回规范化后的路径：面板自身的数据库始终拒绝，
+// 文件必须位于 query.sqlite_dirs 配置的目录中，解析符号链接后比较
+func CheckSQLitePath(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("%w: 路径不能为空", ErrSQLitePathNotAllowed)
+	}
+	resolved, err := safepath.Canonical(path)
+	if err != nil {
+		return "", err
+	}
+	if IsPanelDatabase(resolved) {
+		return "", ErrPanelDatabase
+	}
+
+	for _, dir := range config.AppConfig.Query.SQLiteDirs {
+		if root, err := safepath.Canonical(dir); err == nil && safepath.Within(root, resolved) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrSQLitePathNotAllowed, path)
+}
+
+// loadConnection 根据路径参数加载连接配置，失败时直接写入响应
+func loadConnection(c *gin.Context) (models.DatabaseConnection, bool) {
+	var conn models.DatabaseConnection
+	if err := database.DbConn.First(&conn, c.Param("id")).Error; err != nil {
+		handler.Respond(c, http.StatusNotFound, "数据库连接不存在", nil)
+		return conn, false
+	}
+	return conn, true
+}
+
+// ManagerConfig 将已保存的连接转换为 dbmgr 连接配置，db 非空时覆盖默认数据库
+func ManagerConfig(conn models.DatabaseConnection, db string) dbmgr2.ConnectionConfig {
+	if db == "" {
+		db = conn.Database
+	}
+	return dbmgr2.ConnectionConfig{
+		Host:     conn.Host,
+		Port:     conn.Port,
+		Username: conn.Username,
+		Password: conn.Password,
+		Database: db,
+		SSLMode:  conn.SSLMode,
+		Path:     conn.Path,
+	}
+}
+
+// OpenManager 根据连接配置创建并连接对应引擎的管理器，db 非空时覆盖默认数据库。
+// SQLite 连接在打开时重新校验路径，保存后被替换为符号链接或修改配置的连接同样会被拒绝
+func OpenManager(conn models.DatabaseConnection, db string) (dbmgr2.DatabaseManager, error) {
+	if conn.Engine == dbmgr2.EngineSQLite {
+		path, err := CheckSQLitePath(conn.Path)
+		if err != nil {
+			return nil, err
+		}
+		conn.Path = path
+	}
+	return dbmgr2.Open(conn.Engine, ManagerConfig(conn, db))
+}
@@ -0,0 +1,311 @@
+package dbmgr
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	dbmgr2 "github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/dbmgr"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/safepath"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/sqlguard"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ListDatabases 列出数据库
+// @Summary 列出数据库
+// @Description 列出连接上的所有数据库，Redis 返回各个 db 的键数量
+// @Tags 数据库管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "连接ID"
+// @Success 200 {object} handler.Response{data=[]object} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "连接不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Failure 502 {object} handler.Response "连接失败"
+// @Router /auth/databases/{id}/databases [get]
+func ListDatabases(c *gin.Context) {
+	mgr, ok := connect(c, "")
+	if !ok {
+		return
+	}
+	defer mgr.Disconnect()
+
+	data, err := mgr.ListDatabases()
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, data)
+}
+
+// ListTables 列出数据表
+// @Summary 列出数据表
+// @Description 列出指定数据库中的表，Redis 按 pattern 扫描键
+// @Tags 数据库管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "连接ID"
+// @Param database query string false "数据库名，默认使用连接配置中的数据库"
+// @Param pattern query string false "Redis 键匹配模式" default(*)
+// @Param cursor query int false "Redis 扫描游标" default(0)
+// @Success 200 {object} handler.Response{data=[]object} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "连接不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Failure 502 {object} handler.Response "连接失败"
+// @Router /auth/databases/{id}/tables [get]
+func ListTables(c *gin.Context) {
+	db := c.Query("database")
+	mgr, ok := connect(c, db)
+	if !ok {
+		return
+	}
+	defer mgr.Disconnect()
+
+	var data interface{}
+	var err error
+	if scanner, ok := mgr.(dbmgr2.KeyScanner); ok {
+		cursor, parseErr := strconv.ParseUint(c.DefaultQuery("cursor", "0"), 10, 64)
+		if parseErr != nil {
+			handler.Respond(c, http.StatusBadRequest, "无效的游标: "+c.Query("cursor"), nil)
+			return
+		}
+		keys, next, scanErr := scanner.GetKeys(c.DefaultQuery("pattern", "*"), cursor, 100)
+		data, err = gin.H{"keys": keys, "cursor": next}, scanErr
+	} else {
+		data, err = mgr.ListTables(db)
+	}
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, data)
+}
+
+// ListUsers 列出数据库用户
+// @Summary 列出数据库用户
+// @Description 列出数据库用户，仅支持具备 users 能力的引擎
+// @Tags 数据库管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "连接ID"
+// @Success 200 {object} handler.Response{data=[]object} "获取成功"
+// @Failure 400 {object} handler.Response "引擎不支持"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "连接不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Failure 502 {object} handler.Response "连接失败"
+// @Router /auth/databases/{id}/users [get]
+func ListUsers(c *gin.Context) {
+	mgr, ok := connect(c, "")
+	if !ok {
+		return
+	}
+	defer mgr.Disconnect()
+
+	lister, ok := mgr.(dbmgr2.UserLister)
+	if !ok {
+		handler.Respond(c, http.StatusBadRequest, "该数据库引擎不支持用户管理", nil)
+		return
+	}
+
+	data, err := lister.ListUsers()
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, data)
+}
+
+// ExecuteQuery 执行查询
+// @Summary 执行查询
+// @Description 在指定数据库上执行单条 SQL 语句，Redis 连接执行以空格分隔的命令。只读语句在只读事务中执行，
+// @Description 受配置的最大行数和超时限制；只读模式的连接拒绝写操作，指向面板自身数据库的连接始终拒绝
+// @Tags 数据库管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "连接ID"
+// @Param request body models.DatabaseQueryRequest true "查询内容"
+// @Success 200 {object} handler.Response{data=object} "执行成功，查询返回 rows/count/truncated，写操作返回 affected_rows"
+// @Failure 400 {object} handler.Response "请求参数错误或包含多条语句"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "只读连接不允许写操作或连接指向面板自身的数据库"
+// @Failure 404 {object} handler.Response "连接不存在"
+// @Failure 500 {object} handler.Response "执行失败"
+// @Failure 502 {object} handler.Response "连接失败"
+// @Router /auth/databases/{id}/query [post]
+func ExecuteQuery(c *gin.Context) {
+	var req models.DatabaseQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	conn, ok := loadConnection(c)
+	if !ok {
+		return
+	}
+	// 面板自身的数据库与连接的只读标记无关，始终拒绝，保存前已存在的连接同样如此
+	if conn.Engine == dbmgr2.EngineSQLite && IsPanelDatabase(conn.Path) {
+		handler.Respond(c, http.StatusForbidden, ErrPanelDatabase.Error(), nil)
+		return
+	}
+
+	readOnly, err := IsReadOnlyQuery(conn.Engine, req.Query)
+	if err != nil {
+		handler.Respond(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if !readOnly && conn.ReadOnly {
+		handler.Respond(c, http.StatusForbidden, "该连接为只读模式，不允许执行写操作", nil)
+		return
+	}
+
+	mgr, err := OpenManager(conn, req.Database)
+	if err != nil {
+		respondConnectionError(c, err)
+		return
+	}
+	defer mgr.Disconnect()
+
+	if !readOnly {
+		affected, err := mgr.Execute(req.Query)
+		if err != nil {
+			handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		handler.Respond(c, http.StatusOK, nil, gin.H{"affected_rows": affected})
+		return
+	}
+
+	rows, truncated, err := QueryReadOnly(mgr, req.Query)
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, gin.H{"rows": rows, "count": len(rows), "truncated": truncated})
+}
+
+// BackupDatabase 备份数据库
+// @Summary 备份数据库
+// @Description 将数据库备份到服务器上的指定路径，路径必须位于配置的备份根目录内，相对路径视为相对该目录
+// @Tags 数据库管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "连接ID"
+// @Param request body models.DatabaseBackupRequest true "备份参数"
+// @Success 200 {object} handler.Response "备份成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "连接不存在"
+// @Failure 500 {object} handler.Response "备份失败"
+// @Failure 502 {object} handler.Response "连接失败"
+// @Router /auth/databases/{id}/backup [post]
+func BackupDatabase(c *gin.Context) {
+	var req models.DatabaseBackupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	outputPath, err := safepath.Resolve(config.AppConfig.Backup.Root, req.OutputPath)
+	if err != nil {
+		handler.Respond(c, http.StatusBadRequest, "备份路径必须位于备份根目录内: "+err.Error(), nil)
+		return
+	}
+
+	mgr, ok := connect(c, req.Database)
+	if !ok {
+		return
+	}
+	defer mgr.Disconnect()
+
+	backupper, ok := mgr.(dbmgr2.Backupper)
+	if !ok {
+		handler.Respond(c, http.StatusBadRequest, "该数据库引擎不支持备份", nil)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0700); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "创建备份目录失败: "+err.Error(), nil)
+		return
+	}
+
+	if err := backupper.Backup(req.Database, outputPath); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "备份失败: "+err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "备份成功", gin.H{"output_path": outputPath})
+}
+
+// connect 加载路径参数对应的连接并建立连接，失败时直接写入响应
+func connect(c *gin.Context, db string) (dbmgr2.DatabaseManager, bool) {
+	conn, ok := loadConnection(c)
+	if !ok {
+		return nil, false
+	}
+
+	mgr, err := OpenManager(conn, db)
+	if err != nil {
+		respondConnectionError(c, err)
+		return nil, false
+	}
+	return mgr, true
+}
+
+// IsReadOnlyQuery 判断查询是否只读：SQL 引擎使用语句分类器，Redis 使用只读命令白名单。
+// SQL 文本包含多条语句或无法解析时返回错误
+func IsReadOnlyQuery(engine, query string) (bool, error) {
+	if engine == dbmgr2.EngineRedis {
+		return sqlguard.CheckRedisReadOnly(query) == nil, nil
+	}
+
+	statement, err := sqlguard.Classify(query)
+	if err != nil {
+		return false, err
+	}
+	return statement.ReadOnly(), nil
+}
+
+// CheckReadOnlyQuery 拒绝非只读的查询
+func CheckReadOnlyQuery(engine, query string) error {
+	if engine == dbmgr2.EngineRedis {
+		return sqlguard.CheckRedisReadOnly(query)
+	}
+	return sqlguard.CheckReadOnly(query)
+}
+
+// QueryLimits 配置中的查询行数和超时限制
+func QueryLimits() dbmgr2.QueryLimits {
+	return dbmgr2.QueryLimits{
+		MaxRows: config.AppConfig.Query.MaxRows,
+		Timeout: time.Duration(config.AppConfig.Query.TimeoutSeconds) * time.Second,
+	}
+}
+
+// QueryReadOnly 执行已通过只读检查的查询：支持只读事务的引擎在只读事务中执行并应用行数和超时限制，
+// 其余引擎（Redis）直接执行
+func QueryReadOnly(mgr dbmgr2.DatabaseManager, query string) ([]map[string]interface{}, bool, error) {
+	if querier, ok := mgr.(dbmgr2.ReadOnlyQuerier); ok {
+		return querier.QueryReadOnly(query, QueryLimits())
+	}
+
+	rows, err := mgr.Query(query)
+	return rows, false, err
+}
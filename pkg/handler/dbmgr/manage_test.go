@@ -0,0 +1,184 @@
+package dbmgr
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	dbmgr2 "github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/dbmgr"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+	_ "github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupQueryRouter 初始化面板数据库并挂载查询接口，返回路由和一个含 3 行 users 的 SQLite 数据库路径
+func setupQueryRouter(t *testing.T) (*gin.Engine, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	config.AppConfig = &config.Config{
+		Database: config.DatabaseConfig{Path: filepath.Join(dir, "panel.db")},
+		Query:    config.QueryConfig{MaxRows: 2, TimeoutSeconds: 5, SQLiteDirs: []string{dir}},
+	}
+
+	panel, err := gorm.Open(sqlite.Open(config.AppConfig.Database.Path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := panel.AutoMigrate(&models.DatabaseConnection{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	database.DbConn = panel
+
+	path := filepath.Join(dir, "app.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT); INSERT INTO users (name) VALUES ('a'), ('b'), ('c');`); err != nil {
+		t.Fatalf("seed sqlite: %v", err)
+	}
+
+	r := gin.New()
+	r.POST("/databases", CreateConnection)
+	r.PUT("/databases/:id", UpdateConnection)
+	r.POST("/databases/:id/test", TestConnection)
+	r.POST("/databases/:id/query", ExecuteQuery)
+	return r, path
+}
+
+func createSQLiteConnection(t *testing.T, path string, readOnly bool) uint {
+	t.Helper()
+	conn := models.DatabaseConnection{Name: "app", Engine: dbmgr2.EngineSQLite, Path: path, ReadOnly: readOnly}
+	if err := database.DbConn.Create(&conn).Error; err != nil {
+		t.Fatalf("create connection: %v", err)
+	}
+	return conn.ID
+}
+
+func postQuery(r *gin.Engine, id uint, query string) (int, map[string]interface{}) {
+	payload, _ := json.Marshal(models.DatabaseQueryRequest{Query: query})
+	req := httptest.NewRequest(http.MethodPost, "/databases/"+strconv.FormatUint(uint64(id), 10)+"/query", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp.Data
+}
+
+func TestExecuteQueryReadOnlyConnection(t *testing.T) {
+	r, path := setupQueryRouter(t)
+	id := createSQLiteConnection(t, path, true)
+
+	for _, query := range []string{"DELETE FROM users", "DROP TABLE users", "SELECT * FROM users INTO OUTFILE '/tmp/x'"} {
+		if code, _ := postQuery(r, id, query); code != http.StatusForbidden {
+			t.Errorf("%q: expected 403, got %d", query, code)
+		}
+	}
+
+	code, data := postQuery(r, id, "SELECT * FROM users")
+	if code != http.StatusOK || data["count"] != float64(2) || data["truncated"] != true {
+		t.Fatalf("select: expected 2 truncated rows, got %d %v", code, data)
+	}
+}
+
+func TestExecuteQueryWritableConnection(t *testing.T) {
+	r, path := setupQueryRouter(t)
+	id := createSQLiteConnection(t, path, false)
+
+	if code, _ := postQuery(r, id, "SELECT 1; DELETE FROM users"); code != http.StatusBadRequest {
+		t.Fatalf("stacked statements: expected 400, got %d", code)
+	}
+
+	code, data := postQuery(r, id, "DELETE FROM users WHERE name = 'a'")
+	if code != http.StatusOK || data["affected_rows"] != float64(1) {
+		t.Fatalf("delete: expected 1 affected row, got %d %v", code, data)
+	}
+
+	code, data = postQuery(r, id, "SELECT * FROM users")
+	if code != http.StatusOK || data["count"] != float64(2) || data["truncated"] != false {
+		t.Fatalf("select: expected 2 rows, got %d %v", code, data)
+	}
+}
+
+func TestSaveSQLiteConnectionRestrictsPaths(t *testing.T) {
+	r, path := setupQueryRouter(t)
+	outside := filepath.Join(t.TempDir(), "other.db")
+	link := filepath.Join(filepath.Dir(path), "link.db")
+	if err := os.Symlink(config.AppConfig.Database.Path, link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	save := func(method, target, dbPath string) int {
+		payload, _ := json.Marshal(models.DatabaseConnectionRequest{Name: "app", Engine: dbmgr2.EngineSQLite, Path: dbPath})
+		req := httptest.NewRequest(method, target, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	for dbPath, want := range map[string]int{
+		path: http.StatusCreated,
+		filepath.Join(filepath.Dir(path), "new.db"): http.StatusCreated,
+		config.AppConfig.Database.Path:              http.StatusForbidden,
+		config.AppConfig.Database.Path + "-wal":     http.StatusForbidden,
+		link:                                        http.StatusForbidden,
+		outside:                                     http.StatusBadRequest,
+		"":                                          http.StatusBadRequest,
+	} {
+		if got := save(http.MethodPost, "/databases", dbPath); got != want {
+			t.Errorf("create %q: got %d, want %d", dbPath, got, want)
+		}
+	}
+
+	id := createSQLiteConnection(t, path, false)
+	if got := save(http.MethodPut, "/databases/"+strconv.FormatUint(uint64(id), 10), config.AppConfig.Database.Path); got != http.StatusForbidden {
+		t.Errorf("update to panel database: got %d", got)
+	}
+
+	// 保存后配置的目录被移除，打开时重新校验
+	config.AppConfig.Query.SQLiteDirs = nil
+	req := httptest.NewRequest(http.MethodPost, "/databases/"+strconv.FormatUint(uint64(id), 10)+"/test", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("test connection outside allowed directories: got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestExecuteQueryRejectsPanelDatabase(t *testing.T) {
+	r, _ := setupQueryRouter(t)
+	if err := database.DbConn.AutoMigrate(&models.User{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := database.DbConn.Create(&models.User{Username: "ops", Role: models.RoleOperator}).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	// 直接写入数据库，模拟校验之前保存的连接
+	id := createSQLiteConnection(t, config.AppConfig.Database.Path, false)
+
+	for _, query := range []string{"UPDATE users SET role = 'admin'", "SELECT * FROM users"} {
+		if code, _ := postQuery(r, id, query); code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", query, code)
+		}
+	}
+	var user models.User
+	if err := database.DbConn.First(&user, "username = ?", "ops").Error; err != nil || user.Role != models.RoleOperator {
+		t.Fatalf("panel user modified: %+v, %v", user, err)
+	}
+}
@@ -0,0 +1,309 @@
+package file
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/safepath"
+)
+
+// ErrUnsafeArchiveEntry 压缩包条目、符号链接或硬链接指向解压目录之外
+var ErrUnsafeArchiveEntry = errors.New("压缩包条目指向解压目录之外")
+
+// maxLinkTarget zip 中符号链接目标的最大长度
+const maxLinkTarget = 4096
+
+// archiveExtractor 将压缩包条目写入解压目录。条目名不可信：绝对路径、跳出目录的 ../、
+// 经过已解压或原有的符号链接到达目录外的条目，以及指向目录外的符号链接和硬链接都会被拒绝
+type archiveExtractor struct {
+	dest   string // 规范化后的解压目录
+	policy Policy // 每个条目同样需要符合文件管理策略
+}
+
+func newArchiveExtractor(dest string, policy Policy) (*archiveExtractor, error) {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return nil, err
+	}
+	canonical, err := safepath.Canonical(dest)
+	if err != nil {
+		return nil, err
+	}
+	return &archiveExtractor{dest: canonical, policy: policy}, nil
+}
+
+// target 返回条目在解压目录中的路径，父目录已解析符号链接
+func (e *archiveExtractor) target(name string) (string, error) {
+	if name == "" || filepath.IsAbs(name) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchiveEntry, name)
+	}
+	path := filepath.Join(e.dest, name)
+	if !safepath.Within(e.dest, path) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchiveEntry, name)
+	}
+
+	parent, err := safepath.Canonical(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	if !safepath.Within(e.dest, parent) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchiveEntry, name)
+	}
+	if path != e.dest {
+		path = filepath.Join(parent, filepath.Base(path))
+	}
+	if _, err := e.policy.Resolve(path, AccessWrite); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return path, nil
+}
+
+// replaceable 删除目标位置已有的文件或链接，新条目不会经过原有的符号链接写入；已有目录保留
+func replaceable(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s 已存在且是目录", path)
+	}
+	return os.Remove(path)
+}
+
+func (e *archiveExtractor) dir(name string, mode os.FileMode) error {
+	path, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Lstat(path); err == nil && !info.IsDir() {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	if mode.Perm() == 0 {
+		mode = 0755
+	}
+	return os.MkdirAll(path, mode.Perm())
+}
+
+func (e *archiveExtractor) file(name string, mode os.FileMode, content io.Reader) error {
+	path, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := replaceable(path); err != nil {
+		return err
+	}
+	if mode.Perm() == 0 {
+		mode = 0644
+	}
+
+	// O_EXCL 保证不会经过符号链接写入其他文件；只保留权限位，不保留 setuid 等特殊位
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, content); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// symlink 创建符号链接，链接目标必须是相对路径且解析后位于解压目录内
+func (e *archiveExtractor) symlink(name, linkname string) error {
+	path, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	if linkname == "" || filepath.IsAbs(linkname) || !e.linkWithin(filepath.Dir(path), linkname) {
+		return fmt.Errorf("%w: %s -> %s", ErrUnsafeArchiveEntry, name, linkname)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := replaceable(path); err != nil {
+		return err
+	}
+	return os.Symlink(linkname, path)
+}
+
+// linkWithin 按文件系统逐级解析 dir 中的链接目标 linkname，判断其是否位于解压目录内。
+// 不能按字符串清理：d/up -> .. 时 d/up/../.. 实际指向 dest/../..。经过的符号链接解析后必须位于目录内，
+// 且 .. 只能用于已存在的真实目录，之后才被解压的条目或被替换的链接不会改变链接已检查过的含义
+func (e *archiveExtractor) linkWithin(dir, linkname string) bool {
+	current := dir
+	real := true // current 是否为已存在的真实目录
+	for _, part := range strings.Split(filepath.ToSlash(linkname), "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if !real || current == e.dest {
+				return false
+			}
+			current = filepath.Dir(current)
+			continue
+		}
+
+		next := filepath.Join(current, part)
+		info, err := os.Lstat(next)
+		switch {
+		case os.IsNotExist(err) || (err == nil && !real):
+			real = false
+		case err != nil:
+			return false
+		case info.Mode()&os.ModeSymlink != 0:
+			resolved, err := safepath.Canonical(next)
+			if err != nil || !safepath.Within(e.dest, resolved) {
+				return false
+			}
+			next, real = resolved, false
+		default:
+			real = info.IsDir()
+		}
+		current = next
+	}
+	return safepath.Within(e.dest, current)
+}
+
+// hardlink 创建硬链接，linkname 是压缩包中已解压的普通文件
+func (e *archiveExtractor) hardlink(name, linkname string) error {
+	path, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	source, err := e.target(linkname)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(source)
+	if err != nil || !info.Mode().IsRegular() {
+		return fmt.Errorf("%w: %s -> %s", ErrUnsafeArchiveEntry, name, linkname)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := replaceable(path); err != nil {
+		return err
+	}
+	return os.Link(source, path)
+}
+
+func extractZip(src, dest string, policy Policy) error {
+	reader, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	extractor, err := newArchiveExtractor(dest, policy)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range reader.File {
+		if err := extractZipEntry(extractor, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipEntry(extractor *archiveExtractor, file *zip.File) error {
+	mode := file.Mode()
+	if mode.IsDir() {
+		return extractor.dir(file.Name, mode)
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	switch {
+	case mode&os.ModeSymlink != 0:
+		linkname, err := io.ReadAll(io.LimitReader(content, maxLinkTarget))
+		if err != nil {
+			return err
+		}
+		return extractor.symlink(file.Name, string(linkname))
+	case mode.IsRegular():
+		return extractor.file(file.Name, mode, content)
+	default:
+		// 设备文件、管道等不解压
+		return nil
+	}
+}
+
+func extractTar(src, dest string, policy Policy) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return extractTarReader(tar.NewReader(file), dest, policy)
+}
+
+func extractTarGz(src, dest string, policy Policy) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gzReader.Close()
+
+	return extractTarReader(tar.NewReader(gzReader), dest, policy)
+}
+
+func extractTarReader(tarReader *tar.Reader, dest string, policy Policy) error {
+	extractor, err := newArchiveExtractor(dest, policy)
+	if err != nil {
+		return err
+	}
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = extractor.dir(header.Name, header.FileInfo().Mode())
+		case tar.TypeReg:
+			err = extractor.file(header.Name, header.FileInfo().Mode(), tarReader)
+		case tar.TypeSymlink:
+			err = extractor.symlink(header.Name, header.Linkname)
+		case tar.TypeLink:
+			err = extractor.hardlink(header.Name, header.Linkname)
+		default:
+			// 设备文件、管道等不解压
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
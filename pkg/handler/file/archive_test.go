@@ -0,0 +1,229 @@
+package file
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tarEntry 测试压缩包中的条目，link 为符号链接或硬链接的目标
+type tarEntry struct {
+	name     string
+	typeflag byte
+	body     string
+	link     string
+}
+
+func writeTar(t *testing.T, entries []tarEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.tar")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer out.Close()
+
+	writer := tar.NewWriter(out)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Typeflag: entry.typeflag, Linkname: entry.link, Mode: 0644, Size: int64(len(entry.body))}
+		if entry.typeflag == tar.TypeDir {
+			header.Mode = 0755
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatalf("header: %v", err)
+		}
+		if _, err := writer.Write([]byte(entry.body)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	return path
+}
+
+func TestExtractTarRejectsEscapes(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		entries []tarEntry
+		// escaped 不应在解压目录外出现的文件，相对于 outside
+		escaped string
+	}{
+		{"dot-dot", []tarEntry{{name: "../evil", typeflag: tar.TypeReg, body: "x"}}, "evil"},
+		{"nested dot-dot", []tarEntry{{name: "a/../../evil", typeflag: tar.TypeReg, body: "x"}}, "evil"},
+		{"absolute", []tarEntry{{name: "/evil", typeflag: tar.TypeReg, body: "x"}}, ""},
+		{"absolute symlink", []tarEntry{{name: "link", typeflag: tar.TypeSymlink, link: "OUTSIDE"}}, ""},
+		{"relative symlink", []tarEntry{{name: "link", typeflag: tar.TypeSymlink, link: "../outside"}}, ""},
+		{"write through symlink", []tarEntry{
+			{name: "dir", typeflag: tar.TypeDir},
+			{name: "dir/link", typeflag: tar.TypeSymlink, link: "../../escape"},
+			{name: "dir/link/evil", typeflag: tar.TypeReg, body: "x"},
+		}, "evil"},
+		{"hard link", []tarEntry{{name: "passwd", typeflag: tar.TypeLink, link: "../secret"}}, ""},
+		{"chained symlink", []tarEntry{
+			{name: "d", typeflag: tar.TypeDir},
+			{name: "d/up", typeflag: tar.TypeSymlink, link: ".."},
+			{name: "esc", typeflag: tar.TypeSymlink, link: "d/up/../.."},
+		}, ""},
+		{"symlink created later", []tarEntry{
+			{name: "esc", typeflag: tar.TypeSymlink, link: "x/.."},
+			{name: "x", typeflag: tar.TypeSymlink, link: "."},
+		}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			outside := t.TempDir()
+			dest := filepath.Join(t.TempDir(), "dest")
+			secret := filepath.Join(filepath.Dir(dest), "secret")
+			if err := os.WriteFile(secret, []byte("secret"), 0600); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			for i := range tc.entries {
+				if tc.entries[i].link == "OUTSIDE" {
+					tc.entries[i].link = outside
+				}
+			}
+
+			err := extractTar(writeTar(t, tc.entries), dest, Policy{})
+			if !errors.Is(err, ErrUnsafeArchiveEntry) {
+				t.Fatalf("extractTar = %v, want ErrUnsafeArchiveEntry", err)
+			}
+			if tc.escaped != "" {
+				for _, dir := range []string{outside, filepath.Dir(dest), filepath.Dir(filepath.Dir(dest))} {
+					if _, err := os.Lstat(filepath.Join(dir, tc.escaped)); err == nil {
+						t.Fatalf("%s written outside the destination", filepath.Join(dir, tc.escaped))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestExtractTarExistingSymlink(t *testing.T) {
+	outside := t.TempDir()
+	dest := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dest, "link")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	target := filepath.Join(outside, "file")
+	if err := os.WriteFile(target, []byte("original"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Symlink(target, filepath.Join(dest, "file")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	err := extractTar(writeTar(t, []tarEntry{{name: "link/evil", typeflag: tar.TypeReg, body: "x"}}), dest, Policy{})
+	if !errors.Is(err, ErrUnsafeArchiveEntry) {
+		t.Fatalf("extractTar through existing symlink = %v, want ErrUnsafeArchiveEntry", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "evil")); err == nil {
+		t.Fatal("file written through existing symlink")
+	}
+
+	// 已存在的符号链接被替换，而不是写入链接指向的文件
+	if err := extractTar(writeTar(t, []tarEntry{{name: "file", typeflag: tar.TypeReg, body: "new"}}), dest, Policy{}); err != nil {
+		t.Fatalf("extractTar: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "original" {
+		t.Fatalf("outside file overwritten: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dest, "file")); string(data) != "new" {
+		t.Fatalf("extracted file = %q", data)
+	}
+}
+
+func TestExtractTarInternalLinks(t *testing.T) {
+	dest := t.TempDir()
+	entries := []tarEntry{
+		{name: "dir", typeflag: tar.TypeDir},
+		{name: "dir/file", typeflag: tar.TypeReg, body: "content"},
+		{name: "dir/sub/link", typeflag: tar.TypeSymlink, link: "../file"},
+		{name: "hard", typeflag: tar.TypeLink, link: "dir/file"},
+		{name: "fifo", typeflag: tar.TypeFifo},
+	}
+	if err := extractTar(writeTar(t, entries), dest, Policy{}); err != nil {
+		t.Fatalf("extractTar: %v", err)
+	}
+
+	for _, name := range []string{"dir/file", "dir/sub/link", "hard"} {
+		data, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil || string(data) != "content" {
+			t.Errorf("%s = %q, %v", name, data, err)
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "fifo")); err == nil {
+		t.Error("fifo should be skipped")
+	}
+}
+
+func writeZip(t *testing.T, files map[string]string, symlinks map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.zip")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer out.Close()
+
+	writer := zip.NewWriter(out)
+	add := func(name, body string, mode os.FileMode) {
+		header := &zip.FileHeader{Name: name, Method: zip.Store}
+		header.SetMode(mode)
+		w, err := writer.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("create header: %v", err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	for name, body := range files {
+		add(name, body, 0644)
+	}
+	for name, link := range symlinks {
+		add(name, link, os.ModeSymlink|0777)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	return path
+}
+
+func TestExtractZip(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		files    map[string]string
+		symlinks map[string]string
+		ok       bool
+	}{
+		{"regular", map[string]string{"a/b.txt": "x"}, map[string]string{"a/link": "b.txt"}, true},
+		{"dot-dot", map[string]string{"../evil": "x"}, nil, false},
+		{"absolute", map[string]string{"/evil": "x"}, nil, false},
+		{"symlink escape", nil, map[string]string{"link": "../../etc"}, false},
+		{"absolute symlink", nil, map[string]string{"link": "/etc"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			parent := t.TempDir()
+			dest := filepath.Join(parent, "dest")
+			err := extractZip(writeZip(t, tc.files, tc.symlinks), dest, Policy{})
+			if tc.ok {
+				if err != nil {
+					t.Fatalf("extractZip: %v", err)
+				}
+				if data, err := os.ReadFile(filepath.Join(dest, "a", "link")); err != nil || string(data) != "x" {
+					t.Fatalf("link = %q, %v", data, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnsafeArchiveEntry) {
+				t.Fatalf("extractZip = %v, want ErrUnsafeArchiveEntry", err)
+			}
+			if _, err := os.Stat(filepath.Join(parent, "evil")); err == nil {
+				t.Fatal("file written outside the destination")
+			}
+		})
+	}
+}
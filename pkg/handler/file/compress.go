@@ -0,0 +1,223 @@
+package file
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// archiveFormat 压缩格式对应的扩展名和 Content-Type
+type archiveFormat struct {
+	ext         string
+	contentType string
+}
+
+// archiveFormats 支持的压缩格式
+var archiveFormats = map[string]archiveFormat{
+	"zip":    {".zip", "application/zip"},
+	"tar":    {".tar", "application/x-tar"},
+	"tar.gz": {".tar.gz", "application/gzip"},
+}
+
+// archiveWriter 边遍历边写入压缩包，可以直接写入响应，不需要先在磁盘上生成临时文件。
+// 目录递归写入，符号链接保存为链接本身，受保护和被策略隐藏的路径以及设备文件等会被跳过
+type archiveWriter struct {
+	zip     *zip.Writer
+	tar     *tar.Writer
+	gzip    *gzip.Writer
+	policy  Policy
+	exclude string // 不写入的路径，压缩到被压缩的目录中时跳过输出文件本身
+}
+
+func newArchiveWriter(w io.Writer, format string, policy Policy) (*archiveWriter, error) {
+	a := &archiveWriter{policy: policy}
+	switch format {
+	case "zip":
+		a.zip = zip.NewWriter(w)
+	case "tar":
+		a.tar = tar.NewWriter(w)
+	case "tar.gz":
+		a.gzip = gzip.NewWriter(w)
+		a.tar = tar.NewWriter(a.gzip)
+	default:
+		return nil, fmt.Errorf("格式不支持: %s", format)
+	}
+	return a, nil
+}
+
+// writeArchive 将 paths 写入压缩包，每个路径以其文件名作为压缩包中的顶层条目
+func writeArchive(w io.Writer, format string, paths []string, policy Policy, exclude string) error {
+	archive, err := newArchiveWriter(w, format, policy)
+	if err != nil {
+		return err
+	}
+	archive.exclude = exclude
+
+	names := archiveNames(paths)
+	for i, path := range paths {
+		if err := archive.add(path, names[i]); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// archiveNames 返回每个路径在压缩包中的顶层名称，同名的路径依次加上序号
+func archiveNames(paths []string) []string {
+	names := make([]string, len(paths))
+	used := make(map[string]bool)
+	for i, path := range paths {
+		base := filepath.Base(path)
+		if base == "/" || base == "." {
+			base = "root"
+		}
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s (%d)", base, n)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// add 递归写入 root，name 为 root 在压缩包中的名称
+func (a *archiveWriter) add(root, name string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && (withinProtected(path) || a.policy.IsHidden(path)) || path == a.exclude {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		entryName := filepath.ToSlash(filepath.Join(name, rel))
+
+		switch {
+		case info.IsDir():
+			return a.writeDir(entryName+"/", info)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return a.writeSymlink(entryName, link, info)
+		case info.Mode().IsRegular():
+			return a.writeFile(entryName, path, info)
+		default:
+			// 设备文件、管道和套接字不写入
+			return nil
+		}
+	})
+}
+
+func (a *archiveWriter) writeDir(name string, info fs.FileInfo) error {
+	if a.zip != nil {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		header.Method = zip.Store
+		_, err = a.zip.CreateHeader(header)
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	return a.tar.WriteHeader(header)
+}
+
+func (a *archiveWriter) writeSymlink(name, link string, info fs.FileInfo) error {
+	if a.zip != nil {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		header.Method = zip.Store
+		writer, err := a.zip.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(writer, link)
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	return a.tar.WriteHeader(header)
+}
+
+func (a *archiveWriter) writeFile(name, path string, info fs.FileInfo) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if a.zip != nil {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		header.Method = zip.Deflate
+		writer, err := a.zip.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(writer, file)
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := a.tar.WriteHeader(header); err != nil {
+		return err
+	}
+	// tar 条目的大小在头中已确定，文件在写入期间变化时只写入开始时的大小，变小则报错
+	if _, err := io.CopyN(a.tar, file, header.Size); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Close 写入压缩包的结尾，不关闭底层的 writer
+func (a *archiveWriter) Close() error {
+	if a.zip != nil {
+		return a.zip.Close()
+	}
+	if err := a.tar.Close(); err != nil {
+		return err
+	}
+	if a.gzip != nil {
+		return a.gzip.Close()
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package file
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/gin-gonic/gin"
+)
+
+func download(t *testing.T, target string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.New()
+	r.GET("/download", DownloadFile)
+	r.GET("/download/archive", DownloadArchive)
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestDownloadFileRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "视频 1.mp4")
+	if err := os.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	target := "/download?path=" + url.QueryEscape(path)
+
+	full := download(t, target, nil)
+	if full.Code != http.StatusOK || full.Body.String() != "0123456789" || full.Header().Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("full download: %d %q %v", full.Code, full.Body.String(), full.Header())
+	}
+	if got := full.Header().Get("Content-Disposition"); got != "attachment; filename*=utf-8''%E8%A7%86%E9%A2%91%201.mp4" {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	etag := full.Header().Get("ETag")
+
+	w := download(t, target, http.Header{"Range": {"bytes=2-5"}, "If-Range": {etag}})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "2345" || w.Header().Get("Content-Range") != "bytes 2-5/10" {
+		t.Fatalf("range: %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	// 文件变化后 If-Range 不匹配，返回完整内容
+	w = download(t, target, http.Header{"Range": {"bytes=2-5"}, "If-Range": {`"stale"`}})
+	if w.Code != http.StatusOK || w.Body.String() != "0123456789" {
+		t.Fatalf("stale If-Range: %d %q", w.Code, w.Body.String())
+	}
+
+	w = download(t, target+"&inline=true", http.Header{"Range": {"bytes=8-"}})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "89" || w.Header().Get("Content-Type") != "video/mp4" {
+		t.Fatalf("inline range: %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+// archiveTree 创建用于打包的目录：普通文件、子目录、符号链接和一个被策略隐藏的文件
+func archiveTree(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "site")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for name, content := range map[string]string{"index.html": "<h1>", "sub/a.txt": "a", ".env": "SECRET=1"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := os.Symlink("sub/a.txt", filepath.Join(dir, "link")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+	config.AppConfig = &config.Config{Files: config.FilesConfig{Default: config.FilePolicy{Hidden: []string{".env"}}}}
+	return dir
+}
+
+func TestDownloadDirectoryAsZip(t *testing.T) {
+	dir := archiveTree(t)
+
+	w := download(t, "/download?path="+url.QueryEscape(dir), nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("download: %d %v", w.Code, w.Header())
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename=site.zip` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	contents := make(map[string]string)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[file.Name] = string(data)
+		if file.Name == "site/link" && file.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("link stored as %v", file.Mode())
+		}
+	}
+	want := map[string]string{"site/": "", "site/index.html": "<h1>", "site/sub/": "", "site/sub/a.txt": "a", "site/link": "sub/a.txt"}
+	if len(contents) != len(want) {
+		t.Fatalf("entries = %v", contents)
+	}
+	for name, content := range want {
+		if contents[name] != content {
+			t.Errorf("%s = %q, want %q", name, contents[name], content)
+		}
+	}
+}
+
+func TestDownloadSelectionAsTarGz(t *testing.T) {
+	dir := archiveTree(t)
+	other := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(other, []byte("other"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	query := url.Values{"path": {filepath.Join(dir, "sub", "a.txt"), other}, "format": {"tar.gz"}}
+	w := download(t, "/download/archive?"+query.Encode(), nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/gzip" {
+		t.Fatalf("download: %d %s", w.Code, w.Body.String())
+	}
+
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	reader := tar.NewReader(gz)
+	var names []string
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "a.txt" || names[1] != "a.txt (2)" {
+		t.Errorf("names = %v", names)
+	}
+
+	for _, query := range []url.Values{
+		{"path": {filepath.Join(dir, ".env")}},
+		{"path": {filepath.Join(dir, "missing")}},
+		{"path": {dir}, "format": {"rar"}},
+	} {
+		if w := download(t, "/download/archive?"+query.Encode(), nil); w.Code == http.StatusOK {
+			t.Errorf("%v: expected error, got 200", query)
+		}
+	}
+}
@@ -1,14 +1,16 @@
 package file
 
 import (
-	"archive/tar"
-	"archive/zip"
-	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"log"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -17,16 +19,6 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// 检查路径是否受保护
-func isProtectedPath(path string) bool {
-	for _, protected := range models.ProtectedDirs {
-		if strings.HasPrefix(path, protected) {
-			return true
-		}
-	}
-	return false
-}
-
 // ListFiles 列出目录文件
 // @Summary 列出目录文件
 // @Description 获取指定目录下的文件和文件夹列表
@@ -35,16 +27,16 @@ func isProtectedPath(path string) bool {
 // @Produce json
 // @Security BearerAuth
 // @Param path query string false "目录路径" default("/home")
-// @Success 200 {object} handler.Response{data=object{files=[]models.FileInfo,currentPath=string}} "获取成功"
+// @Success 200 {object} handler.Response{data=object{files=[]models.FileInfo,currentPath=string,readOnly=bool}} "获取成功"
 // @Failure 401 {object} handler.Response "未授权"
 // @Failure 403 {object} handler.Response "拒绝访问"
 // @Failure 500 {object} handler.Response "服务器内部错误"
 // @Router /auth/files [get]
 func ListFiles(c *gin.Context) {
-	path := c.DefaultQuery("path", "/home")
-
-	if isProtectedPath(path) {
-		handler.Respond(c, http.StatusForbidden, "拒绝访问", nil)
+	policy := policyOf(c)
+	path, err := policy.Resolve(c.DefaultQuery("path", "/home"), AccessRead)
+	if err != nil {
+		respondPathError(c, err)
 		return
 	}
 
@@ -57,6 +49,9 @@ func ListFiles(c *gin.Context) {
 	var fileInfos []models.FileInfo
 	for _, file := range files {
 		fullPath := filepath.Join(path, file.Name())
+		if policy.IsHidden(fullPath) {
+			continue
+		}
 		info, err := os.Lstat(fullPath) // 使用 os.Lstat 获取符号链接本身的信息
 		if err != nil {
 			continue
@@ -96,53 +91,134 @@ func ListFiles(c *gin.Context) {
 	handler.Respond(c, http.StatusOK, nil, gin.H{
 		"files":       fileInfos,
 		"currentPath": path,
+		"readOnly":    policy.ReadOnly(path),
 	})
 }
 
 // DownloadFile 下载文件
 // @Summary 下载文件
-// @Description 下载指定路径的文件
+// @Description 下载指定路径的文件，支持 Range 和 If-Range 断点续传及视频拖动播放。路径为目录时打包为压缩包边压缩边发送
 // @Tags 文件管理
 // @Accept json
 // @Produce application/octet-stream
 // @Security BearerAuth
 // @Param path query string true "文件路径"
+// @Param inline query bool false "在浏览器中直接打开而不是下载"
+// @Param format query string false "路径为目录时的压缩格式：zip、tar 或 tar.gz" default(zip)
 // @Success 200 {file} file "文件内容"
+// @Success 206 {file} file "Range 请求的部分内容"
 // @Failure 400 {object} handler.Response "请求参数错误"
 // @Failure 401 {object} handler.Response "未授权"
 // @Failure 403 {object} handler.Response "拒绝访问"
 // @Failure 404 {object} handler.Response "文件不存在"
 // @Router /auth/files/download [get]
 func DownloadFile(c *gin.Context) {
-	handler.Respond(c, http.StatusBadRequest, "演示版本禁止操作", nil)
-	return
-	//filePath := c.Query("path")
-	//if filePath == "" {
-	//	handler.Respond(c, http.StatusBadRequest, "参数为空", nil)
-	//	return
-	//}
-	//
-	//if isProtectedPath(filePath) {
-	//	handler.Respond(c, http.StatusForbidden, "拒绝访问", nil)
-	//	return
-	//}
-	//
-	//info, err := os.Stat(filePath)
-	//if err != nil {
-	//	handler.Respond(c, http.StatusNotFound, "文件不存在", nil)
-	//	return
-	//}
-	//
-	//if info.IsDir() {
-	//	handler.Respond(c, http.StatusBadRequest, "文件夹不允许下载", nil)
-	//	return
-	//}
-	//
-	//c.Header("Content-Description", "File Transfer")
-	//c.Header("Content-Transfer-Encoding", "binary")
-	//c.Header("Content-Disposition", "attachment; filename="+filepath.Base(filePath))
-	//c.Header("Content-Type", "application/octet-stream")
-	//c.File(filePath)
+	filePath := c.Query("path")
+	if filePath == "" {
+		handler.Respond(c, http.StatusBadRequest, "参数为空", nil)
+		return
+	}
+
+	policy := policyOf(c)
+	filePath, err := policy.Resolve(filePath, AccessRead)
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		handler.Respond(c, http.StatusNotFound, "文件不存在", nil)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		handler.Respond(c, http.StatusNotFound, "文件不存在", nil)
+		return
+	}
+
+	// 目录打包为压缩包边读边发送
+	if info.IsDir() {
+		streamArchive(c, []string{filePath}, c.DefaultQuery("format", "zip"), policy)
+		return
+	}
+
+	disposition := "attachment"
+	if c.Query("inline") == "true" {
+		// 在浏览器中直接打开（如视频播放），Content-Type 由扩展名或内容判断
+		disposition = "inline"
+	} else {
+		c.Header("Content-Type", "application/octet-stream")
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": info.Name()}))
+	// ETag 由大小和修改时间生成，配合 Range 和 If-Range 断点续传，文件变化后重新下载完整内容
+	c.Header("ETag", fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), file)
+}
+
+// DownloadArchive 打包下载
+// @Summary 打包下载
+// @Description 将多个文件或目录打包为 zip、tar 或 tar.gz，边压缩边发送，不在服务器上生成临时文件。受保护和被隐藏的路径会被跳过
+// @Tags 文件管理
+// @Accept json
+// @Produce application/octet-stream
+// @Security BearerAuth
+// @Param path query []string true "文件或目录路径，可重复" collectionFormat(multi)
+// @Param format query string false "压缩格式：zip、tar 或 tar.gz" default(zip)
+// @Success 200 {file} file "压缩包"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "拒绝访问"
+// @Failure 404 {object} handler.Response "文件不存在"
+// @Router /auth/files/download/archive [get]
+func DownloadArchive(c *gin.Context) {
+	paths := c.QueryArray("path")
+	if len(paths) == 0 {
+		handler.Respond(c, http.StatusBadRequest, "参数为空", nil)
+		return
+	}
+
+	policy := policyOf(c)
+	resolved := make([]string, 0, len(paths))
+	for _, path := range paths {
+		path, err := policy.Resolve(path, AccessRead)
+		if err == nil {
+			_, err = os.Lstat(path)
+		}
+		if err != nil {
+			respondPathError(c, err)
+			return
+		}
+		resolved = append(resolved, path)
+	}
+
+	streamArchive(c, resolved, c.DefaultQuery("format", "zip"), policy)
+}
+
+// streamArchive 将路径打包后直接写入响应。开始发送后无法再返回错误，出错时直接关闭连接，让客户端得知下载不完整
+func streamArchive(c *gin.Context, paths []string, format string, policy Policy) {
+	archive, ok := archiveFormats[format]
+	if !ok {
+		handler.Respond(c, http.StatusBadRequest, "格式不支持", nil)
+		return
+	}
+
+	name := "files"
+	if len(paths) == 1 {
+		name = archiveNames(paths)[0]
+	}
+	c.Header("Content-Type", archive.contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + archive.ext}))
+	c.Status(http.StatusOK)
+
+	if err := writeArchive(c.Writer, format, paths, policy, ""); err != nil {
+		log.Printf("打包下载 %v 失败: %v", paths, err)
+		if conn, _, err := c.Writer.Hijack(); err == nil {
+			conn.Close()
+		}
+	}
 }
 
 // UploadFile 上传文件
@@ -161,51 +237,38 @@ func DownloadFile(c *gin.Context) {
 // @Failure 500 {object} handler.Response "服务器内部错误"
 // @Router /auth/files/upload [post]
 func UploadFile(c *gin.Context) {
-	handler.Respond(c, http.StatusBadRequest, "演示版本禁止操作", nil)
-	return
-	//targetDir := c.PostForm("path")
-	//if targetDir == "" {
-	//	targetDir = "/tmp"
-	//}
-	//
-	//if isProtectedPath(targetDir) {
-	//	handler.Respond(c, http.StatusForbidden, "拒绝访问", nil)
-	//	return
-	//}
-	//
-	//file, header, err := c.Request.FormFile("file")
-	//if err != nil {
-	//	handler.Respond(c, http.StatusBadRequest, "获取上传文件失败", nil)
-	//	return
-	//}
-	//defer func(file multipart.File) {
-	//	err := file.Close()
-	//	if err != nil {
-	//
-	//	}
-	//}(file)
-	//
-	//targetPath := filepath.Join(targetDir, header.Filename)
-	//
-	//out, err := os.Create(targetPath)
-	//if err != nil {
-	//	handler.Respond(c, http.StatusInternalServerError, "创建文件失败", nil)
-	//	return
-	//}
-	//defer func(out *os.File) {
-	//	err := out.Close()
-	//	if err != nil {
-	//
-	//	}
-	//}(out)
-	//
-	//_, err = io.Copy(out, file)
-	//if err != nil {
-	//	handler.Respond(c, http.StatusInternalServerError, "保存文件失败", nil)
-	//	return
-	//}
-	//
-	//handler.Respond(c, http.StatusOK, "上传成功", nil)
+	targetDir := c.PostForm("path")
+	if targetDir == "" {
+		targetDir = "/tmp"
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		handler.Respond(c, http.StatusBadRequest, "获取上传文件失败", nil)
+		return
+	}
+	defer file.Close()
+
+	targetPath, err := policyOf(c).Resolve(filepath.Join(targetDir, filepath.Base(header.Filename)), AccessWrite)
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+
+	out, err := os.Create(targetPath)
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "创建文件失败", nil)
+		return
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, file)
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "保存文件失败", nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "上传成功", nil)
 }
 
 // MoveFile 移动文件
@@ -223,30 +286,35 @@ func UploadFile(c *gin.Context) {
 // @Failure 500 {object} handler.Response "服务器内部错误"
 // @Router /auth/files/move [post]
 func MoveFile(c *gin.Context) {
-	handler.Respond(c, http.StatusBadRequest, "演示版本禁止操作", nil)
-	return
-	//var req struct {
-	//	Source string `json:"source"`
-	//	Target string `json:"target"`
-	//}
-	//
-	//if err := c.ShouldBindJSON(&req); err != nil {
-	//	handler.Respond(c, http.StatusBadRequest, nil, nil)
-	//	return
-	//}
-	//
-	//if isProtectedPath(req.Source) || isProtectedPath(req.Target) {
-	//	handler.Respond(c, http.StatusForbidden, "拒绝访问", nil)
-	//	return
-	//}
-	//
-	//err := os.Rename(req.Source, req.Target)
-	//if err != nil {
-	//	handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
-	//	return
-	//}
-	//
-	//handler.Respond(c, http.StatusOK, "移动成功", nil)
+	var req struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, nil, nil)
+		return
+	}
+
+	policy := policyOf(c)
+	source, err := policy.Resolve(req.Source, AccessWrite)
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+	target, err := policy.Resolve(req.Target, AccessWrite)
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+
+	err = os.Rename(source, target)
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "移动成功", nil)
 }
 
 // CopyFile 复制文件
@@ -264,30 +332,35 @@ func MoveFile(c *gin.Context) {
 // @Failure 500 {object} handler.Response "服务器内部错误"
 // @Router /auth/files/copy [post]
 func CopyFile(c *gin.Context) {
-	handler.Respond(c, http.StatusBadRequest, "演示版本禁止操作", nil)
-	return
-	//var req struct {
-	//	Source string `json:"source"`
-	//	Target string `json:"target"`
-	//}
-	//
-	//if err := c.ShouldBindJSON(&req); err != nil {
-	//	handler.Respond(c, http.StatusBadRequest, nil, nil)
-	//	return
-	//}
-	//
-	//if isProtectedPath(req.Source) || isProtectedPath(req.Target) {
-	//	handler.Respond(c, http.StatusForbidden, "拒绝访问", nil)
-	//	return
-	//}
-	//
-	//err := copyFile(req.Source, req.Target)
-	//if err != nil {
-	//	handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
-	//	return
-	//}
-	//
-	//handler.Respond(c, http.StatusOK, "复制成功", nil)
+	var req struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, nil, nil)
+		return
+	}
+
+	policy := policyOf(c)
+	source, err := policy.Resolve(req.Source, AccessRead)
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+	target, err := policy.Resolve(req.Target, AccessWrite)
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+
+	err = copyFile(source, target)
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "复制成功", nil)
 }
 
 func copyFile(src, dst string) error {
@@ -317,41 +390,134 @@ func copyFile(src, dst string) error {
 	return err
 }
 
+// movePath 移动文件或目录，跨文件系统时先复制到目标所在目录的临时目录中再重命名，目标不会出现复制了一半的内容
+func movePath(src, dst string) error {
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dst), ".etapanel-move-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tmp := filepath.Join(tmpDir, filepath.Base(dst))
+	if err := copyTree(src, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyTree 递归复制文件或目录，保留权限、属主和符号链接，设备文件等不复制
+func copyTree(src, dst string) error {
+	// 目录最后再设置权限，避免只读目录中无法创建子项
+	type dirMode struct {
+		path string
+		mode os.FileMode
+	}
+	var dirs []dirMode
+
+	err := filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			if err := os.Mkdir(target, 0700); err != nil {
+				return err
+			}
+			dirs = append(dirs, dirMode{target, info.Mode().Perm()})
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if err := copyFile(path, target); err != nil {
+				return err
+			}
+			if err := os.Chmod(target, info.Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+
+		// 非 root 运行时无法修改属主，保留当前用户
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			_ = os.Lchown(target, int(stat.Uid), int(stat.Gid))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // DeleteFile 删除文件
 // @Summary 删除文件
-// @Description 删除指定路径的文件或目录
+// @Description 将指定路径的文件或目录移入回收站，可在回收站中还原或永久删除
 // @Tags 文件管理
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param path query string true "文件路径"
-// @Success 200 {object} handler.Response "删除成功"
+// @Success 200 {object} handler.Response{data=models.TrashItem} "已移入回收站"
 // @Failure 400 {object} handler.Response "请求参数错误"
 // @Failure 401 {object} handler.Response "未授权"
 // @Failure 403 {object} handler.Response "拒绝访问"
+// @Failure 404 {object} handler.Response "文件不存在"
 // @Failure 500 {object} handler.Response "服务器内部错误"
 // @Router /auth/files [delete]
 func DeleteFile(c *gin.Context) {
-	handler.Respond(c, http.StatusBadRequest, "演示版本禁止操作", nil)
-	return
-	//filePath := c.Query("path")
-	//if filePath == "" {
-	//	handler.Respond(c, http.StatusBadRequest, nil, nil)
-	//	return
-	//}
-	//
-	//if isProtectedPath(filePath) {
-	//	handler.Respond(c, http.StatusForbidden, "拒绝访问", nil)
-	//	return
-	//}
-	//
-	//err := os.RemoveAll(filePath)
-	//if err != nil {
-	//	handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
-	//	return
-	//}
-	//
-	//handler.Respond(c, http.StatusOK, "删除成功", nil)
+	filePath := c.Query("path")
+	if filePath == "" {
+		handler.Respond(c, http.StatusBadRequest, nil, nil)
+		return
+	}
+
+	filePath, err := policyOf(c).Resolve(filePath, AccessWrite)
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+
+	item, err := moveToTrash(filePath, c.GetString("username"))
+	if errors.Is(err, errContainsTrash) || os.IsNotExist(err) {
+		respondPathError(c, err)
+		return
+	}
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "已移入回收站", item)
 }
 
 // CreateDirectory 创建目录
@@ -369,32 +535,29 @@ func DeleteFile(c *gin.Context) {
 // @Failure 500 {object} handler.Response "服务器内部错误"
 // @Router /auth/files/mkdir [post]
 func CreateDirectory(c *gin.Context) {
-	handler.Respond(c, http.StatusBadRequest, "演示版本禁止操作", nil)
-	return
-	//var req struct {
-	//	Path string `json:"path"`
-	//	Name string `json:"name"`
-	//}
-	//
-	//if err := c.ShouldBindJSON(&req); err != nil {
-	//	handler.Respond(c, http.StatusBadRequest, "拒绝访问", nil)
-	//	return
-	//}
-	//
-	//fullPath := filepath.Join(req.Path, req.Name)
-	//
-	//if isProtectedPath(fullPath) {
-	//	handler.Respond(c, http.StatusForbidden, "拒绝访问", nil)
-	//	return
-	//}
-	//
-	//err := os.MkdirAll(fullPath, 0755)
-	//if err != nil {
-	//	handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
-	//	return
-	//}
-	//
-	//handler.Respond(c, http.StatusOK, "创建成功", nil)
+	var req struct {
+		Path string `json:"path"`
+		Name string `json:"name"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误", nil)
+		return
+	}
+
+	fullPath, err := policyOf(c).Resolve(filepath.Join(req.Path, req.Name), AccessWrite)
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+
+	err = os.MkdirAll(fullPath, 0755)
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "创建成功", nil)
 }
 
 // CompressFiles 压缩文件
@@ -412,220 +575,54 @@ func CreateDirectory(c *gin.Context) {
 // @Failure 500 {object} handler.Response "服务器内部错误"
 // @Router /auth/files/compress [post]
 func CompressFiles(c *gin.Context) {
-
-	handler.Respond(c, http.StatusBadRequest, "演示版本禁止操作", nil)
-	return
-	//var req struct {
-	//	Files      []string `json:"files"`
-	//	OutputPath string   `json:"outputPath"`
-	//	Format     string   `json:"format"` // zip, tar, tar.gz
-	//}
-	//
-	//if err := c.ShouldBindJSON(&req); err != nil {
-	//	handler.Respond(c, http.StatusBadRequest, nil, nil)
-	//	return
-	//}
-	//
-	//if isProtectedPath(req.OutputPath) {
-	//	handler.Respond(c, http.StatusForbidden, "拒绝访问", nil)
-	//	return
-	//}
-	//
-	//var err error
-	//switch req.Format {
-	//case "zip":
-	//	err = createZip(req.Files, req.OutputPath)
-	//case "tar":
-	//	err = createTar(req.Files, req.OutputPath)
-	//case "tar.gz":
-	//	err = createTarGz(req.Files, req.OutputPath)
-	//default:
-	//	handler.Respond(c, http.StatusBadRequest, "格式不支持", nil)
-	//	return
-	//}
-	//
-	//if err != nil {
-	//	handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
-	//	return
-	//}
-	//
-	//handler.Respond(c, http.StatusOK, "压缩完毕", nil)
-}
-
-func createZip(files []string, output string) error {
-	zipFile, err := os.Create(output)
-	if err != nil {
-		return err
+	var req struct {
+		Files      []string `json:"files"`
+		OutputPath string   `json:"outputPath"`
+		Format     string   `json:"format"` // zip, tar, tar.gz
 	}
-	defer func(zipFile *os.File) {
-		err := zipFile.Close()
-		if err != nil {
-
-		}
-	}(zipFile)
-
-	zipWriter := zip.NewWriter(zipFile)
-	defer func(zipWriter *zip.Writer) {
-		err := zipWriter.Close()
-		if err != nil {
 
-		}
-	}(zipWriter)
-
-	for _, file := range files {
-		if isProtectedPath(file) {
-			continue
-		}
-
-		err := addFileToZip(zipWriter, file)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-func addFileToZip(zipWriter *zip.Writer, filename string) error {
-	file, err := os.Open(filename)
-	if err != nil {
-		return err
-	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-
-		}
-	}(file)
-
-	info, err := file.Stat()
-	if err != nil {
-		return err
-	}
-
-	header, err := zip.FileInfoHeader(info)
-	if err != nil {
-		return err
-	}
-
-	header.Name = filepath.Base(filename)
-
-	writer, err := zipWriter.CreateHeader(header)
-	if err != nil {
-		return err
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, nil, nil)
+		return
 	}
 
-	_, err = io.Copy(writer, file)
-	return err
-}
-
-func createTar(files []string, output string) error {
-	tarFile, err := os.Create(output)
+	policy := policyOf(c)
+	outputPath, err := policy.Resolve(req.OutputPath, AccessWrite)
 	if err != nil {
-		return err
+		respondPathError(c, err)
+		return
 	}
-	defer func(tarFile *os.File) {
-		err := tarFile.Close()
-		if err != nil {
-
-		}
-	}(tarFile)
-
-	tarWriter := tar.NewWriter(tarFile)
-	defer func(tarWriter *tar.Writer) {
-		err := tarWriter.Close()
-		if err != nil {
-
-		}
-	}(tarWriter)
-
-	for _, file := range files {
-		if isProtectedPath(file) {
-			continue
-		}
-
-		err := addFileToTar(tarWriter, file)
+	files := make([]string, 0, len(req.Files))
+	for _, file := range req.Files {
+		resolved, err := policy.Resolve(file, AccessRead)
 		if err != nil {
-			return err
+			respondPathError(c, err)
+			return
 		}
+		files = append(files, resolved)
 	}
 
-	return nil
-}
-
-func addFileToTar(tarWriter *tar.Writer, filename string) error {
-	file, err := os.Open(filename)
-	if err != nil {
-		return err
-	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-
-		}
-	}(file)
-
-	info, err := file.Stat()
-	if err != nil {
-		return err
+	if _, ok := archiveFormats[req.Format]; !ok {
+		handler.Respond(c, http.StatusBadRequest, "格式不支持", nil)
+		return
 	}
 
-	header, err := tar.FileInfoHeader(info, "")
+	output, err := os.Create(outputPath)
 	if err != nil {
-		return err
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
 	}
-
-	header.Name = filepath.Base(filename)
-
-	err = tarWriter.WriteHeader(header)
-	if err != nil {
-		return err
+	err = writeArchive(output, req.Format, files, policy, outputPath)
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
 	}
-
-	_, err = io.Copy(tarWriter, file)
-	return err
-}
-
-func createTarGz(files []string, output string) error {
-	tarFile, err := os.Create(output)
 	if err != nil {
-		return err
-	}
-	defer func(tarFile *os.File) {
-		err := tarFile.Close()
-		if err != nil {
-
-		}
-	}(tarFile)
-
-	gzWriter := gzip.NewWriter(tarFile)
-	defer func(gzWriter *gzip.Writer) {
-		err := gzWriter.Close()
-		if err != nil {
-
-		}
-	}(gzWriter)
-
-	tarWriter := tar.NewWriter(gzWriter)
-	defer func(tarWriter *tar.Writer) {
-		err := tarWriter.Close()
-		if err != nil {
-
-		}
-	}(tarWriter)
-
-	for _, file := range files {
-		if isProtectedPath(file) {
-			continue
-		}
-
-		err := addFileToTar(tarWriter, file)
-		if err != nil {
-			return err
-		}
+		os.Remove(outputPath)
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
 	}
 
-	return nil
+	handler.Respond(c, http.StatusOK, "压缩完毕", nil)
 }
 
 // ExtractFiles 解压文件
@@ -643,231 +640,47 @@ func createTarGz(files []string, output string) error {
 // @Failure 500 {object} handler.Response "服务器内部错误"
 // @Router /auth/files/extract [post]
 func ExtractFiles(c *gin.Context) {
-	handler.Respond(c, http.StatusBadRequest, "演示版本禁止操作", nil)
-	return
-	//var req struct {
-	//	FilePath   string `json:"filePath"`
-	//	OutputPath string `json:"outputPath"`
-	//}
-	//
-	//if err := c.ShouldBindJSON(&req); err != nil {
-	//	handler.Respond(c, http.StatusBadRequest, nil, nil)
-	//	return
-	//}
-	//
-	//if isProtectedPath(req.FilePath) || isProtectedPath(req.OutputPath) {
-	//	handler.Respond(c, http.StatusForbidden, "拒绝访问", nil)
-	//	return
-	//}
-	//
-	//ext := strings.ToLower(filepath.Ext(req.FilePath))
-	//var err error
-	//
-	//switch ext {
-	//case ".zip":
-	//	err = extractZip(req.FilePath, req.OutputPath)
-	//case ".tar":
-	//	err = extractTar(req.FilePath, req.OutputPath)
-	//case ".gz":
-	//	if strings.HasSuffix(strings.ToLower(req.FilePath), ".tar.gz") {
-	//		err = extractTarGz(req.FilePath, req.OutputPath)
-	//	} else {
-	//		err = fmt.Errorf("不支持的文件格式")
-	//	}
-	//default:
-	//	err = fmt.Errorf("不支持的文件格式")
-	//}
-	//
-	//if err != nil {
-	//	handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
-	//	return
-	//}
-	//
-	//handler.Respond(c, http.StatusOK, nil, nil)
-}
-
-func extractZip(src, dest string) error {
-	reader, err := zip.OpenReader(src)
-	if err != nil {
-		return err
-	}
-	defer func(reader *zip.ReadCloser) {
-		err := reader.Close()
-		if err != nil {
-
-		}
-	}(reader)
-
-	err = os.MkdirAll(dest, 0755)
-	if err != nil {
-		return err
+	var req struct {
+		FilePath   string `json:"filePath"`
+		OutputPath string `json:"outputPath"`
 	}
 
-	for _, file := range reader.File {
-		path := filepath.Join(dest, file.Name)
-
-		if file.FileInfo().IsDir() {
-			err := os.MkdirAll(path, file.FileInfo().Mode())
-			if err != nil {
-				return err
-			}
-			continue
-		}
-
-		fileReader, err := file.Open()
-		if err != nil {
-			return err
-		}
-		defer func(fileReader io.ReadCloser) {
-			err := fileReader.Close()
-			if err != nil {
-
-			}
-		}(fileReader)
-
-		targetFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.FileInfo().Mode())
-		if err != nil {
-			return err
-		}
-		defer func(targetFile *os.File) {
-			err := targetFile.Close()
-			if err != nil {
-
-			}
-		}(targetFile)
-
-		_, err = io.Copy(targetFile, fileReader)
-		if err != nil {
-			return err
-		}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, nil, nil)
+		return
 	}
 
-	return nil
-}
-
-func extractTar(src, dest string) error {
-	file, err := os.Open(src)
+	policy := policyOf(c)
+	filePath, err := policy.Resolve(req.FilePath, AccessRead)
 	if err != nil {
-		return err
-	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-
-		}
-	}(file)
-
-	tarReader := tar.NewReader(file)
-
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		path := filepath.Join(dest, header.Name)
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			err := os.MkdirAll(path, 0755)
-			if err != nil {
-				return err
-			}
-		case tar.TypeReg:
-			err := os.MkdirAll(filepath.Dir(path), 0755)
-			if err != nil {
-				return err
-			}
-			outFile, err := os.Create(path)
-			if err != nil {
-				return err
-			}
-			defer func(outFile *os.File) {
-				err := outFile.Close()
-				if err != nil {
-
-				}
-			}(outFile)
-
-			_, err = io.Copy(outFile, tarReader)
-			if err != nil {
-				return err
-			}
-		}
+		respondPathError(c, err)
+		return
 	}
-
-	return nil
-}
-
-func extractTarGz(src, dest string) error {
-	file, err := os.Open(src)
+	outputPath, err := policy.Resolve(req.OutputPath, AccessWrite)
 	if err != nil {
-		return err
+		respondPathError(c, err)
+		return
 	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-
-		}
-	}(file)
 
-	gzReader, err := gzip.NewReader(file)
-	if err != nil {
-		return err
+	name := strings.ToLower(filepath.Base(filePath))
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		err = extractZip(filePath, outputPath, policy)
+	case strings.HasSuffix(name, ".tar"):
+		err = extractTar(filePath, outputPath, policy)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		err = extractTarGz(filePath, outputPath, policy)
+	default:
+		handler.Respond(c, http.StatusBadRequest, "不支持的文件格式", nil)
+		return
 	}
-	defer func(gzReader *gzip.Reader) {
-		err := gzReader.Close()
-		if err != nil {
-
-		}
-	}(gzReader)
-
-	tarReader := tar.NewReader(gzReader)
 
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		path := filepath.Join(dest, header.Name)
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			err := os.MkdirAll(path, 0755)
-			if err != nil {
-				return err
-			}
-		case tar.TypeReg:
-			err := os.MkdirAll(filepath.Dir(path), 0755)
-			if err != nil {
-				return err
-			}
-			outFile, err := os.Create(path)
-			if err != nil {
-				return err
-			}
-			defer func(outFile *os.File) {
-				err := outFile.Close()
-				if err != nil {
-					fmt.Printf("Error closing file %s: %v\n", outFile.Name(), err)
-				}
-			}(outFile)
-
-			_, err = io.Copy(outFile, tarReader)
-			if err != nil {
-				return err
-			}
-		}
+	if err != nil {
+		respondPathError(c, err)
+		return
 	}
 
-	return nil
+	handler.Respond(c, http.StatusOK, nil, nil)
 }
 
 // GetPermissions 获取文件权限
@@ -891,10 +704,15 @@ func GetPermissions(c *gin.Context) {
 		return
 	}
 
-	if isProtectedPath(filePath) {
+	filePath, err := policyOf(c).Resolve(filePath, AccessRead)
+	if errors.Is(err, ErrProtectedPath) {
 		handler.Respond(c, http.StatusForbidden, "无法查看受保护文件的权限", nil)
 		return
 	}
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
 
 	info, err := os.Stat(filePath)
 	if err != nil {
@@ -929,63 +747,66 @@ func GetPermissions(c *gin.Context) {
 // @Failure 500 {object} handler.Response "服务器内部错误"
 // @Router /auth/files/permissions [post]
 func SetPermissions(c *gin.Context) {
-	handler.Respond(c, http.StatusBadRequest, "演示版本禁止操作", nil)
-	return
-	//var req struct {
-	//	Path        string `json:"path"`
-	//	Permissions string `json:"permissions"`
-	//	Owner       string `json:"owner,omitempty"`
-	//	Group       string `json:"group,omitempty"`
-	//}
-	//
-	//if err := c.ShouldBindJSON(&req); err != nil {
-	//	handler.Respond(c, http.StatusBadRequest, "请求参数错误", nil)
-	//	return
-	//}
-	//
-	//if isProtectedPath(req.Path) {
-	//	handler.Respond(c, http.StatusForbidden, "无法修改受保护文件的权限", nil)
-	//	return
-	//}
-	//
-	//// 设置文件权限
-	//perm, err := strconv.ParseUint(req.Permissions, 8, 32)
-	//if err != nil {
-	//	handler.Respond(c, http.StatusBadRequest, "权限格式错误", nil)
-	//	return
-	//}
-	//
-	//err = os.Chmod(req.Path, os.FileMode(perm))
-	//if err != nil {
-	//	handler.Respond(c, http.StatusInternalServerError, "设置权限失败: "+err.Error(), nil)
-	//	return
-	//}
-	//
-	//// 设置所有者和组（如果提供）
-	//if req.Owner != "" || req.Group != "" {
-	//	uid := -1
-	//	gid := -1
-	//
-	//	if req.Owner != "" {
-	//		if u, err := strconv.Atoi(req.Owner); err == nil {
-	//			uid = u
-	//		}
-	//	}
-	//
-	//	if req.Group != "" {
-	//		if g, err := strconv.Atoi(req.Group); err == nil {
-	//			gid = g
-	//		}
-	//	}
-	//
-	//	err = os.Chown(req.Path, uid, gid)
-	//	if err != nil {
-	//		handler.Respond(c, http.StatusInternalServerError, "设置所有者失败: "+err.Error(), nil)
-	//		return
-	//	}
-	//}
-	//
-	//handler.Respond(c, http.StatusOK, "权限设置成功", nil)
+	var req struct {
+		Path        string `json:"path"`
+		Permissions string `json:"permissions"`
+		Owner       string `json:"owner,omitempty"`
+		Group       string `json:"group,omitempty"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误", nil)
+		return
+	}
+
+	filePath, err := policyOf(c).Resolve(req.Path, AccessWrite)
+	if errors.Is(err, ErrProtectedPath) {
+		handler.Respond(c, http.StatusForbidden, "无法修改受保护文件的权限", nil)
+		return
+	}
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+
+	// 设置文件权限
+	perm, err := strconv.ParseUint(req.Permissions, 8, 32)
+	if err != nil || perm > 0o7777 {
+		handler.Respond(c, http.StatusBadRequest, "权限格式错误", nil)
+		return
+	}
+
+	err = os.Chmod(filePath, os.FileMode(perm))
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "设置权限失败: "+err.Error(), nil)
+		return
+	}
+
+	// 设置所有者和组（如果提供）
+	if req.Owner != "" || req.Group != "" {
+		uid := -1
+		gid := -1
+
+		if req.Owner != "" {
+			if u, err := strconv.Atoi(req.Owner); err == nil {
+				uid = u
+			}
+		}
+
+		if req.Group != "" {
+			if g, err := strconv.Atoi(req.Group); err == nil {
+				gid = g
+			}
+		}
+
+		err = os.Chown(filePath, uid, gid)
+		if err != nil {
+			handler.Respond(c, http.StatusInternalServerError, "设置所有者失败: "+err.Error(), nil)
+			return
+		}
+	}
+
+	handler.Respond(c, http.StatusOK, "权限设置成功", nil)
 }
 
 // GetFileContent 获取文件内容（用于编辑文本文件）
@@ -1003,36 +824,32 @@ func SetPermissions(c *gin.Context) {
 // @Failure 500 {object} handler.Response "服务器内部错误"
 // @Router /auth/files/content [get]
 func GetFileContent(c *gin.Context) {
-	handler.Respond(c, http.StatusBadRequest, "演示版本禁止操作", nil)
-	return
-	//filePath := c.Query("path")
-	//if filePath == "" {
-	//	handler.Respond(c, http.StatusBadRequest, "文件路径不能为空", nil)
-	//	return
-	//}
-	//decodedPath, err := url.QueryUnescape(filePath)
-	//if err != nil {
-	//	handler.Respond(c, http.StatusBadRequest, "文件路径解码失败", nil)
-	//	return
-	//}
-	//filePath = decodedPath
-	//
-	//if isProtectedPath(filePath) {
-	//	handler.Respond(c, http.StatusForbidden, "无法查看受保护文件的内容", nil)
-	//	return
-	//}
-	//
-	//content, err := os.ReadFile(filePath)
-	//if err != nil {
-	//	handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
-	//	fmt.Println(err)
-	//	return
-	//}
-
-	//handler.Respond(c, http.StatusOK, "", gin.H{
-	//	"content": string(content),
-	//	"path":    filePath,
-	//})
+	filePath := c.Query("path")
+	if filePath == "" {
+		handler.Respond(c, http.StatusBadRequest, "文件路径不能为空", nil)
+		return
+	}
+
+	filePath, err := policyOf(c).Resolve(filePath, AccessRead)
+	if errors.Is(err, ErrProtectedPath) {
+		handler.Respond(c, http.StatusForbidden, "无法查看受保护文件的内容", nil)
+		return
+	}
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "", gin.H{
+		"content": string(content),
+		"path":    filePath,
+	})
 }
 
 // SaveFileContent 保存文件内容
@@ -1050,28 +867,31 @@ func GetFileContent(c *gin.Context) {
 // @Failure 500 {object} handler.Response "服务器内部错误"
 // @Router /auth/files/content [post]
 func SaveFileContent(c *gin.Context) {
-	handler.Respond(c, http.StatusBadRequest, "演示版本禁止操作", nil)
-	return
-	//var req struct {
-	//	Path    string `json:"path"`
-	//	Content string `json:"content"`
-	//}
-	//
-	//if err := c.ShouldBindJSON(&req); err != nil {
-	//	handler.Respond(c, http.StatusBadRequest, "请求参数错误", nil)
-	//	return
-	//}
-	//
-	//if isProtectedPath(req.Path) {
-	//	handler.Respond(c, http.StatusForbidden, "无法修改受保护的文件", nil)
-	//	return
-	//}
-	//
-	//err := os.WriteFile(req.Path, []byte(req.Content), 0644)
-	//if err != nil {
-	//	handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
-	//	return
-	//}
-	//
-	//handler.Respond(c, http.StatusOK, "文件保存成功", nil)
+	var req struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误", nil)
+		return
+	}
+
+	filePath, err := policyOf(c).Resolve(req.Path, AccessWrite)
+	if errors.Is(err, ErrProtectedPath) {
+		handler.Respond(c, http.StatusForbidden, "无法修改受保护的文件", nil)
+		return
+	}
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+
+	err = os.WriteFile(filePath, []byte(req.Content), 0644)
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "文件保存成功", nil)
 }
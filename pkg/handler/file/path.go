@@ -0,0 +1,89 @@
+package file
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/safepath"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrProtectedPath 路径位于受保护的目录中
+	ErrProtectedPath = errors.New("拒绝访问")
+	// ErrRelativePath 文件管理只接受绝对路径
+	ErrRelativePath = errors.New("路径必须是绝对路径")
+)
+
+// ResolvePath 规范化文件管理使用的路径：清理 ../ 并解析已存在部分的符号链接，返回规范化后的路径。
+// 之后的文件操作都应使用返回的路径，避免再次经过符号链接
+func ResolvePath(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", ErrRelativePath
+	}
+	if withinProtected(filepath.Clean(path)) {
+		return "", ErrProtectedPath
+	}
+	canonical, err := safepath.Canonical(path)
+	if err != nil {
+		return "", err
+	}
+	if withinProtected(canonical) {
+		return "", ErrProtectedPath
+	}
+	return canonical, nil
+}
+
+// IsProtectedPath 检查路径是否受保护，路径本身或解析符号链接后的路径位于受保护的目录中都视为受保护，无法解析时同样视为受保护
+func IsProtectedPath(path string) bool {
+	if withinProtected(filepath.Clean(path)) {
+		return true
+	}
+	canonical, err := safepath.Canonical(path)
+	return err != nil || withinProtected(canonical)
+}
+
+// withinProtected 规范化后的路径是否等于受保护的路径或位于其下。面板的配置文件和数据库包含密钥和用户角色，
+// 对所有角色都受保护；回收站目录只能通过回收站接口访问，同样受保护
+func withinProtected(path string) bool {
+	for _, protected := range models.ProtectedDirs {
+		if safepath.Within(protected, path) {
+			return true
+		}
+	}
+	return withinRoots(panelFiles(), path)
+}
+
+// panelFiles 面板自身的配置文件、数据库及其 -wal、-shm、-journal 文件和回收站目录
+func panelFiles() []string {
+	files := []string{config.Path()}
+	if config.AppConfig == nil {
+		return files
+	}
+	if db := config.AppConfig.Database.Path; db != "" {
+		files = append(files, db, db+"-wal", db+"-shm", db+"-journal")
+	}
+	if config.AppConfig.Files.TrashDir != "" {
+		files = append(files, config.AppConfig.Files.TrashDir)
+	}
+	return files
+}
+
+// respondPathError 将路径解析错误转换为响应
+func respondPathError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrProtectedPath), errors.Is(err, ErrOutsideRoots), errors.Is(err, ErrReadOnlyPath), errors.Is(err, errContainsTrash):
+		handler.Respond(c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, ErrHiddenPath), os.IsNotExist(err):
+		handler.Respond(c, http.StatusNotFound, "文件不存在", nil)
+	case errors.Is(err, ErrRelativePath), errors.Is(err, ErrUnsafeArchiveEntry):
+		handler.Respond(c, http.StatusBadRequest, err.Error(), nil)
+	default:
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+	}
+}
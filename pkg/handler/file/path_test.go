@@ -0,0 +1,91 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+)
+
+func TestIsProtectedPath(t *testing.T) {
+	link := filepath.Join(t.TempDir(), "proc")
+	if err := os.Symlink("/proc", link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	for _, tc := range []struct {
+		path      string
+		protected bool
+	}{
+		{"/proc", true},
+		{"/proc/1/environ", true},
+		{"/tmp/../proc", true},
+		{"/etc/shadow", true},
+		{link, true},
+		{filepath.Join(link, "self"), true},
+		{"/process", false},
+		{"/tmp", false},
+	} {
+		if got := IsProtectedPath(tc.path); got != tc.protected {
+			t.Errorf("IsProtectedPath(%q) = %v, want %v", tc.path, got, tc.protected)
+		}
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	dir := t.TempDir()
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	if err := os.Symlink(real, filepath.Join(dir, "link")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	if got, err := ResolvePath(filepath.Join(dir, "link", "missing")); err != nil || got != filepath.Join(real, "missing") {
+		t.Errorf("ResolvePath = %q, %v", got, err)
+	}
+	if _, err := ResolvePath("relative/path"); !errors.Is(err, ErrRelativePath) {
+		t.Errorf("relative path: %v", err)
+	}
+	if _, err := ResolvePath("/tmp/../sys/kernel"); !errors.Is(err, ErrProtectedPath) {
+		t.Errorf("protected path: %v", err)
+	}
+}
+
+func TestPanelFilesAreProtected(t *testing.T) {
+	dir := t.TempDir()
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+	config.AppConfig = &config.Config{Database: config.DatabaseConfig{Path: filepath.Join(dir, "data.db")}}
+	if err := os.WriteFile(config.AppConfig.Database.Path, nil, 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	link := filepath.Join(dir, "link.db")
+	if err := os.Symlink(config.AppConfig.Database.Path, link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	for _, tc := range []struct {
+		path      string
+		protected bool
+	}{
+		{config.Path(), true},
+		{config.AppConfig.Database.Path, true},
+		{config.AppConfig.Database.Path + "-wal", true},
+		{config.AppConfig.Database.Path + "-shm", true},
+		{link, true},
+		{filepath.Join(dir, "other.db"), false},
+	} {
+		if got := IsProtectedPath(tc.path); got != tc.protected {
+			t.Errorf("IsProtectedPath(%q) = %v, want %v", tc.path, got, tc.protected)
+		}
+	}
+	// 管理员同样不能通过文件管理访问
+	if _, err := PolicyFor(models.RoleAdmin).Resolve(config.AppConfig.Database.Path, AccessRead); !errors.Is(err, ErrProtectedPath) {
+		t.Errorf("admin read of the panel database: %v", err)
+	}
+}
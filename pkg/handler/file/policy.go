@@ -0,0 +1,123 @@
+package file
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/safepath"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/middleware"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrOutsideRoots 路径不在策略允许访问的目录中
+	ErrOutsideRoots = errors.New("路径不在允许访问的目录中")
+	// ErrReadOnlyPath 路径位于只读目录中
+	ErrReadOnlyPath = errors.New("路径只读，不允许修改")
+	// ErrHiddenPath 路径被策略隐藏，与不存在的文件一样处理
+	ErrHiddenPath = errors.New("文件不存在")
+)
+
+// Access 对路径的操作类型
+type Access int
+
+const (
+	AccessRead  Access = iota // 浏览、查看和下载
+	AccessWrite               // 创建、修改、移动和删除
+)
+
+// Policy 文件管理的路径策略，零值不做额外限制，受保护的系统文件始终拒绝
+type Policy struct {
+	config.FilePolicy
+	private []string // 只有管理员可以访问的面板数据路径，与隐藏的路径一样处理
+}
+
+// PolicyFor 返回角色在当前配置下的策略，非管理员角色看不到面板的配置文件、数据库和备份目录
+func PolicyFor(role string) Policy {
+	if config.AppConfig == nil {
+		return Policy{}
+	}
+	policy := Policy{FilePolicy: config.AppConfig.Files.PolicyFor(role)}
+	if role != models.RoleAdmin {
+		policy.private = panelFiles()
+		if root := config.AppConfig.Backup.Root; root != "" {
+			policy.private = append(policy.private, root)
+		}
+	}
+	return policy
+}
+
+// policyOf 返回当前登录用户的策略
+func policyOf(c *gin.Context) Policy {
+	value, _ := c.Get("claims")
+	if claims, ok := value.(*middleware.Claims); ok {
+		return PolicyFor(claims.Role)
+	}
+	return PolicyFor("")
+}
+
+// Resolve 规范化路径并按策略检查，返回规范化后的路径。路径本身和解析符号链接后的路径都不能被隐藏，
+// 解析后的路径必须位于允许的目录中，写操作时两者都不能位于只读目录中
+func (p Policy) Resolve(path string, access Access) (string, error) {
+	resolved, err := ResolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	cleaned := filepath.Clean(path)
+
+	if p.IsHidden(cleaned) || p.IsHidden(resolved) {
+		return "", ErrHiddenPath
+	}
+	if len(p.AllowedRoots) > 0 && !withinRoots(p.AllowedRoots, resolved) {
+		return "", ErrOutsideRoots
+	}
+	if access == AccessWrite && (withinRoots(p.ReadOnlyRoots, cleaned) || withinRoots(p.ReadOnlyRoots, resolved)) {
+		return "", ErrReadOnlyPath
+	}
+	return resolved, nil
+}
+
+// ReadOnly 路径是否位于只读目录中
+func (p Policy) ReadOnly(path string) bool {
+	return withinRoots(p.ReadOnlyRoots, path)
+}
+
+// IsHidden 路径或其上级是否匹配隐藏的模式，或位于只有管理员可以访问的路径中
+func (p Policy) IsHidden(path string) bool {
+	if withinRoots(p.private, path) {
+		return true
+	}
+	if len(p.Hidden) == 0 {
+		return false
+	}
+	for dir := path; ; dir = filepath.Dir(dir) {
+		for _, pattern := range p.Hidden {
+			target := filepath.Base(dir)
+			if strings.Contains(pattern, "/") {
+				target = dir
+			}
+			if matched, _ := filepath.Match(pattern, target); matched {
+				return true
+			}
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return false
+		}
+	}
+}
+
+// withinRoots 路径是否位于某个目录中，目录按解析符号链接后的路径比较
+func withinRoots(roots []string, path string) bool {
+	for _, root := range roots {
+		if canonical, err := safepath.Canonical(root); err == nil && safepath.Within(canonical, path) {
+			return true
+		}
+		if safepath.Within(filepath.Clean(root), path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package file
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/middleware"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// setupPolicy 创建 allowed、allowed/readonly 和 outside 目录，并将它们配置为默认策略
+func setupPolicy(t *testing.T) (root string) {
+	t.Helper()
+	root = t.TempDir()
+	for _, dir := range []string{"allowed/readonly", "allowed/.git", "outside"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	for _, name := range []string{"allowed/a.txt", "allowed/.env", "allowed/readonly/b.txt", "outside/c.txt"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(root, "outside"), filepath.Join(root, "allowed", "escape")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+	config.AppConfig = &config.Config{Backup: config.BackupConfig{Root: filepath.Join(root, "allowed", "backups")}, Files: config.FilesConfig{
+		Default: config.FilePolicy{
+			AllowedRoots:  []string{filepath.Join(root, "allowed")},
+			ReadOnlyRoots: []string{filepath.Join(root, "allowed", "readonly")},
+			Hidden:        []string{".git", "*.env"},
+		},
+		Roles: map[string]config.FilePolicy{
+			models.RoleAdmin: {ReadOnlyRoots: []string{}},
+		},
+	}}
+	return root
+}
+
+func TestPolicyResolve(t *testing.T) {
+	root := setupPolicy(t)
+	allowed := filepath.Join(root, "allowed")
+
+	for _, tc := range []struct {
+		role   string
+		path   string
+		access Access
+		want   error
+	}{
+		{models.RoleOperator, filepath.Join(allowed, "a.txt"), AccessWrite, nil},
+		{models.RoleOperator, filepath.Join(allowed, "new", "file"), AccessWrite, nil},
+		{models.RoleOperator, filepath.Join(allowed, "readonly", "b.txt"), AccessRead, nil},
+		{models.RoleOperator, filepath.Join(allowed, "readonly", "b.txt"), AccessWrite, ErrReadOnlyPath},
+		{models.RoleAdmin, filepath.Join(allowed, "readonly", "b.txt"), AccessWrite, nil},
+		{models.RoleOperator, filepath.Join(root, "outside", "c.txt"), AccessRead, ErrOutsideRoots},
+		{models.RoleOperator, filepath.Join(allowed, "..", "outside", "c.txt"), AccessRead, ErrOutsideRoots},
+		{models.RoleOperator, filepath.Join(allowed, "escape", "c.txt"), AccessRead, ErrOutsideRoots},
+		{models.RoleOperator, filepath.Join(allowed, ".env"), AccessRead, ErrHiddenPath},
+		{models.RoleOperator, filepath.Join(allowed, ".git", "config"), AccessRead, ErrHiddenPath},
+		{models.RoleAdmin, filepath.Join(allowed, ".git"), AccessRead, ErrHiddenPath},
+		{models.RoleOperator, "/proc/self", AccessRead, ErrProtectedPath},
+		{models.RoleOperator, filepath.Join(allowed, "backups", "app.sql"), AccessRead, ErrHiddenPath},
+		{models.RoleReadOnly, filepath.Join(allowed, "backups"), AccessRead, ErrHiddenPath},
+		{models.RoleAdmin, filepath.Join(allowed, "backups", "app.sql"), AccessRead, nil},
+	} {
+		_, err := PolicyFor(tc.role).Resolve(tc.path, tc.access)
+		if !errors.Is(err, tc.want) || (tc.want == nil && err != nil) {
+			t.Errorf("%s: Resolve(%q, %d) = %v, want %v", tc.role, tc.path, tc.access, err, tc.want)
+		}
+	}
+}
+
+func TestPolicyZeroValueAllowsAll(t *testing.T) {
+	if _, err := (Policy{}).Resolve(t.TempDir(), AccessWrite); err != nil {
+		t.Fatalf("zero policy: %v", err)
+	}
+}
+
+// serveFiles 以指定角色调用文件管理接口
+func serveFiles(t *testing.T, role string, method, target string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", &middleware.Claims{Username: role, Role: role})
+	})
+	r.GET("/files", ListFiles)
+	r.POST("/files/content", SaveFileContent)
+	r.DELETE("/files", DeleteFile)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestFileHandlersApplyPolicy(t *testing.T) {
+	root := setupPolicy(t)
+	allowed := filepath.Join(root, "allowed")
+
+	w := serveFiles(t, models.RoleOperator, http.MethodGet, "/files?path="+url.QueryEscape(allowed), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: %d %s", w.Code, w.Body.String())
+	}
+	var listing struct {
+		Data struct {
+			Files    []models.FileInfo `json:"files"`
+			ReadOnly bool              `json:"readOnly"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &listing); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	for _, file := range listing.Data.Files {
+		if file.Name == ".env" || file.Name == ".git" {
+			t.Errorf("hidden entry %s listed", file.Name)
+		}
+	}
+	if len(listing.Data.Files) != 3 || listing.Data.ReadOnly {
+		t.Errorf("listing = %+v", listing.Data)
+	}
+
+	w = serveFiles(t, models.RoleOperator, http.MethodGet, "/files?path="+url.QueryEscape(filepath.Join(allowed, "readonly")), "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"readOnly":true`) {
+		t.Errorf("read-only listing: %d %s", w.Code, w.Body.String())
+	}
+
+	readOnlyFile := filepath.Join(allowed, "readonly", "b.txt")
+	body, _ := json.Marshal(map[string]string{"path": readOnlyFile, "content": "changed"})
+	if w := serveFiles(t, models.RoleOperator, http.MethodPost, "/files/content", string(body)); w.Code != http.StatusForbidden {
+		t.Errorf("operator save read-only: %d %s", w.Code, w.Body.String())
+	}
+	if w := serveFiles(t, models.RoleAdmin, http.MethodPost, "/files/content", string(body)); w.Code != http.StatusOK {
+		t.Errorf("admin save read-only: %d %s", w.Code, w.Body.String())
+	}
+
+	outside := filepath.Join(allowed, "escape", "c.txt")
+	if w := serveFiles(t, models.RoleAdmin, http.MethodDelete, "/files?path="+url.QueryEscape(outside), ""); w.Code != http.StatusForbidden {
+		t.Errorf("delete through escaping symlink: %d %s", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(root, "outside", "c.txt")); err != nil {
+		t.Errorf("outside file removed: %v", err)
+	}
+	if w := serveFiles(t, models.RoleOperator, http.MethodDelete, "/files?path="+url.QueryEscape(filepath.Join(allowed, ".env")), ""); w.Code != http.StatusNotFound {
+		t.Errorf("delete hidden: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestExtractAppliesPolicy(t *testing.T) {
+	root := setupPolicy(t)
+	allowed := filepath.Join(root, "allowed")
+	policy := PolicyFor(models.RoleOperator)
+
+	for _, tc := range []struct {
+		name string
+		want error
+	}{
+		{"readonly/evil", ErrReadOnlyPath},
+		{"sub/.git/hooks/pre-commit", ErrHiddenPath},
+	} {
+		err := extractTar(writeTar(t, []tarEntry{{name: tc.name, typeflag: tar.TypeReg, body: "x"}}), allowed, policy)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("extract %s = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(allowed, "readonly", "evil")); err == nil {
+		t.Error("entry written into read-only directory")
+	}
+}
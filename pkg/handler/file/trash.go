@@ -0,0 +1,374 @@
+package file
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/safepath"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// trashCleanInterval 清除过期回收站文件的间隔
+const trashCleanInterval = time.Hour
+
+// 还原时原路径已存在的处理方式
+const (
+	RestoreRename    = "rename"    // 还原为带序号的新名称
+	RestoreOverwrite = "overwrite" // 将已存在的文件移入回收站后还原
+)
+
+var (
+	errTrashItemNotFound = errors.New("回收站中不存在该文件")
+	// errContainsTrash 删除的目录包含回收站目录
+	errContainsTrash = errors.New("不能删除包含回收站的目录")
+)
+
+// RestoreTrashRequest 还原回收站文件请求
+type RestoreTrashRequest struct {
+	// Conflict 原路径已存在时的处理方式，为空时返回 409
+	Conflict string `json:"conflict" binding:"omitempty,oneof=rename overwrite" example:"rename"`
+}
+
+func trashDir() string {
+	return config.AppConfig.Files.TrashDir
+}
+
+func trashRetention() time.Duration {
+	return time.Duration(config.AppConfig.Files.TrashRetentionDays) * 24 * time.Hour
+}
+
+func storedPath(item models.TrashItem) string {
+	return filepath.Join(trashDir(), item.StoredName)
+}
+
+// moveToTrash 将文件或目录移入回收站，并记录原路径和删除人
+func moveToTrash(path, username string) (*models.TrashItem, error) {
+	if trash, err := safepath.Canonical(trashDir()); err != nil || safepath.Within(path, trash) {
+		return nil, errContainsTrash
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(trashDir(), 0700); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+
+	// 先写记录再移动，进程中途退出时最多留下一条找不到内容的记录，不会有无法找回的文件
+	item := &models.TrashItem{
+		Name:         filepath.Base(path),
+		OriginalPath: path,
+		StoredName:   hex.EncodeToString(buf),
+		IsDir:        info.IsDir(),
+		Size:         treeSize(path, info),
+		DeletedBy:    username,
+		DeletedAt:    time.Now(),
+	}
+	if err := database.DbConn.Create(item).Error; err != nil {
+		return nil, err
+	}
+	if err := movePath(path, storedPath(*item)); err != nil {
+		database.DbConn.Delete(item)
+		return nil, err
+	}
+	return item, nil
+}
+
+// treeSize 返回文件大小，目录为其中所有文件的大小之和
+func treeSize(path string, info fs.FileInfo) int64 {
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// purgeTrashItem 永久删除回收站中的文件及其记录
+func purgeTrashItem(item models.TrashItem) error {
+	if err := os.RemoveAll(storedPath(item)); err != nil {
+		return err
+	}
+	return database.DbConn.Delete(&item).Error
+}
+
+// trashItemFor 读取回收站文件，当前用户的策略需要允许对原路径的操作
+func trashItemFor(c *gin.Context, access Access) (models.TrashItem, error) {
+	var item models.TrashItem
+	if err := database.DbConn.First(&item, c.Param("id")).Error; err != nil {
+		return item, errTrashItemNotFound
+	}
+	if _, err := policyOf(c).Resolve(item.OriginalPath, access); err != nil {
+		if errors.Is(err, ErrHiddenPath) {
+			return item, errTrashItemNotFound
+		}
+		return item, err
+	}
+	return item, nil
+}
+
+// respondTrashError 将回收站错误转换为响应
+func respondTrashError(c *gin.Context, err error) {
+	if errors.Is(err, errTrashItemNotFound) {
+		handler.Respond(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+	respondPathError(c, err)
+}
+
+// availablePath 返回与 path 同目录且不存在的名称，如 a (1).txt
+func availablePath(path string, isDir bool) string {
+	dir, base := filepath.Split(path)
+	ext := ""
+	if !isDir {
+		ext = filepath.Ext(base)
+		// 以点开头的文件（如 .env）整体视为名称
+		if ext == base {
+			ext = ""
+		}
+	}
+	name := strings.TrimSuffix(base, ext)
+	for n := 1; ; n++ {
+		candidate := filepath.Join(dir, fmt.Sprintf("%s (%d)%s", name, n, ext))
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// GetTrashItems 获取回收站文件列表
+// @Summary 获取回收站文件列表
+// @Description 按删除时间倒序返回回收站中的文件，只包含当前用户的策略允许访问的原路径。超过保留天数的文件会被自动清除
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=object{items=[]models.TrashItem,retentionDays=int}} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/files/trash [get]
+func GetTrashItems(c *gin.Context) {
+	var items []models.TrashItem
+	if err := database.DbConn.Order("deleted_at DESC").Find(&items).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	policy := policyOf(c)
+	visible := make([]models.TrashItem, 0, len(items))
+	for _, item := range items {
+		if _, err := policy.Resolve(item.OriginalPath, AccessRead); err == nil {
+			visible = append(visible, item)
+		}
+	}
+
+	handler.Respond(c, http.StatusOK, nil, gin.H{
+		"items":         visible,
+		"retentionDays": config.AppConfig.Files.TrashRetentionDays,
+	})
+}
+
+// RestoreTrashItem 还原回收站文件
+// @Summary 还原回收站文件
+// @Description 将文件还原到原路径，原目录已不存在时重新创建。原路径已存在时默认返回 409，可选择还原为带序号的新名称，或将已存在的文件移入回收站后覆盖
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "回收站文件ID"
+// @Param request body RestoreTrashRequest false "原路径已存在时的处理方式"
+// @Success 200 {object} handler.Response{data=object{path=string}} "还原成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "拒绝访问"
+// @Failure 404 {object} handler.Response "回收站中不存在该文件"
+// @Failure 409 {object} handler.Response{data=object{path=string}} "原路径已存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/files/trash/{id}/restore [post]
+func RestoreTrashItem(c *gin.Context) {
+	var req RestoreTrashRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	item, err := trashItemFor(c, AccessWrite)
+	if err != nil {
+		respondTrashError(c, err)
+		return
+	}
+	if _, err := os.Lstat(storedPath(item)); err != nil {
+		handler.Respond(c, http.StatusNotFound, "回收站中的文件已丢失", nil)
+		return
+	}
+
+	policy := policyOf(c)
+	target, err := policy.Resolve(item.OriginalPath, AccessWrite)
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+
+	if _, err := os.Lstat(target); err == nil {
+		switch req.Conflict {
+		case RestoreRename:
+			if target, err = policy.Resolve(availablePath(target, item.IsDir), AccessWrite); err != nil {
+				respondPathError(c, err)
+				return
+			}
+		case RestoreOverwrite:
+			if _, err := moveToTrash(target, c.GetString("username")); err != nil {
+				handler.Respond(c, http.StatusInternalServerError, "移除已存在的文件失败: "+err.Error(), nil)
+				return
+			}
+		default:
+			handler.Respond(c, http.StatusConflict, "原路径已存在", gin.H{"path": target})
+			return
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	if err := movePath(storedPath(item), target); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "还原失败: "+err.Error(), nil)
+		return
+	}
+	if err := database.DbConn.Delete(&item).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, "还原成功", gin.H{"path": target})
+}
+
+// PurgeTrashItem 永久删除回收站文件
+// @Summary 永久删除回收站文件
+// @Description 从回收站中永久删除指定文件，无法恢复
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "回收站文件ID"
+// @Success 200 {object} handler.Response "删除成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "拒绝访问"
+// @Failure 404 {object} handler.Response "回收站中不存在该文件"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/files/trash/{id} [delete]
+func PurgeTrashItem(c *gin.Context) {
+	item, err := trashItemFor(c, AccessWrite)
+	if err != nil {
+		respondTrashError(c, err)
+		return
+	}
+	if err := purgeTrashItem(item); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	handler.Respond(c, http.StatusOK, "删除成功", nil)
+}
+
+// EmptyTrash 清空回收站
+// @Summary 清空回收站
+// @Description 永久删除回收站中当前用户的策略允许修改的所有文件
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=object{purged=int}} "清空成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/files/trash [delete]
+func EmptyTrash(c *gin.Context) {
+	var items []models.TrashItem
+	if err := database.DbConn.Find(&items).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	policy := policyOf(c)
+	purged := 0
+	for _, item := range items {
+		if _, err := policy.Resolve(item.OriginalPath, AccessWrite); err != nil {
+			continue
+		}
+		if err := purgeTrashItem(item); err != nil {
+			handler.Respond(c, http.StatusInternalServerError, err.Error(), gin.H{"purged": purged})
+			return
+		}
+		purged++
+	}
+	handler.Respond(c, http.StatusOK, "清空成功", gin.H{"purged": purged})
+}
+
+// InitTrash 清除超过保留天数的回收站文件并定期继续清除
+func InitTrash() error {
+	if err := os.MkdirAll(trashDir(), 0700); err != nil {
+		return err
+	}
+	purgeExpiredTrash(time.Now())
+
+	go func() {
+		for now := range time.Tick(trashCleanInterval) {
+			purgeExpiredTrash(now)
+		}
+	}()
+	return nil
+}
+
+// purgeExpiredTrash 永久删除超过保留天数的回收站文件，以及回收站目录中没有记录的残留内容
+func purgeExpiredTrash(now time.Time) {
+	var expired []models.TrashItem
+	if err := database.DbConn.Where("deleted_at < ?", now.Add(-trashRetention())).Find(&expired).Error; err != nil {
+		log.Printf("清除过期回收站文件失败: %v", err)
+		return
+	}
+	for _, item := range expired {
+		if err := purgeTrashItem(item); err != nil {
+			log.Printf("清除回收站文件 %s 失败: %v", item.OriginalPath, err)
+		}
+	}
+
+	entries, err := os.ReadDir(trashDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < trashRetention() {
+			continue
+		}
+		var count int64
+		database.DbConn.Model(&models.TrashItem{}).Where("stored_name = ?", entry.Name()).Count(&count)
+		if count == 0 {
+			os.RemoveAll(filepath.Join(trashDir(), entry.Name()))
+		}
+	}
+}
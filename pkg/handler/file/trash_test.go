@@ -0,0 +1,244 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTrash 使用临时目录作为回收站和数据库，返回接口和存放测试文件的目录
+func setupTrash(t *testing.T) (*gin.Engine, string) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "panel.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.TrashItem{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	original := database.DbConn
+	database.DbConn = db
+	t.Cleanup(func() { database.DbConn = original })
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+	config.AppConfig = &config.Config{Files: config.FilesConfig{TrashDir: t.TempDir(), TrashRetentionDays: 1}}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("username", "ops")
+	})
+	r.DELETE("/files", DeleteFile)
+	r.GET("/trash", GetTrashItems)
+	r.POST("/trash/:id/restore", RestoreTrashItem)
+	r.DELETE("/trash/:id", PurgeTrashItem)
+	r.DELETE("/trash", EmptyTrash)
+	return r, t.TempDir()
+}
+
+func doTrash(t *testing.T, r *gin.Engine, method, target, body string) (*httptest.ResponseRecorder, json.RawMessage) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response.Data
+}
+
+func trashFile(t *testing.T, r *gin.Engine, path string) models.TrashItem {
+	t.Helper()
+	w, data := doTrash(t, r, http.MethodDelete, "/files?path="+url.QueryEscape(path), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete %s: %d %s", path, w.Code, w.Body.String())
+	}
+	var item models.TrashItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// 存放名称不在响应中返回，从数据库中读取
+	if err := database.DbConn.First(&item, item.ID).Error; err != nil {
+		t.Fatalf("load item: %v", err)
+	}
+	return item
+}
+
+func TestDeleteMovesToTrashAndRestores(t *testing.T) {
+	r, dir := setupTrash(t)
+	site := filepath.Join(dir, "site")
+	if err := os.MkdirAll(filepath.Join(site, "css"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(site, "css", "a.css"), []byte("body{}"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	item := trashFile(t, r, site)
+	if _, err := os.Stat(site); !os.IsNotExist(err) {
+		t.Fatalf("deleted directory still exists: %v", err)
+	}
+	if !item.IsDir || item.Size != 6 || item.DeletedBy != "ops" || item.OriginalPath != site {
+		t.Errorf("item = %+v", item)
+	}
+
+	w, data := doTrash(t, r, http.MethodGet, "/trash", "")
+	var listing struct {
+		Items []models.TrashItem `json:"items"`
+	}
+	if err := json.Unmarshal(data, &listing); err != nil || w.Code != http.StatusOK || len(listing.Items) != 1 {
+		t.Fatalf("list: %d %s", w.Code, w.Body.String())
+	}
+
+	// 原目录的上级已被删除时重新创建
+	if err := os.Rename(dir, dir+"-moved"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir + "-moved") })
+	restore := "/trash/" + strconv.Itoa(int(item.ID)) + "/restore"
+	if w, _ := doTrash(t, r, http.MethodPost, restore, ""); w.Code != http.StatusOK {
+		t.Fatalf("restore: %d %s", w.Code, w.Body.String())
+	}
+	if data, err := os.ReadFile(filepath.Join(site, "css", "a.css")); err != nil || string(data) != "body{}" {
+		t.Fatalf("restored content = %q, %v", data, err)
+	}
+	if w, _ := doTrash(t, r, http.MethodPost, restore, ""); w.Code != http.StatusNotFound {
+		t.Errorf("restore twice: %d", w.Code)
+	}
+}
+
+func TestRestoreConflict(t *testing.T) {
+	r, dir := setupTrash(t)
+	path := filepath.Join(dir, "a.txt")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	write("first")
+	first := trashFile(t, r, path)
+	write("second")
+	second := trashFile(t, r, path)
+	write("current")
+
+	restore := func(item models.TrashItem, body string) *httptest.ResponseRecorder {
+		w, _ := doTrash(t, r, http.MethodPost, "/trash/"+strconv.Itoa(int(item.ID))+"/restore", body)
+		return w
+	}
+	if w := restore(first, ""); w.Code != http.StatusConflict {
+		t.Fatalf("conflict: %d %s", w.Code, w.Body.String())
+	}
+	if w := restore(first, `{"conflict":"rename"}`); w.Code != http.StatusOK {
+		t.Fatalf("rename: %d %s", w.Code, w.Body.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a (1).txt")); string(data) != "first" {
+		t.Errorf("renamed restore = %q", data)
+	}
+
+	if w := restore(second, `{"conflict":"overwrite"}`); w.Code != http.StatusOK {
+		t.Fatalf("overwrite: %d %s", w.Code, w.Body.String())
+	}
+	if data, _ := os.ReadFile(path); string(data) != "second" {
+		t.Errorf("overwritten restore = %q", data)
+	}
+	// 被覆盖的文件进入回收站
+	var items []models.TrashItem
+	database.DbConn.Find(&items)
+	if len(items) != 1 {
+		t.Fatalf("trash items = %+v", items)
+	}
+	if data, _ := os.ReadFile(storedPath(items[0])); string(data) != "current" {
+		t.Errorf("overwritten file in trash = %q", data)
+	}
+	if w := restore(second, `{"conflict":"merge"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid conflict: %d", w.Code)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	r, dir := setupTrash(t)
+	var items []models.TrashItem
+	for _, name := range []string{"a", "b", "c"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		items = append(items, trashFile(t, r, path))
+	}
+
+	if w, _ := doTrash(t, r, http.MethodDelete, "/trash/"+strconv.Itoa(int(items[0].ID)), ""); w.Code != http.StatusOK {
+		t.Fatalf("purge: %d %s", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(storedPath(items[0])); !os.IsNotExist(err) {
+		t.Errorf("purged content still exists: %v", err)
+	}
+
+	w, data := doTrash(t, r, http.MethodDelete, "/trash", "")
+	if w.Code != http.StatusOK || string(data) != `{"purged":2}` {
+		t.Fatalf("empty: %d %s", w.Code, w.Body.String())
+	}
+	if entries, _ := os.ReadDir(trashDir()); len(entries) != 0 {
+		t.Errorf("trash directory not empty: %d entries", len(entries))
+	}
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	r, dir := setupTrash(t)
+	for _, name := range []string{"old", "new"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	old := trashFile(t, r, filepath.Join(dir, "old"))
+	fresh := trashFile(t, r, filepath.Join(dir, "new"))
+	database.DbConn.Model(&old).Update("deleted_at", time.Now().Add(-48*time.Hour))
+
+	orphan := filepath.Join(trashDir(), "orphan")
+	if err := os.WriteFile(orphan, nil, 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	stale := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(orphan, stale, stale)
+
+	purgeExpiredTrash(time.Now())
+
+	var remaining []models.TrashItem
+	database.DbConn.Find(&remaining)
+	if len(remaining) != 1 || remaining[0].ID != fresh.ID {
+		t.Fatalf("remaining = %+v", remaining)
+	}
+	for path, exists := range map[string]bool{storedPath(old): false, orphan: false, storedPath(fresh): true} {
+		if _, err := os.Stat(path); (err == nil) != exists {
+			t.Errorf("%s exists = %v, want %v", filepath.Base(path), err == nil, exists)
+		}
+	}
+}
+
+func TestTrashDirectoryIsProtected(t *testing.T) {
+	r, _ := setupTrash(t)
+	if _, err := ResolvePath(filepath.Join(trashDir(), "x")); !errors.Is(err, ErrProtectedPath) {
+		t.Errorf("trash path: %v", err)
+	}
+	if w, _ := doTrash(t, r, http.MethodDelete, "/files?path="+url.QueryEscape(filepath.Dir(trashDir())), ""); w.Code != http.StatusForbidden {
+		t.Errorf("delete parent of trash: %d %s", w.Code, w.Body.String())
+	}
+}
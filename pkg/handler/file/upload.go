@@ -0,0 +1,555 @@
+package file
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// uploadCleanInterval 清理过期上传的间隔
+const uploadCleanInterval = time.Hour
+
+var (
+	// uploadIDPattern 上传 ID 为 32 位十六进制，防止拼出临时目录外的路径
+	uploadIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	// sha256Pattern SHA-256 校验值的十六进制格式
+	sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	// uploadLocks 同一上传的分块和完成操作串行执行，只保留正在使用的锁
+	uploadLocks   = make(map[string]*uploadLock)
+	uploadLocksMu sync.Mutex
+
+	errUploadNotFound = errors.New("上传不存在或已过期")
+)
+
+// CreateUploadRequest 创建分块上传请求
+type CreateUploadRequest struct {
+	Path string `json:"path" binding:"required" example:"/home/www"`     // 目标目录
+	Name string `json:"name" binding:"required" example:"backup.tar.gz"` // 文件名
+	Size int64  `json:"size" binding:"min=0" example:"1073741824"`       // 文件总大小
+	// SHA256 文件的 SHA-256 校验值，可在完成上传时再提供
+	SHA256    string `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Overwrite bool   `json:"overwrite"` // 目标文件已存在时是否覆盖
+}
+
+// CompleteUploadRequest 完成分块上传请求
+type CompleteUploadRequest struct {
+	SHA256 string `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// UploadStatus 分块上传的状态
+type UploadStatus struct {
+	ID        string    `json:"id"`
+	Target    string    `json:"target"`    // 完成后写入的文件
+	Size      int64     `json:"size"`      // 文件总大小
+	Offset    int64     `json:"offset"`    // 已接收的字节数，下一个分块从这里开始
+	ChunkSize int64     `json:"chunkSize"` // 单个分块的最大大小
+	ExpiresAt time.Time `json:"expiresAt"` // 在此之前没有新的分块时上传会被清理
+}
+
+// uploadMeta 与未完成的文件一起保存在临时目录中的上传信息
+type uploadMeta struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Target    string    `json:"target"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256,omitempty"`
+	Overwrite bool      `json:"overwrite"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func uploadDir() string {
+	return config.AppConfig.Files.UploadDir
+}
+
+func uploadExpire() time.Duration {
+	return time.Duration(config.AppConfig.Files.UploadExpireHours) * time.Hour
+}
+
+func uploadMaxChunk() int64 {
+	return int64(config.AppConfig.Files.UploadMaxChunkMB) << 20
+}
+
+func metaPath(id string) string {
+	return filepath.Join(uploadDir(), id+".json")
+}
+
+func partPath(id string) string {
+	return filepath.Join(uploadDir(), id+".part")
+}
+
+// uploadLock 上传的锁，refs 为持有和等待锁的请求数，为 0 时从 uploadLocks 中删除
+type uploadLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lockUpload 锁定上传，返回解锁函数。id 需要先通过 uploadIDPattern 校验
+func lockUpload(id string) func() {
+	uploadLocksMu.Lock()
+	lock, ok := uploadLocks[id]
+	if !ok {
+		lock = &uploadLock{}
+		uploadLocks[id] = lock
+	}
+	lock.refs++
+	uploadLocksMu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		uploadLocksMu.Lock()
+		if lock.refs--; lock.refs == 0 {
+			delete(uploadLocks, id)
+		}
+		uploadLocksMu.Unlock()
+	}
+}
+
+// lockUploadID 校验上传 ID 后锁定上传，ID 格式不正确时与不存在一样处理，不创建锁
+func lockUploadID(c *gin.Context) (string, func(), bool) {
+	id := c.Param("id")
+	if !uploadIDPattern.MatchString(id) {
+		respondUploadError(c, errUploadNotFound)
+		return "", nil, false
+	}
+	return id, lockUpload(id), true
+}
+
+// loadUpload 读取当前用户的上传，其他用户的上传与不存在一样处理
+func loadUpload(c *gin.Context, id string) (*uploadMeta, error) {
+	if !uploadIDPattern.MatchString(id) {
+		return nil, errUploadNotFound
+	}
+	data, err := os.ReadFile(metaPath(id))
+	if err != nil {
+		return nil, errUploadNotFound
+	}
+	var meta uploadMeta
+	if err := json.Unmarshal(data, &meta); err != nil || meta.Username != c.GetString("username") {
+		return nil, errUploadNotFound
+	}
+	return &meta, nil
+}
+
+// status 根据未完成文件的大小和修改时间计算上传状态
+func (m *uploadMeta) status() (UploadStatus, error) {
+	info, err := os.Stat(partPath(m.ID))
+	if err != nil {
+		return UploadStatus{}, errUploadNotFound
+	}
+	return UploadStatus{
+		ID:        m.ID,
+		Target:    m.Target,
+		Size:      m.Size,
+		Offset:    info.Size(),
+		ChunkSize: uploadMaxChunk(),
+		ExpiresAt: info.ModTime().Add(uploadExpire()),
+	}, nil
+}
+
+// removeUpload 删除上传的临时文件
+func removeUpload(id string) {
+	os.Remove(partPath(id))
+	os.Remove(metaPath(id))
+}
+
+// respondUploadError 将上传错误转换为响应
+func respondUploadError(c *gin.Context, err error) {
+	if errors.Is(err, errUploadNotFound) {
+		handler.Respond(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+	respondPathError(c, err)
+}
+
+// uploadTarget 按策略检查上传的目标文件，不覆盖时目标不能已存在
+func uploadTarget(c *gin.Context, path string, overwrite bool) (string, error) {
+	target, err := policyOf(c).Resolve(path, AccessWrite)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Lstat(target); err == nil && (!overwrite || info.IsDir()) {
+		return "", os.ErrExist
+	}
+	return target, nil
+}
+
+// CreateUpload 创建分块上传
+// @Summary 创建分块上传
+// @Description 为大文件创建可断点续传的上传。之后按 offset 依次上传分块，中断后可查询已接收的字节数继续上传，全部接收后校验 SHA-256 并写入目标文件。超过过期时间没有新分块的上传会被清理
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateUploadRequest true "目标目录、文件名、大小和校验值"
+// @Success 200 {object} handler.Response{data=UploadStatus} "创建成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "拒绝访问"
+// @Failure 409 {object} handler.Response "目标文件已存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/files/uploads [post]
+func CreateUpload(c *gin.Context) {
+	var req CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	name := filepath.Base(req.Name)
+	if name != req.Name || name == "." || name == ".." {
+		handler.Respond(c, http.StatusBadRequest, "文件名不合法", nil)
+		return
+	}
+	req.SHA256 = strings.ToLower(req.SHA256)
+	if req.SHA256 != "" && !sha256Pattern.MatchString(req.SHA256) {
+		handler.Respond(c, http.StatusBadRequest, "SHA-256 校验值格式错误", nil)
+		return
+	}
+
+	target, err := uploadTarget(c, filepath.Join(req.Path, name), req.Overwrite)
+	if errors.Is(err, os.ErrExist) {
+		handler.Respond(c, http.StatusConflict, "目标文件已存在", nil)
+		return
+	}
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+
+	if err := os.MkdirAll(uploadDir(), 0700); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	meta := uploadMeta{
+		ID:        hex.EncodeToString(buf),
+		Username:  c.GetString("username"),
+		Target:    target,
+		Size:      req.Size,
+		SHA256:    req.SHA256,
+		Overwrite: req.Overwrite,
+		CreatedAt: time.Now(),
+	}
+	// 先创建未完成的文件再写入上传信息，清理时只要文件未过期就不会删除正在创建的上传
+	part, err := os.OpenFile(partPath(meta.ID), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err == nil {
+		err = part.Close()
+	}
+	var data []byte
+	if err == nil {
+		data, err = json.Marshal(meta)
+	}
+	if err == nil {
+		err = os.WriteFile(metaPath(meta.ID), data, 0600)
+	}
+	if err != nil {
+		removeUpload(meta.ID)
+		handler.Respond(c, http.StatusInternalServerError, "创建上传失败: "+err.Error(), nil)
+		return
+	}
+
+	status, err := meta.status()
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+	handler.Respond(c, http.StatusOK, nil, status)
+}
+
+// GetUpload 查询分块上传状态
+// @Summary 查询分块上传状态
+// @Description 返回已接收的字节数，断线后从该位置继续上传
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "上传ID"
+// @Success 200 {object} handler.Response{data=UploadStatus} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "上传不存在或已过期"
+// @Router /auth/files/uploads/{id} [get]
+func GetUpload(c *gin.Context) {
+	meta, err := loadUpload(c, c.Param("id"))
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+	status, err := meta.status()
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+	handler.Respond(c, http.StatusOK, nil, status)
+}
+
+// UploadChunk 上传分块
+// @Summary 上传分块
+// @Description 请求体为分块的原始内容，offset 必须等于已接收的字节数，否则返回 409 和当前状态。连接中断时已写入的部分会保留，查询状态后从新的位置继续
+// @Tags 文件管理
+// @Accept application/octet-stream
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "上传ID"
+// @Param offset query int true "分块在文件中的起始位置"
+// @Success 200 {object} handler.Response{data=UploadStatus} "上传成功"
+// @Failure 400 {object} handler.Response "请求参数错误或传输中断"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "上传不存在或已过期"
+// @Failure 409 {object} handler.Response{data=UploadStatus} "offset 与已接收的字节数不一致"
+// @Failure 413 {object} handler.Response "分块超过大小限制或超出文件大小"
+// @Router /auth/files/uploads/{id} [patch]
+func UploadChunk(c *gin.Context) {
+	offset, err := strconv.ParseInt(c.Query("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		handler.Respond(c, http.StatusBadRequest, "offset 参数错误", nil)
+		return
+	}
+
+	id, unlock, ok := lockUploadID(c)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	meta, err := loadUpload(c, id)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+	status, err := meta.status()
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+	if offset != status.Offset {
+		handler.Respond(c, http.StatusConflict, "offset 与已接收的字节数不一致", status)
+		return
+	}
+
+	limit := min(meta.Size-offset, uploadMaxChunk())
+	part, err := os.OpenFile(partPath(id), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	defer part.Close()
+
+	_, copyErr := io.Copy(part, io.LimitReader(c.Request.Body, limit))
+	if copyErr == nil {
+		// 分块超过限制时整体丢弃，避免写入不属于该文件的内容
+		if n, _ := c.Request.Body.Read(make([]byte, 1)); n > 0 {
+			if err := part.Truncate(offset); err != nil {
+				handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+				return
+			}
+			handler.Respond(c, http.StatusRequestEntityTooLarge, "分块超过大小限制或超出文件大小", nil)
+			return
+		}
+	}
+
+	status, err = meta.status()
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+	if copyErr != nil {
+		handler.Respond(c, http.StatusBadRequest, "传输中断: "+copyErr.Error(), status)
+		return
+	}
+	handler.Respond(c, http.StatusOK, nil, status)
+}
+
+// CompleteUpload 完成分块上传
+// @Summary 完成分块上传
+// @Description 全部分块接收后校验 SHA-256，通过后写入目标文件。校验失败时上传被删除，需要重新上传
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "上传ID"
+// @Param request body CompleteUploadRequest false "SHA-256 校验值，创建时已提供可省略"
+// @Success 200 {object} handler.Response{data=object{path=string,size=int,sha256=string}} "上传完成"
+// @Failure 400 {object} handler.Response "缺少校验值或校验失败"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "拒绝访问"
+// @Failure 404 {object} handler.Response "上传不存在或已过期"
+// @Failure 409 {object} handler.Response "上传未完成或目标文件已存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/files/uploads/{id}/complete [post]
+func CompleteUpload(c *gin.Context) {
+	var req CompleteUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	id, unlock, ok := lockUploadID(c)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	meta, err := loadUpload(c, id)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+	status, err := meta.status()
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+	if status.Offset != meta.Size {
+		handler.Respond(c, http.StatusConflict, "上传未完成", status)
+		return
+	}
+
+	expected := strings.ToLower(req.SHA256)
+	if expected == "" {
+		expected = meta.SHA256
+	}
+	if !sha256Pattern.MatchString(expected) || (meta.SHA256 != "" && expected != meta.SHA256) {
+		handler.Respond(c, http.StatusBadRequest, "缺少或不一致的 SHA-256 校验值", nil)
+		return
+	}
+
+	actual, err := fileSHA256(partPath(id))
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	if actual != expected {
+		removeUpload(id)
+		handler.Respond(c, http.StatusBadRequest, "文件校验失败，请重新上传", nil)
+		return
+	}
+
+	// 上传期间策略或目标可能已变化，写入前重新检查
+	target, err := uploadTarget(c, meta.Target, meta.Overwrite)
+	if errors.Is(err, os.ErrExist) {
+		handler.Respond(c, http.StatusConflict, "目标文件已存在", nil)
+		return
+	}
+	if err != nil {
+		respondPathError(c, err)
+		return
+	}
+
+	if err := os.Chmod(partPath(id), 0644); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	if err := movePath(partPath(id), target); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "写入目标文件失败: "+err.Error(), nil)
+		return
+	}
+	removeUpload(id)
+
+	handler.Respond(c, http.StatusOK, "上传完成", gin.H{
+		"path":   target,
+		"size":   meta.Size,
+		"sha256": actual,
+	})
+}
+
+// CancelUpload 取消分块上传
+// @Summary 取消分块上传
+// @Description 删除未完成的上传及已接收的内容
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "上传ID"
+// @Success 200 {object} handler.Response "已取消"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 404 {object} handler.Response "上传不存在或已过期"
+// @Router /auth/files/uploads/{id} [delete]
+func CancelUpload(c *gin.Context) {
+	id, unlock, ok := lockUploadID(c)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	if _, err := loadUpload(c, id); err != nil {
+		respondUploadError(c, err)
+		return
+	}
+	removeUpload(id)
+	handler.Respond(c, http.StatusOK, "已取消", nil)
+}
+
+// fileSHA256 计算文件的 SHA-256
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// InitUploads 清理过期的上传并定期继续清理
+func InitUploads() error {
+	if err := os.MkdirAll(uploadDir(), 0700); err != nil {
+		return err
+	}
+	cleanExpiredUploads(time.Now())
+
+	go func() {
+		for now := range time.Tick(uploadCleanInterval) {
+			cleanExpiredUploads(now)
+		}
+	}()
+	return nil
+}
+
+// cleanExpiredUploads 删除超过过期时间没有新分块的上传，以及只剩上传信息的残留文件
+func cleanExpiredUploads(now time.Time) {
+	entries, err := os.ReadDir(uploadDir())
+	if err != nil {
+		log.Printf("清理过期上传失败: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if !uploadIDPattern.MatchString(id) {
+			continue
+		}
+
+		unlock := lockUpload(id)
+		info, err := os.Stat(partPath(id))
+		if os.IsNotExist(err) || (err == nil && now.Sub(info.ModTime()) >= uploadExpire()) {
+			removeUpload(id)
+		}
+		unlock()
+	}
+}
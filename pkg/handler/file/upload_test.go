@@ -0,0 +1,237 @@
+package file
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/gin-gonic/gin"
+)
+
+// setupUploads 使用临时目录保存未完成的上传，分块上限为 1MB，返回接口和目标目录
+func setupUploads(t *testing.T) (*gin.Engine, string) {
+	t.Helper()
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+	config.AppConfig = &config.Config{Files: config.FilesConfig{
+		UploadDir:         t.TempDir(),
+		UploadExpireHours: 1,
+		UploadMaxChunkMB:  1,
+	}}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("username", c.GetHeader("X-User"))
+	})
+	r.POST("/uploads", CreateUpload)
+	r.GET("/uploads/:id", GetUpload)
+	r.PATCH("/uploads/:id", UploadChunk)
+	r.POST("/uploads/:id/complete", CompleteUpload)
+	r.DELETE("/uploads/:id", CancelUpload)
+	return r, t.TempDir()
+}
+
+func doUpload(t *testing.T, r *gin.Engine, method, target string, body []byte) (*httptest.ResponseRecorder, UploadStatus) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", "ops")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response struct {
+		Data UploadStatus `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response.Data
+}
+
+func createUpload(t *testing.T, r *gin.Engine, dir, name string, size int, checksum string) UploadStatus {
+	t.Helper()
+	body, _ := json.Marshal(CreateUploadRequest{Path: dir, Name: name, Size: int64(size), SHA256: checksum})
+	w, status := doUpload(t, r, http.MethodPost, "/uploads", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	return status
+}
+
+func sum(data []byte) string {
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:])
+}
+
+func TestChunkedUploadResumes(t *testing.T) {
+	r, dir := setupUploads(t)
+	content := bytes.Repeat([]byte("0123456789"), 150000) // 1.5MB，需要两个分块
+	status := createUpload(t, r, dir, "big.bin", len(content), "")
+	if status.Offset != 0 || status.ChunkSize != 1<<20 {
+		t.Fatalf("initial status = %+v", status)
+	}
+	chunk := func(offset int, data []byte) (*httptest.ResponseRecorder, UploadStatus) {
+		return doUpload(t, r, http.MethodPatch, "/uploads/"+status.ID+"?offset="+strconv.Itoa(offset), data)
+	}
+
+	if w, _ := chunk(0, content[:(1<<20)+1]); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized chunk: %d %s", w.Code, w.Body.String())
+	}
+	if w, got := chunk(0, content[:1000]); w.Code != http.StatusOK || got.Offset != 1000 {
+		t.Fatalf("first chunk: %d %+v", w.Code, got)
+	}
+	// 客户端断线后重发了已经写入的分块
+	w, got := chunk(0, content[:1<<20])
+	if w.Code != http.StatusConflict || got.Offset != 1000 {
+		t.Fatalf("stale offset: %d %+v", w.Code, got)
+	}
+
+	if w, got := doUpload(t, r, http.MethodGet, "/uploads/"+status.ID, nil); w.Code != http.StatusOK || got.Offset != 1000 {
+		t.Fatalf("status: %d %+v", w.Code, got)
+	}
+	if w, _ := doUpload(t, r, http.MethodPost, "/uploads/"+status.ID+"/complete", []byte(`{"sha256":"`+sum(content)+`"}`)); w.Code != http.StatusConflict {
+		t.Fatalf("complete before all chunks: %d %s", w.Code, w.Body.String())
+	}
+
+	for offset := 1000; offset < len(content); {
+		end := min(offset+(1<<20), len(content))
+		w, got := chunk(offset, content[offset:end])
+		if w.Code != http.StatusOK || got.Offset != int64(end) {
+			t.Fatalf("chunk at %d: %d %s", offset, w.Code, w.Body.String())
+		}
+		offset = end
+	}
+	if w, _ := chunk(len(content), []byte("x")); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("data past the end: %d %s", w.Code, w.Body.String())
+	}
+
+	w, _ = doUpload(t, r, http.MethodPost, "/uploads/"+status.ID+"/complete", []byte(`{"sha256":"`+sum(content)+`"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("complete: %d %s", w.Code, w.Body.String())
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "big.bin"))
+	if err != nil || !bytes.Equal(data, content) {
+		t.Fatalf("uploaded file differs: %v", err)
+	}
+	if entries, _ := os.ReadDir(uploadDir()); len(entries) != 0 {
+		t.Errorf("temporary files left: %d", len(entries))
+	}
+}
+
+func TestChunkedUploadRejectsChecksumMismatch(t *testing.T) {
+	r, dir := setupUploads(t)
+	status := createUpload(t, r, dir, "a.txt", 5, sum([]byte("hello")))
+
+	if w, _ := doUpload(t, r, http.MethodPatch, "/uploads/"+status.ID+"?offset=0", []byte("hullo")); w.Code != http.StatusOK {
+		t.Fatalf("chunk: %d %s", w.Code, w.Body.String())
+	}
+	if w, _ := doUpload(t, r, http.MethodPost, "/uploads/"+status.ID+"/complete", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("complete with bad checksum: %d %s", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.txt")); err == nil {
+		t.Error("target written despite checksum mismatch")
+	}
+	if w, _ := doUpload(t, r, http.MethodGet, "/uploads/"+status.ID, nil); w.Code != http.StatusNotFound {
+		t.Errorf("failed upload kept: %d", w.Code)
+	}
+}
+
+func TestChunkedUploadOwnershipAndTarget(t *testing.T) {
+	r, dir := setupUploads(t)
+	status := createUpload(t, r, dir, "a.txt", 1, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/uploads/"+status.ID, nil)
+	req.Header.Set("X-User", "someone-else")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("other user's upload: %d", w.Code)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "exists.txt"), nil, 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for _, name := range []string{"exists.txt", "../escape.txt", "sub/a.txt"} {
+		body, _ := json.Marshal(CreateUploadRequest{Path: dir, Name: name, Size: 1})
+		if w, _ := doUpload(t, r, http.MethodPost, "/uploads", body); w.Code == http.StatusOK {
+			t.Errorf("create %q should fail", name)
+		}
+	}
+
+	if w, _ := doUpload(t, r, http.MethodDelete, "/uploads/"+status.ID, nil); w.Code != http.StatusOK {
+		t.Fatalf("cancel: %d %s", w.Code, w.Body.String())
+	}
+	if w, _ := doUpload(t, r, http.MethodGet, "/uploads/"+status.ID, nil); w.Code != http.StatusNotFound {
+		t.Errorf("cancelled upload: %d", w.Code)
+	}
+}
+
+func TestCleanExpiredUploads(t *testing.T) {
+	r, dir := setupUploads(t)
+	stale := createUpload(t, r, dir, "stale.txt", 1, "")
+	fresh := createUpload(t, r, dir, "fresh.txt", 1, "")
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(partPath(stale.ID), old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	orphan := filepath.Join(uploadDir(), "0123456789abcdef0123456789abcdef.json")
+	if err := os.WriteFile(orphan, []byte("{}"), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cleanExpiredUploads(time.Now())
+
+	for path, exists := range map[string]bool{
+		partPath(stale.ID): false,
+		metaPath(stale.ID): false,
+		orphan:             false,
+		partPath(fresh.ID): true,
+		metaPath(fresh.ID): true,
+	} {
+		if _, err := os.Stat(path); (err == nil) != exists {
+			t.Errorf("%s exists = %v, want %v", filepath.Base(path), err == nil, exists)
+		}
+	}
+}
+
+func TestUploadLocksReleased(t *testing.T) {
+	r, dir := setupUploads(t)
+	content := []byte("hello")
+	status := createUpload(t, r, dir, "a.txt", len(content), "")
+	cancelled := createUpload(t, r, dir, "b.txt", len(content), "")
+
+	for _, id := range []string{"made-up", strings.Repeat("ab", 16)} {
+		if w, _ := doUpload(t, r, http.MethodPatch, "/uploads/"+id+"?offset=0", content); w.Code != http.StatusNotFound {
+			t.Errorf("chunk for %s: %d", id, w.Code)
+		}
+		if w, _ := doUpload(t, r, http.MethodPost, "/uploads/"+id+"/complete", nil); w.Code != http.StatusNotFound {
+			t.Errorf("complete %s: %d", id, w.Code)
+		}
+		if w, _ := doUpload(t, r, http.MethodDelete, "/uploads/"+id, nil); w.Code != http.StatusNotFound {
+			t.Errorf("cancel %s: %d", id, w.Code)
+		}
+	}
+	if w, _ := doUpload(t, r, http.MethodPatch, "/uploads/"+status.ID+"?offset=0", content); w.Code != http.StatusOK {
+		t.Fatalf("chunk: %d %s", w.Code, w.Body.String())
+	}
+	if w, _ := doUpload(t, r, http.MethodPost, "/uploads/"+status.ID+"/complete", []byte(`{"sha256":"`+sum(content)+`"}`)); w.Code != http.StatusOK {
+		t.Fatalf("complete: %d %s", w.Code, w.Body.String())
+	}
+	if w, _ := doUpload(t, r, http.MethodDelete, "/uploads/"+cancelled.ID, nil); w.Code != http.StatusOK {
+		t.Fatalf("cancel: %d %s", w.Code, w.Body.String())
+	}
+
+	uploadLocksMu.Lock()
+	defer uploadLocksMu.Unlock()
+	if len(uploadLocks) != 0 {
+		t.Errorf("upload locks left: %d", len(uploadLocks))
+	}
+}
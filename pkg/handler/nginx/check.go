@@ -0,0 +1,95 @@
+package nginx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+)
+
+// ErrNginxNotInstalled 系统中没有 nginx 命令
+var ErrNginxNotInstalled = errors.New("未找到 nginx 命令，无法校验配置")
+
+// includePattern 匹配 include 指令的参数
+var includePattern = regexp.MustCompile(`(?:^|[;{}\s])include\s+([^;]+);`)
+
+// sitePathPattern 网站配置文件名只允许字母、数字和 .-_，防止读取其他目录的文件
+var sitePathPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// SiteConfigPath 返回网站配置文件的路径，名称不合法时返回错误
+func SiteConfigPath(name string) (string, error) {
+	if !sitePathPattern.MatchString(name) {
+		return "", fmt.Errorf("网站名称不合法: %s", name)
+	}
+	return filepath.Join(models.NginxSitesAvailable, name), nil
+}
+
+// CheckSiteConfig 在临时 prefix 中用 nginx -t 检查单个网站的配置，只加载这一份配置，不影响正在运行的 nginx。
+// 校验失败时返回 nginx 的输出
+func CheckSiteConfig(ctx context.Context, content string) (string, error) {
+	// include 会被 nginx -t 读取，解析错误的输出中可能带出文件内容，只允许引用 nginx 自身的配置目录
+	for _, match := range includePattern.FindAllStringSubmatch(content, -1) {
+		target := strings.Trim(strings.TrimSpace(match[1]), `"'`)
+		if !filepath.IsAbs(target) || !strings.HasPrefix(filepath.Clean(target), "/etc/nginx/") {
+			return "", fmt.Errorf("不允许 include %s，只能引用 /etc/nginx/ 下的文件", target)
+		}
+	}
+
+	if _, err := exec.LookPath("nginx"); err != nil {
+		return "", ErrNginxNotInstalled
+	}
+
+	dir, err := os.MkdirTemp("", "etapanel-nginx-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	sitePath := filepath.Join(dir, "site.conf")
+	if err := os.WriteFile(sitePath, []byte(content), 0600); err != nil {
+		return "", err
+	}
+
+	var mimeTypes string
+	if _, err := os.Stat("/etc/nginx/mime.types"); err == nil {
+		mimeTypes = "    include /etc/nginx/mime.types;\n"
+	}
+	// 临时目录、pid 和日志都放在 prefix 中，不写入系统目录
+	main := fmt.Sprintf(`pid %[1]s/nginx.pid;
+error_log %[1]s/error.log;
+
+events {
+}
+
+http {
+%[2]s    access_log off;
+    client_body_temp_path %[1]s/client_body;
+    proxy_temp_path %[1]s/proxy;
+    fastcgi_temp_path %[1]s/fastcgi;
+    uwsgi_temp_path %[1]s/uwsgi;
+    scgi_temp_path %[1]s/scgi;
+
+    include %[3]s;
+}
+`, dir, mimeTypes, sitePath)
+	mainPath := filepath.Join(dir, "nginx.conf")
+	if err := os.WriteFile(mainPath, []byte(main), 0600); err != nil {
+		return "", err
+	}
+
+	output, err := exec.CommandContext(ctx, "nginx", "-t", "-q", "-p", dir+"/", "-c", mainPath).CombinedOutput()
+	result := strings.TrimSpace(strings.ReplaceAll(string(output), sitePath, "site.conf"))
+	if err != nil {
+		if result == "" {
+			result = err.Error()
+		}
+		return result, fmt.Errorf("配置测试失败: %s", result)
+	}
+	return result, nil
+}
@@ -366,7 +366,7 @@ func createNginxSite(site models.NginxSite) error {
 	}
 
 	// 生成配置内容
-	config := generateSiteConfig(site)
+	config := GenerateSiteConfig(site)
 
 	// 写入配置文件
 	configPath := filepath.Join(models.NginxSitesAvailable, site.Name)
@@ -434,7 +434,7 @@ func updateNginxSite(site models.NginxSite) error {
 	site.ConfigPath = existingSite.ConfigPath
 
 	// 生成新配置
-	config := generateSiteConfig(site)
+	config := GenerateSiteConfig(site)
 
 	// 写入配置文件
 	if err := ioutil.WriteFile(site.ConfigPath, []byte(config), 0644); err != nil {
@@ -583,8 +583,8 @@ http {
 		serverTokens, config.AccessLog, config.ErrorLog, gzipStatus)
 }
 
-// generateSiteConfig 生成网站配置
-func generateSiteConfig(site models.NginxSite) string {
+// GenerateSiteConfig 生成网站配置
+func GenerateSiteConfig(site models.NginxSite) string {
 	var config strings.Builder
 
 	// HTTP服务器块
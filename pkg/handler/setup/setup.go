@@ -0,0 +1,92 @@
+package setup
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/audit"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/setup"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRequest 首次初始化请求
+type SetupRequest struct {
+	Token    string                `json:"token" binding:"required" example:"3f2a9c..."` // 启动时输出到服务器日志的一次性初始化令牌
+	Username string                `json:"username" binding:"required,min=2,max=64" example:"admin"`
+	Password string                `json:"password" binding:"required,min=8" example:"S3cure-Passw0rd"`
+	Settings *models.PanelSettings `json:"settings"` // 可选，面板名称、Logo和主题色
+}
+
+// GetSetupStatus 获取初始化状态
+// @Summary 获取初始化状态
+// @Description 查询面板是否需要首次初始化
+// @Tags 公共接口
+// @Accept json
+// @Produce json
+// @Success 200 {object} handler.Response{data=object{setup_required=bool}} "获取成功"
+// @Router /public/setup [get]
+func GetSetupStatus(c *gin.Context) {
+	handler.Respond(c, http.StatusOK, nil, gin.H{"setup_required": setup.Required()})
+}
+
+// Setup 首次初始化
+// @Summary 首次初始化
+// @Description 创建管理员账号、生成随机JWT密钥并保存面板设置，仅在面板未初始化时可用，需提供启动日志中输出的初始化令牌
+// @Tags 公共接口
+// @Accept json
+// @Produce json
+// @Param request body SetupRequest true "初始化信息"
+// @Success 200 {object} handler.Response{data=models.User} "初始化成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 403 {object} handler.Response "初始化令牌错误"
+// @Failure 409 {object} handler.Response "面板已完成初始化"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /public/setup [post]
+func Setup(c *gin.Context) {
+	if !setup.Required() {
+		handler.Respond(c, http.StatusConflict, "面板已完成初始化", nil)
+		return
+	}
+
+	var req SetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	admin, err := setup.Complete(setup.Options{
+		Token:    req.Token,
+		Username: req.Username,
+		Password: req.Password,
+		Settings: req.Settings,
+	})
+	if errors.Is(err, setup.ErrAlreadyCompleted) {
+		handler.Respond(c, http.StatusConflict, "面板已完成初始化", nil)
+		return
+	}
+	if errors.Is(err, setup.ErrInvalidToken) {
+		audit.Log(models.AuditLog{
+			Username: req.Username,
+			Action:   models.AuditSetupCompleted,
+			ClientIP: c.ClientIP(),
+			Detail:   "初始化令牌错误",
+		})
+		handler.Respond(c, http.StatusForbidden, "初始化令牌错误，请查看服务器启动日志", nil)
+		return
+	}
+	if err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "初始化失败: "+err.Error(), nil)
+		return
+	}
+
+	audit.Log(models.AuditLog{
+		Username: admin.Username,
+		Action:   models.AuditSetupCompleted,
+		ClientIP: c.ClientIP(),
+		Success:  true,
+	})
+
+	handler.Respond(c, http.StatusOK, "初始化完成，请使用新账号登录", admin)
+}
@@ -0,0 +1,110 @@
+package setup
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/setup"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupEngine 初始化空数据库并进入初始化模式，返回路由和启动日志中的初始化令牌
+func setupEngine(t *testing.T) (*gin.Engine, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	t.Chdir(dir)
+	config.AppConfig = &config.Config{JWT: config.JWTConfig{Secret: config.DefaultJWTSecret}}
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.AuthToken{}, &models.PanelSettings{}, &models.AuditLog{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	database.DbConn = db
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	if err := setup.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	match := regexp.MustCompile(`初始化令牌 ([0-9a-f]{32})`).FindStringSubmatch(buf.String())
+	if match == nil {
+		t.Fatalf("expected the setup token in the log, got %q", buf.String())
+	}
+
+	r := gin.New()
+	r.GET("/setup", GetSetupStatus)
+	r.POST("/setup", Setup)
+	return r, match[1]
+}
+
+func postSetup(r *gin.Engine, body SetupRequest) int {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/setup", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestSetupRequiresToken(t *testing.T) {
+	r, token := setupEngine(t)
+
+	cases := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"missing token", "", http.StatusBadRequest},
+		{"wrong token", "0123456789abcdef0123456789abcdef", http.StatusForbidden},
+		{"valid token", token, http.StatusOK},
+		{"already completed", token, http.StatusConflict},
+	}
+
+	for _, tc := range cases {
+		if code := postSetup(r, SetupRequest{Token: tc.token, Username: "admin", Password: "S3cure-Passw0rd"}); code != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.name, tc.want, code)
+		}
+	}
+
+	var admins int64
+	database.DbConn.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&admins)
+	if admins != 1 {
+		t.Fatalf("expected exactly one admin, got %d", admins)
+	}
+}
+
+func TestSetupStatus(t *testing.T) {
+	r, _ := setupEngine(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/setup", nil))
+
+	var resp struct {
+		Data struct {
+			SetupRequired bool `json:"setup_required"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || !resp.Data.SetupRequired {
+		t.Fatalf("expected setup_required, got %s", w.Body.String())
+	}
+	if bytes.Contains(w.Body.Bytes(), []byte("token")) {
+		t.Fatal("status endpoint must not expose the setup token")
+	}
+}
@@ -0,0 +1,189 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+)
+
+const (
+	// snapshotTopProcesses 快照中按 CPU 和内存分别保留的进程数
+	snapshotTopProcesses = 10
+	// snapshotLogLines 每个日志来源保留的末尾行数
+	snapshotLogLines = 30
+	// snapshotLineLength 单行日志保留的最大字符数
+	snapshotLineLength = 300
+	// processSampleInterval 计算进程 CPU 占用的采样间隔
+	processSampleInterval = 500 * time.Millisecond
+	// clockTicks /proc/<pid>/stat 中 CPU 时间的单位，Linux 上几乎总是 100
+	clockTicks = 100
+)
+
+// snapshotLogFiles 诊断时读取末尾内容的日志文件，不存在的跳过
+var snapshotLogFiles = []string{
+	"/var/log/nginx/error.log",
+	"/var/log/syslog",
+	"/var/log/messages",
+	"/var/log/kern.log",
+}
+
+// CollectHealthSnapshot 采集 CPU、内存、磁盘、系统信息、资源占用最高的进程以及相关日志的末尾，用于健康诊断
+func CollectHealthSnapshot(ctx context.Context) models.HealthSnapshot {
+	processes := sampleProcesses(processSampleInterval)
+
+	snapshot := models.HealthSnapshot{
+		CollectedAt: time.Now().Format("2006-01-02 15:04:05"),
+		Cpu:         getCPUInfo(),
+		Memory:      getMemoryInfo(),
+		Disk:        getDiskInfo(),
+		System:      getOSInfo(),
+		TopCPU:      topProcesses(processes, func(a, b models.ProcessInfo) bool { return a.CPU > b.CPU }),
+		TopMemory:   topProcesses(processes, func(a, b models.ProcessInfo) bool { return a.Memory > b.Memory }),
+	}
+
+	for _, path := range snapshotLogFiles {
+		if lines := tailFile(path, snapshotLogLines); len(lines) > 0 {
+			snapshot.Logs = append(snapshot.Logs, models.LogTail{Source: path, Lines: lines})
+		}
+	}
+	if lines := journalErrors(ctx, snapshotLogLines); len(lines) > 0 {
+		snapshot.Logs = append(snapshot.Logs, models.LogTail{Source: "journalctl -p err", Lines: lines})
+	}
+	return snapshot
+}
+
+// IsCriticalProcess 是否为不允许终止的系统关键进程
+func IsCriticalProcess(pid int) bool {
+	return isSystemCriticalProcess(pid)
+}
+
+// ProcessExists 进程是否存在
+func ProcessExists(pid int) bool {
+	return pid > 0 && getProcessInfo(pid) != nil
+}
+
+// sampleProcesses 获取进程列表，并按间隔内消耗的 CPU 时间计算每个进程的 CPU 占用百分比
+func sampleProcesses(interval time.Duration) []models.ProcessInfo {
+	before := make(map[int]uint64)
+	for _, process := range getProcessList() {
+		if ticks, ok := processCPUTicks(process.PId); ok {
+			before[process.PId] = ticks
+		}
+	}
+	time.Sleep(interval)
+
+	processes := getProcessList()
+	for i := range processes {
+		start, ok := before[processes[i].PId]
+		end, ok2 := processCPUTicks(processes[i].PId)
+		if ok && ok2 && end >= start {
+			processes[i].CPU = float64(end-start) / clockTicks / interval.Seconds() * 100
+		}
+	}
+	return processes
+}
+
+// processCPUTicks 读取进程的用户态和内核态 CPU 时间之和
+func processCPUTicks(pid int) (uint64, bool) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0, false
+	}
+	// 进程名可能包含空格和括号，从最后一个右括号之后开始按字段解析
+	stat := string(data)
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(stat[end+1:])
+	// 右括号之后第 1 个字段是状态，utime 和 stime 分别是第 12 和第 13 个
+	if len(fields) < 13 {
+		return 0, false
+	}
+	utime, err1 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err2 := strconv.ParseUint(fields[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	return utime + stime, true
+}
+
+// topProcesses 按 less 排序后返回前 snapshotTopProcesses 个进程
+func topProcesses(processes []models.ProcessInfo, less func(a, b models.ProcessInfo) bool) []models.ProcessInfo {
+	sorted := append([]models.ProcessInfo(nil), processes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	if len(sorted) > snapshotTopProcesses {
+		sorted = sorted[:snapshotTopProcesses]
+	}
+	return sorted
+}
+
+// tailFile 读取文件最后 n 行，只读取文件末尾的一部分
+func tailFile(path string, n int) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	const window = 64 * 1024
+	seeked := false
+	if info, err := file.Stat(); err == nil && info.Size() > window {
+		if _, err := file.Seek(-window, io.SeekEnd); err != nil {
+			return nil
+		}
+		seeked = true
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), window)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	// 从文件中间开始读取时第一行可能不完整
+	if seeked && len(lines) > 0 {
+		lines = lines[1:]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return truncateLines(lines)
+}
+
+// journalErrors 读取最近一小时 systemd 日志中错误级别以上的最后 n 条
+func journalErrors(ctx context.Context, n int) []string {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "journalctl", "--no-pager", "--output", "short-iso",
+		"--priority", "err", "--since", "-1h", "--lines", strconv.Itoa(n)).Output()
+	if err != nil {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" && !strings.HasPrefix(line, "-- ") {
+			lines = append(lines, line)
+		}
+	}
+	return truncateLines(lines)
+}
+
+// truncateLines 截断过长的日志行
+func truncateLines(lines []string) []string {
+	for i, line := range lines {
+		if runes := []rune(line); len(runes) > snapshotLineLength {
+			lines[i] = string(runes[:snapshotLineLength]) + "…"
+		}
+	}
+	return lines
+}
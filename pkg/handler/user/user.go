@@ -0,0 +1,202 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/auth"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetUsers 获取用户列表
+// @Summary 获取用户列表
+// @Description 获取面板所有用户及其角色
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} handler.Response{data=[]models.User} "获取成功"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "权限不足"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/users [get]
+func GetUsers(c *gin.Context) {
+	var users []models.User
+	if err := database.DbConn.Order("id").Find(&users).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, users)
+}
+
+// CreateUser 创建用户
+// @Summary 创建用户
+// @Description 创建面板用户并指定角色（admin, operator, readonly）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.UserCreateRequest true "用户信息"
+// @Success 201 {object} handler.Response{data=models.User} "创建成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "权限不足"
+// @Failure 409 {object} handler.Response "用户名已存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/users [post]
+func CreateUser(c *gin.Context) {
+	var req models.UserCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	var count int64
+	database.DbConn.Model(&models.User{}).Where("username = ?", req.Username).Count(&count)
+	if count > 0 {
+		handler.Respond(c, http.StatusConflict, "用户名已存在", nil)
+		return
+	}
+
+	user := models.User{
+		Username: req.Username,
+		Password: req.Password,
+		Role:     req.Role,
+	}
+	if err := user.HashPassword(); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "密码加密失败", nil)
+		return
+	}
+
+	if err := database.DbConn.Create(&user).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusCreated, nil, user)
+}
+
+// UpdateUser 更新用户
+// @Summary 更新用户
+// @Description 修改用户角色或重置密码，修改后该用户的所有会话将被撤销
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body models.UserUpdateRequest true "更新内容"
+// @Success 200 {object} handler.Response{data=models.User} "更新成功"
+// @Failure 400 {object} handler.Response "请求参数错误"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "权限不足"
+// @Failure 404 {object} handler.Response "用户不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/users/{id} [put]
+func UpdateUser(c *gin.Context) {
+	var req models.UserUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handler.Respond(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), nil)
+		return
+	}
+
+	user, ok := loadUser(c)
+	if !ok {
+		return
+	}
+
+	if req.Role != "" && req.Role != user.Role {
+		if user.Role == models.RoleAdmin && isLastAdmin(user) {
+			handler.Respond(c, http.StatusBadRequest, "不能修改最后一个管理员的角色", nil)
+			return
+		}
+		user.Role = req.Role
+	}
+
+	if req.Password != "" {
+		user.Password = req.Password
+		if err := user.HashPassword(); err != nil {
+			handler.Respond(c, http.StatusInternalServerError, "密码加密失败", nil)
+			return
+		}
+	}
+
+	if err := database.DbConn.Save(&user).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	// 角色记录在token中，修改角色或密码后需要重新登录
+	if _, err := auth.RevokeUserSessions(user.Username, ""); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "撤销用户会话失败", nil)
+		return
+	}
+
+	handler.Respond(c, http.StatusOK, nil, user)
+}
+
+// DeleteUser 删除用户
+// @Summary 删除用户
+// @Description 删除指定用户并撤销其所有会话，不能删除自己或最后一个管理员
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} handler.Response "删除成功"
+// @Failure 400 {object} handler.Response "不能删除该用户"
+// @Failure 401 {object} handler.Response "未授权"
+// @Failure 403 {object} handler.Response "权限不足"
+// @Failure 404 {object} handler.Response "用户不存在"
+// @Failure 500 {object} handler.Response "服务器内部错误"
+// @Router /auth/users/{id} [delete]
+func DeleteUser(c *gin.Context) {
+	user, ok := loadUser(c)
+	if !ok {
+		return
+	}
+
+	if user.Username == c.GetString("username") {
+		handler.Respond(c, http.StatusBadRequest, "不能删除当前登录的用户", nil)
+		return
+	}
+	if user.Role == models.RoleAdmin && isLastAdmin(user) {
+		handler.Respond(c, http.StatusBadRequest, "不能删除最后一个管理员", nil)
+		return
+	}
+
+	if _, err := auth.RevokeUserSessions(user.Username, ""); err != nil {
+		handler.Respond(c, http.StatusInternalServerError, "撤销用户会话失败", nil)
+		return
+	}
+
+	// 硬删除，释放用户名的唯一约束
+	if err := database.DbConn.Unscoped().Delete(&user).Error; err != nil {
+		handler.Respond(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	database.DbConn.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{})
+
+	handler.Respond(c, http.StatusOK, "用户已删除", nil)
+}
+
+// loadUser 加载路径参数对应的用户，失败时直接写入响应
+func loadUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+	if err := database.DbConn.First(&user, c.Param("id")).Error; err != nil {
+		handler.Respond(c, http.StatusNotFound, "用户不存在", nil)
+		return user, false
+	}
+	return user, true
+}
+
+// isLastAdmin 判断用户是否为唯一的管理员
+func isLastAdmin(user models.User) bool {
+	var count int64
+	database.DbConn.Model(&models.User{}).
+		Where("role = ? AND id <> ?", models.RoleAdmin, user.ID).
+		Count(&count)
+	return count == 0
+}
@@ -1,47 +1,51 @@
 package middleware
 
 import (
+	"errors"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"net/http"
+	"strings"
+	"time"
 )
 
+// sessionTouchInterval 会话最后活跃时间的最小刷新间隔，避免每个请求都写库
+const sessionTouchInterval = time.Minute
+
+// allowQueryTokenKey 上下文中标记当前请求允许使用 token 查询参数
+const allowQueryTokenKey = "allow_query_token"
+
+// TokenSubprotocol WebSocket 子协议认证标识
+// 浏览器无法为 WebSocket 设置请求头，客户端可发送 Sec-WebSocket-Protocol: access_token, <token>
+const TokenSubprotocol = "access_token"
+
 type Claims struct {
 	Username string `json:"username"`
+	Role     string `json:"role"`
 	jwt.RegisteredClaims
 }
 
 // JWTAuth JWT认证中间件
 func JWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 从请求头获取token
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
+		tokenString := ExtractToken(c)
+		if tokenString == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code":    401,
-				"message": "请求头中缺少Authorization字段",
+				"message": "缺少认证Token",
 			})
 			c.Abort()
 			return
 		}
 
-		// 检查Bearer前缀
-		//parts := strings.SplitN(authHeader, " ", 2)
-		//if !(len(parts) == 2 && parts[0] == "Bearer") {
-		//	c.JSON(http.StatusUnauthorized, gin.H{
-		//		"code":    401,
-		//		"message": "Authorization格式错误",
-		//	})
-		//	c.Abort()
-		//	return
-		//}
-
 		// 解析token，使用配置文件中的密钥
 		jwtSecret := []byte(config.AppConfig.JWT.Secret)
-		token, err := jwt.ParseWithClaims(authHeader, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 			return jwtSecret, nil
-		})
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -52,11 +56,8 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
-		if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-			// 将用户信息存储到上下文中
-			c.Set("username", claims.Username)
-			c.Next()
-		} else {
+		claims, ok := token.Claims.(*Claims)
+		if !ok || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code":    401,
 				"message": "Token无效",
@@ -64,5 +65,104 @@ func JWTAuth() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+
+		// 校验服务端会话，已撤销或过期的会话即使签名有效也拒绝
+		session, err := activeSession(claims)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		// 将用户信息存储到上下文中
+		c.Set("username", claims.Username)
+		c.Set("session_id", session.TokenID)
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// AllowQueryToken 允许 WebSocket 握手请求通过 token 查询参数认证，需在 JWTAuth 之前使用。
+// 查询参数会出现在访问日志和代理日志中，只应挂在确实需要的 WebSocket 路由上。
+func AllowQueryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.IsWebsocket() {
+			c.Set(allowQueryTokenKey, true)
+		}
+		c.Next()
+	}
+}
+
+// RequireRole 角色权限中间件，需在 JWTAuth 之后使用
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("claims")
+		claims, ok := value.(*Claims)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "未找到用户信息",
+			})
+			c.Abort()
+			return
+		}
+
+		if !models.RoleAtLeast(claims.Role, role) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "权限不足",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// activeSession 查找 token 对应的有效会话，并按间隔刷新最后活跃时间
+func activeSession(claims *Claims) (*models.AuthToken, error) {
+	if claims.ID == "" {
+		return nil, errors.New("会话无效，请重新登录")
+	}
+
+	var session models.AuthToken
+	if err := database.DbConn.Where("token_id = ?", claims.ID).First(&session).Error; err != nil {
+		return nil, errors.New("会话不存在，请重新登录")
+	}
+	if session.Username != claims.Username || !session.IsActive() {
+		return nil, errors.New("会话已失效，请重新登录")
+	}
+
+	if time.Since(session.LastSeenAt) > sessionTouchInterval {
+		database.DbConn.Model(&session).Update("last_seen_at", time.Now())
+	}
+	return &session, nil
+}
+
+// ExtractToken 依次从 Authorization 请求头、token 查询参数（仅 AllowQueryToken 标记的请求）、WebSocket 子协议中提取 token
+func ExtractToken(c *gin.Context) string {
+	if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+		return strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+	}
+
+	if c.GetBool(allowQueryTokenKey) {
+		if token := c.Query("token"); token != "" {
+			return token
+		}
+	}
+
+	if protocols := c.GetHeader("Sec-WebSocket-Protocol"); protocols != "" {
+		parts := strings.Split(protocols, ",")
+		for i := 0; i < len(parts)-1; i++ {
+			if strings.TrimSpace(parts[i]) == TokenSubprotocol {
+				return strings.TrimSpace(parts[i+1])
+			}
+		}
 	}
+
+	return ""
 }
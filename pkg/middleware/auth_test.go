@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testSecret = "middleware-test-secret"
+
+func newAuthEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	config.AppConfig = &config.Config{JWT: config.JWTConfig{Secret: testSecret}}
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.AuthToken{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	database.DbConn = db
+
+	r := gin.New()
+	r.GET("/protected", JWTAuth(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("username"))
+	})
+	r.GET("/ws", AllowQueryToken(), JWTAuth(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("username"))
+	})
+	return r
+}
+
+// createSession 写入一条会话记录并返回其 token id
+func createSession(t *testing.T, tokenID string, revoked bool) string {
+	t.Helper()
+	session := models.AuthToken{
+		TokenID:   tokenID,
+		Username:  "admin",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if revoked {
+		now := time.Now()
+		session.RevokedAt = &now
+	}
+	if err := database.DbConn.Create(&session).Error; err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	return tokenID
+}
+
+func signTestToken(t *testing.T, method jwt.SigningMethod, key interface{}, tokenID string) string {
+	t.Helper()
+	claims := &Claims{
+		Username: "admin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func TestJWTAuthTokenSources(t *testing.T) {
+	r := newAuthEngine(t)
+	token := signTestToken(t, jwt.SigningMethodHS256, []byte(testSecret), createSession(t, "active", false))
+
+	cases := map[string]func(req *http.Request){
+		"raw header":    func(req *http.Request) { req.Header.Set("Authorization", token) },
+		"bearer header": func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) },
+		"subprotocol": func(req *http.Request) {
+			req.Header.Set("Sec-WebSocket-Protocol", TokenSubprotocol+", "+token)
+		},
+	}
+
+	for name, apply := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		apply(req)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK || w.Body.String() != "admin" {
+			t.Errorf("%s: expected 200 admin, got %d %q", name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestJWTAuthQueryTokenOnlyOnWebSocketRoutes(t *testing.T) {
+	r := newAuthEngine(t)
+	token := signTestToken(t, jwt.SigningMethodHS256, []byte(testSecret), createSession(t, "active", false))
+
+	cases := []struct {
+		name      string
+		path      string
+		websocket bool
+		want      int
+	}{
+		{"websocket route upgrade", "/ws", true, http.StatusOK},
+		{"websocket route without upgrade", "/ws", false, http.StatusUnauthorized},
+		{"regular route", "/protected", false, http.StatusUnauthorized},
+		{"regular route upgrade", "/protected", true, http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path+"?token="+token, nil)
+		if tc.websocket {
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, w.Code)
+		}
+	}
+}
+
+func TestJWTAuthRejects(t *testing.T) {
+	r := newAuthEngine(t)
+	active := createSession(t, "active", false)
+	revoked := createSession(t, "revoked", true)
+
+	cases := map[string]func(req *http.Request){
+		"missing": func(req *http.Request) {},
+		"unsigned alg none": func(req *http.Request) {
+			req.Header.Set("Authorization", signTestToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, active))
+		},
+		"subprotocol without token": func(req *http.Request) {
+			req.Header.Set("Sec-WebSocket-Protocol", TokenSubprotocol)
+		},
+		"unknown subprotocol": func(req *http.Request) {
+			req.Header.Set("Sec-WebSocket-Protocol", "chat, "+signTestToken(t, jwt.SigningMethodHS256, []byte(testSecret), active))
+		},
+		"revoked session": func(req *http.Request) {
+			req.Header.Set("Authorization", signTestToken(t, jwt.SigningMethodHS256, []byte(testSecret), revoked))
+		},
+		"unknown session": func(req *http.Request) {
+			req.Header.Set("Authorization", signTestToken(t, jwt.SigningMethodHS256, []byte(testSecret), "missing"))
+		},
+		"no session id": func(req *http.Request) {
+			req.Header.Set("Authorization", signTestToken(t, jwt.SigningMethodHS256, []byte(testSecret), ""))
+		},
+	}
+
+	for name, apply := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		apply(req)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", name, w.Code)
+		}
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		role     string
+		required string
+		want     int
+	}{
+		{models.RoleAdmin, models.RoleAdmin, http.StatusOK},
+		{models.RoleAdmin, models.RoleReadOnly, http.StatusOK},
+		{models.RoleOperator, models.RoleOperator, http.StatusOK},
+		{models.RoleOperator, models.RoleAdmin, http.StatusForbidden},
+		{models.RoleReadOnly, models.RoleReadOnly, http.StatusOK},
+		{models.RoleReadOnly, models.RoleOperator, http.StatusForbidden},
+		{"", models.RoleReadOnly, http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		r := gin.New()
+		r.GET("/protected", func(c *gin.Context) {
+			c.Set("claims", &Claims{Username: "user", Role: tc.role})
+		}, RequireRole(tc.required), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/protected", nil))
+
+		if w.Code != tc.want {
+			t.Errorf("role %q requiring %q: expected %d, got %d", tc.role, tc.required, tc.want, w.Code)
+		}
+	}
+}
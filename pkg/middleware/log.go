@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -74,7 +75,7 @@ func processAPILog(param gin.LogFormatterParams) error {
 		Timestamp:    param.TimeStamp,
 		Method:       param.Method,
 		Path:         param.Path,
-		Query:        param.Request.URL.RawQuery,
+		Query:        redactQuery(param.Request.URL),
 		Headers:      extractHeaders(param.Request.Header),
 		Body:         string(bodyBytes),
 		ClientIP:     param.ClientIP,
@@ -122,6 +123,16 @@ func processAPILog(param gin.LogFormatterParams) error {
 	return nil
 }
 
+// redactQuery 隐去查询参数中的 token，避免认证凭据写入日志
+func redactQuery(u *url.URL) string {
+	query := u.Query()
+	if query.Get("token") == "" {
+		return u.RawQuery
+	}
+	query.Set("token", "REDACTED")
+	return query.Encode()
+}
+
 // 生成请求ID
 func generateRequestID(param gin.LogFormatterParams) string {
 	data := fmt.Sprintf("%s-%s-%s-%d",
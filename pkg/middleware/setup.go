@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/setup"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupGuard 初始化模式中间件，面板未初始化时仅开放健康检查和初始化接口
+func SetupGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !setup.Required() {
+			c.Next()
+			return
+		}
+
+		path := c.Request.URL.Path
+		if path == "/api/public" || strings.HasPrefix(path, "/api/public/setup") || strings.HasPrefix(path, "/swagger/") {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":           503,
+			"message":        "面板尚未初始化，请先完成初始化设置",
+			"setup_required": true,
+		})
+		c.Abort()
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/setup"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newSetupEngine 按数据库中是否存在管理员初始化 setup 状态，返回挂载 SetupGuard 的路由
+func newSetupEngine(t *testing.T, withAdmin bool) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	config.AppConfig = &config.Config{JWT: config.JWTConfig{Secret: testSecret}}
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	database.DbConn = db
+
+	if withAdmin {
+		admin := models.User{Username: "admin", Password: "S3cure-Passw0rd", Role: models.RoleAdmin}
+		if err := admin.HashPassword(); err != nil {
+			t.Fatalf("hash password: %v", err)
+		}
+		db.Create(&admin)
+	}
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	if err := setup.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(SetupGuard())
+	for _, path := range []string{"/api/public", "/api/public/setup", "/api/public/login", "/api/auth/files", "/swagger/index.html"} {
+		r.GET(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	return r
+}
+
+func TestSetupGuard(t *testing.T) {
+	cases := []struct {
+		path      string
+		setupWant int
+		readyWant int
+	}{
+		{"/api/public", http.StatusOK, http.StatusOK},
+		{"/api/public/setup", http.StatusOK, http.StatusOK},
+		{"/swagger/index.html", http.StatusOK, http.StatusOK},
+		{"/api/public/login", http.StatusServiceUnavailable, http.StatusOK},
+		{"/api/auth/files", http.StatusServiceUnavailable, http.StatusOK},
+	}
+
+	for _, withAdmin := range []bool{false, true} {
+		r := newSetupEngine(t, withAdmin)
+		for _, tc := range cases {
+			want := tc.setupWant
+			if withAdmin {
+				want = tc.readyWant
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if w.Code != want {
+				t.Errorf("%s (admin exists: %v): expected %d, got %d", tc.path, withAdmin, want, w.Code)
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AI 工具调用审批状态
+const (
+	AIApprovalPending   = "pending"   // 等待审批
+	AIApprovalExecuting = "executing" // 已批准，正在执行
+	AIApprovalExecuted  = "executed"  // 已批准并执行成功
+	AIApprovalFailed    = "failed"    // 已批准但执行失败
+	AIApprovalRejected  = "rejected"  // 已拒绝
+	AIApprovalExpired   = "expired"   // 超时未审批
+)
+
+// AIToolApproval 等待人工审批的高风险 AI 工具调用
+type AIToolApproval struct {
+	gorm.Model
+	Username   string     `json:"username" gorm:"index"` // 发起对话的用户
+	ToolCallID string     `json:"tool_call_id"`
+	ToolName   string     `json:"tool_name" gorm:"not null"`
+	Arguments  string     `json:"arguments" gorm:"type:text"` // 工具参数 JSON
+	Target     string     `json:"target" gorm:"type:text"`    // 执行时需要连接的数据库 JSON
+	Risk       string     `json:"risk"`
+	Status     string     `json:"status" gorm:"index;not null"`
+	DecidedBy  string     `json:"decided_by"`
+	DecidedAt  *time.Time `json:"decided_at"`
+	Reason     string     `json:"reason"`
+	Result     string     `json:"result" gorm:"type:text"` // 执行结果 JSON
+}
+
+// AIApprovalDecisionRequest 审批请求
+type AIApprovalDecisionRequest struct {
+	Reason string `json:"reason" example:"确认删除测试表"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// AIConversation AI 对话，仅发起对话的用户可以继续提问
+type AIConversation struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at" gorm:"index"`
+	Username  string      `json:"username" gorm:"index;not null"`
+	Kind      string      `json:"kind" gorm:"not null"` // 对话类型，如 database
+	Title     string      `json:"title"`                // 取自第一条提问
+	Messages  []AIMessage `json:"messages,omitempty" gorm:"foreignKey:ConversationID"`
+}
+
+// AIMessage 对话中的一条消息，包括用户提问、模型回复、工具调用及其结果
+type AIMessage struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID uint      `json:"conversation_id" gorm:"index;not null"`
+	Role           string    `json:"role" gorm:"not null"` // user, assistant, tool
+	Content        string    `json:"content" gorm:"type:text"`
+	ToolCalls      string    `json:"tool_calls,omitempty" gorm:"type:text"`  // 模型发起的工具调用 JSON
+	ToolResult     string    `json:"tool_result,omitempty" gorm:"type:text"` // 工具执行结果 JSON
+}
+
+// AIUsage 一次 AI 请求的 token 用量
+type AIUsage struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt        time.Time `json:"created_at" gorm:"index"`
+	Username         string    `json:"username" gorm:"index;not null"`
+	Endpoint         string    `json:"endpoint"` // 调用来源，如 log、files、db
+	ConversationID   *uint     `json:"conversation_id"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	Estimated        bool      `json:"estimated"` // 服务商未返回用量，按内容长度估算
+}
+
+// AIQuota 用户每月的 token 配额，没有记录或为 0 表示不限
+type AIQuota struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Username      string    `json:"username" gorm:"uniqueIndex;not null"`
+	MonthlyTokens int64     `json:"monthly_tokens"`
+}
+
+// AIQuotaRequest 设置配额请求
+type AIQuotaRequest struct {
+	MonthlyTokens int64 `json:"monthly_tokens" binding:"min=0" example:"1000000"` // 0 表示不限
+}
+
+// AIUsageSummary 用户在一个月内的用量汇总
+type AIUsageSummary struct {
+	Username         string `json:"username"`
+	Requests         int64  `json:"requests"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	TotalTokens      int64  `json:"total_tokens"`
+	MonthlyQuota     int64  `json:"monthly_quota"` // 0 表示不限
+}
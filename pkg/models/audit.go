@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// 审计事件类型
+const (
+	AuditLoginSuccess   = "login.success"
+	AuditLoginFailed    = "login.failed"
+	AuditLoginThrottled = "login.throttled"
+	AuditLoginLocked    = "login.locked"
+	AuditLockoutCleared = "login.lockout_cleared"
+	AuditSetupCompleted = "setup.completed"
+	AuditAIToolQueued   = "ai.tool_queued"
+	AuditAIToolApproved = "ai.tool_approved"
+	AuditAIToolRejected = "ai.tool_rejected"
+	AuditAIQuotaChanged = "ai.quota_changed"
+)
+
+// AuditLog 审计日志
+type AuditLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	Username  string    `json:"username" gorm:"index"` // 操作人，登录事件中为尝试登录的用户名
+	Action    string    `json:"action" gorm:"index;not null"`
+	Target    string    `json:"target"`
+	ClientIP  string    `json:"client_ip"`
+	Success   bool      `json:"success"`
+	Detail    string    `json:"detail"`
+}
+
+// LoginAttempt 登录失败计数，Key 为 ip:<地址> 或 user:<用户名>
+type LoginAttempt struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Key           string     `json:"key" gorm:"column:throttle_key;uniqueIndex;not null"` // key 在 MySQL 中是保留字
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
+
+// IsLocked 是否处于锁定状态
+func (a *LoginAttempt) IsLocked() bool {
+	return a.LockedUntil != nil && time.Now().Before(*a.LockedUntil)
+}
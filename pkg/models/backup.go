@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 备份记录状态
+const (
+	BackupStatusRunning = "running"
+	BackupStatusSuccess = "success"
+	BackupStatusFailed  = "failed"
+)
+
+// BackupPlan 数据库定时备份计划
+type BackupPlan struct {
+	gorm.Model
+	Name         string     `json:"name" gorm:"not null"`
+	ConnectionID uint       `json:"connection_id" gorm:"not null;index"`
+	Database     string     `json:"database"`                    // 为空时使用连接配置中的数据库
+	CronSpec     string     `json:"cron_spec" gorm:"not null"`   // 标准5段 cron 表达式
+	DestDir      string     `json:"dest_dir" gorm:"not null"`    // 备份文件保存目录，位于配置的备份根目录内
+	KeepCount    int        `json:"keep_count" gorm:"default:0"` // 保留最近N份，0 表示不限制
+	KeepDays     int        `json:"keep_days" gorm:"default:0"`  // 保留N天内的备份，0 表示不限制
+	Enabled      bool       `json:"enabled"`
+	LastRunAt    *time.Time `json:"last_run_at"`
+	LastStatus   string     `json:"last_status"`
+	NextRunAt    *time.Time `json:"next_run_at" gorm:"-"`
+}
+
+// BackupRecord 单次备份执行记录
+type BackupRecord struct {
+	gorm.Model
+	PlanID       uint      `json:"plan_id" gorm:"index"` // 手动备份时为 0
+	ConnectionID uint      `json:"connection_id" gorm:"not null;index"`
+	Engine       string    `json:"engine"`
+	Database     string    `json:"database"`
+	FilePath     string    `json:"file_path"`
+	Size         int64     `json:"size"`
+	DurationMs   int64     `json:"duration_ms"`
+	Status       string    `json:"status"`
+	Error        string    `json:"error,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+}
+
+// BackupPlanRequest 创建/更新备份计划请求
+type BackupPlanRequest struct {
+	Name         string `json:"name" binding:"required" example:"每日备份"`
+	ConnectionID uint   `json:"connection_id" binding:"required" example:"1"`
+	Database     string `json:"database" example:"app"`
+	CronSpec     string `json:"cron_spec" binding:"required" example:"0 3 * * *"`
+	DestDir      string `json:"dest_dir" binding:"required" example:"/var/backups/etapanel/daily"`
+	KeepCount    int    `json:"keep_count" binding:"min=0" example:"7"`
+	KeepDays     int    `json:"keep_days" binding:"min=0" example:"30"`
+	Enabled      bool   `json:"enabled" example:"true"`
+}
+
+// BackupRestoreRequest 恢复备份请求
+type BackupRestoreRequest struct {
+	Database string `json:"database" example:"app"` // 为空时恢复到备份来源数据库
+}
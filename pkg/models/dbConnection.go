@@ -0,0 +1,46 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// DatabaseConnection 已保存的数据库连接配置
+type DatabaseConnection struct {
+	gorm.Model
+	Name     string `json:"name" gorm:"not null"`
+	Engine   string `json:"engine" gorm:"not null"` // dbmgr 注册的引擎名: mysql, postgresql, redis, sqlite
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"-"`
+	Database string `json:"database"`
+	SSLMode  string `json:"ssl_mode"`
+	Path     string `json:"path"`                                    // SQLite 数据库文件路径
+	ReadOnly bool   `json:"read_only" gorm:"not null;default:false"` // 只读模式，查询控制台拒绝写操作
+}
+
+// DatabaseConnectionRequest 创建/更新数据库连接请求
+type DatabaseConnectionRequest struct {
+	Name     string `json:"name" binding:"required" example:"本地MySQL"`
+	Engine   string `json:"engine" binding:"required" example:"mysql"` // 须为 dbmgr 已注册的引擎，由处理器校验
+	Host     string `json:"host" example:"127.0.0.1"`
+	Port     int    `json:"port" example:"3306"`
+	Username string `json:"username" example:"root"`
+	Password string `json:"password" example:"password"`
+	Database string `json:"database" example:"app"`
+	SSLMode  string `json:"ssl_mode" example:"disable"`
+	Path     string `json:"path" example:"/var/lib/app/data.db"`
+	ReadOnly bool   `json:"read_only" example:"false"` // 只读模式，查询控制台拒绝写操作
+}
+
+// DatabaseQueryRequest 执行查询请求
+type DatabaseQueryRequest struct {
+	Database string `json:"database" example:"app"`
+	Query    string `json:"query" binding:"required" example:"SELECT * FROM users LIMIT 10"`
+}
+
+// DatabaseBackupRequest 备份请求
+type DatabaseBackupRequest struct {
+	Database   string `json:"database" example:"app"`
+	OutputPath string `json:"output_path" binding:"required" example:"manual/app.sql"` // 位于备份根目录内，相对路径视为相对备份根目录
+}
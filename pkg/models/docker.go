@@ -39,4 +39,6 @@ var Upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true // 允许跨域
 	},
+	// 回显客户端用于传递 token 的子协议，否则浏览器会拒绝握手
+	Subprotocols: []string{"access_token"},
 }
@@ -15,11 +15,28 @@ type FileInfo struct {
 	Group       string    `json:"group"`
 }
 
-// ProtectedDirs 受保护的目录列表
+// TrashItem 回收站中的文件或目录，内容保存在回收站目录下以 StoredName 命名
+type TrashItem struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Name         string    `json:"name"`
+	OriginalPath string    `json:"originalPath" gorm:"not null;index"`
+	StoredName   string    `json:"-" gorm:"not null;uniqueIndex"`
+	IsDir        bool      `json:"isDir"`
+	Size         int64     `json:"size"` // 目录为其中所有文件的大小之和
+	DeletedBy    string    `json:"deletedBy"`
+	DeletedAt    time.Time `json:"deletedAt" gorm:"not null;index"`
+}
+
+// ProtectedDirs 受保护的目录列表，路径本身及其下的所有内容都受保护
 var ProtectedDirs = []string{
 	"/etc/passwd",
+	"/etc/passwd-",
 	"/etc/shadow",
+	"/etc/shadow-",
+	"/etc/gshadow",
+	"/etc/gshadow-",
 	"/etc/sudoers",
+	"/etc/sudoers.d",
 	"/boot",
 	"/sys",
 	"/proc",
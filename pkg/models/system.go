@@ -82,3 +82,21 @@ type OSInfo struct {
 	Uptime    int64  `json:"uptime"`
 	Processes int    `json:"processes"`
 }
+
+// HealthSnapshot 系统健康诊断使用的状态快照
+type HealthSnapshot struct {
+	CollectedAt string        `json:"collectedAt"`
+	Cpu         CpuInfo       `json:"cpu"`
+	Memory      MemoryInfo    `json:"memory"`
+	Disk        []DiskInfo    `json:"disk"`
+	System      OSInfo        `json:"system"`
+	TopCPU      []ProcessInfo `json:"topCpu"`    // CPU 占用最高的进程
+	TopMemory   []ProcessInfo `json:"topMemory"` // 内存占用最高的进程
+	Logs        []LogTail     `json:"logs"`
+}
+
+// LogTail 一个日志来源的末尾几行
+type LogTail struct {
+	Source string   `json:"source"`
+	Lines  []string `json:"lines"`
+}
@@ -5,16 +5,25 @@ import (
 	"time"
 )
 
+// AuthToken 登录会话，对应已签发 JWT 的 jti
 type AuthToken struct {
 	gorm.Model
-	Id        uint      `json:"id" gorm:"primaryKey"`
-	Token     string    `json:"token" gorm:"unique;not null"`
-	Ua        string    `json:"ua" gorm:"unique;not null"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	TokenID    string     `json:"-" gorm:"uniqueIndex;not null"`
+	Username   string     `json:"username" gorm:"index;not null"`
+	UserAgent  string     `json:"user_agent"`
+	ClientIP   string     `json:"client_ip"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Current    bool       `json:"current" gorm:"-"` // 是否为发起请求的会话
 }
 
 // IsExpired 检查令牌是否过期
 func (t *AuthToken) IsExpired() bool {
 	return time.Now().After(t.ExpiresAt)
 }
+
+// IsActive 会话未过期且未被撤销
+func (t *AuthToken) IsActive() bool {
+	return t.RevokedAt == nil && !t.IsExpired()
+}
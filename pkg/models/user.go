@@ -7,12 +7,36 @@ import (
 	"gorm.io/gorm"
 )
 
+// 用户角色
+const (
+	RoleAdmin    = "admin"    // 管理员：全部权限，包括用户管理和系统设置
+	RoleOperator = "operator" // 运维：可管理文件、容器、防火墙等服务器资源
+	RoleReadOnly = "readonly" // 只读：仅可查看系统监控和日志
+)
+
+// roleLevels 角色权限等级，数值越大权限越高
+var roleLevels = map[string]int{
+	RoleReadOnly: 1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// RoleAtLeast 判断角色是否具备 required 角色的权限
+func RoleAtLeast(role, required string) bool {
+	level, ok := roleLevels[role]
+	return ok && level >= roleLevels[required]
+}
+
 type User struct {
 	gorm.Model
-	Username  string    `json:"username" gorm:"primaryKey;unique;not null"`
-	Password  string    `json:"-" gorm:"not null"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Username     string    `json:"username" gorm:"primaryKey;unique;not null"`
+	Password     string    `json:"-" gorm:"not null"`
+	Role         string    `json:"role" gorm:"not null;default:readonly"`   // admin, operator, readonly，未指定时为最低权限
+	TOTPSecret   string    `json:"-" gorm:"column:totp_secret"`             // Base32 密钥，启用前为待确认状态
+	TOTPEnabled  bool      `json:"totp_enabled" gorm:"column:totp_enabled"` // 是否已启用两步验证
+	TOTPLastStep int64     `json:"-" gorm:"column:totp_last_step"`          // 最近一次使用的时间步，防止验证码重放
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 func (u *User) HashPassword() error {
@@ -28,3 +52,24 @@ func (u *User) HashPassword() error {
 func (u *User) CheckPassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 }
+
+// RecoveryCode 两步验证恢复码，仅保存哈希值
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `json:"user_id" gorm:"index;not null"`
+	CodeHash string     `json:"-" gorm:"not null"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+// UserCreateRequest 创建用户请求
+type UserCreateRequest struct {
+	Username string `json:"username" binding:"required,min=2,max=64" example:"ops"`
+	Password string `json:"password" binding:"required,min=6" example:"Abc123456"`
+	Role     string `json:"role" binding:"required,oneof=admin operator readonly" example:"operator"`
+}
+
+// UserUpdateRequest 更新用户请求，字段为空时不修改
+type UserUpdateRequest struct {
+	Password string `json:"password" binding:"omitempty,min=6" example:"Abc123456"`
+	Role     string `json:"role" binding:"omitempty,oneof=admin operator readonly" example:"readonly"`
+}
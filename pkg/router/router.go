@@ -3,17 +3,23 @@ package router
 import (
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/extend/pty"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/ai"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/audit"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/auth"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/backup"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/crontab"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/dbmgr"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/docker"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/file"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/firewall"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/log"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/nginx"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/setting"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/setup"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/ssl"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/system"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/handler/user"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/middleware"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
 	"github.com/gin-gonic/gin"
 )
 
@@ -25,6 +31,7 @@ func LoadRoutes(r *gin.Engine) {
 	r.Use(middleware.CORS())
 	r.Use(middleware.Security())
 	r.Use(middleware.LogVerification())
+	r.Use(middleware.SetupGuard())
 
 	// 公共 API
 	apiPublicRouter := r.Group("/api/public")
@@ -40,23 +47,78 @@ func LoadRoutes(r *gin.Engine) {
 			c.JSON(200, gin.H{"code": 200, "message": "Eta Panel API Server Is OK!"})
 		})
 		apiPublicRouter.POST("/login", auth.Login)
+		apiPublicRouter.POST("/login/2fa", auth.LoginTwoFactor)
+		apiPublicRouter.GET("/setup", setup.GetSetupStatus)
+		apiPublicRouter.POST("/setup", setup.Setup)
 	}
 
 	// 授权API
 	apiAuthRouter := r.Group("/api/auth")
-	//apiAuthRouter.Use(middleware.JWTAuth()) // 添加JWT认证中间件
+	apiAuthRouter.Use(middleware.JWTAuth()) // 添加JWT认证中间件
+
+	// 角色权限：只读用户仅可查看监控和日志，运维可管理服务器资源，管理员额外管理用户、设置、审批AI高风险调用并可使用服务器终端
+	readOnly := middleware.RequireRole(models.RoleReadOnly)
+	operator := middleware.RequireRole(models.RoleOperator)
+	admin := middleware.RequireRole(models.RoleAdmin)
 	{
+		apiAuthRouter.GET("/me", auth.GetCurrentUser)
 		apiAuthRouter.POST("/change-password", auth.ChangePassword)
+		apiAuthRouter.POST("/logout", auth.Logout)
+
+		// 会话管理API
+		apiSessionRouter := apiAuthRouter.Group("/sessions")
+		{
+			apiSessionRouter.GET("", auth.GetSessions)
+			apiSessionRouter.DELETE("/:id", auth.RevokeSession)
+			apiSessionRouter.POST("/revoke-all", auth.RevokeAllSessions)
+		}
+
+		// 用户管理API
+		apiUserRouter := apiAuthRouter.Group("/users", admin)
+		{
+			apiUserRouter.GET("", user.GetUsers)
+			apiUserRouter.POST("", user.CreateUser)
+			apiUserRouter.PUT("/:id", user.UpdateUser)
+			apiUserRouter.DELETE("/:id", user.DeleteUser)
+		}
+
+		// 登录安全与审计API
+		apiSecurityRouter := apiAuthRouter.Group("/security", admin)
+		{
+			apiSecurityRouter.GET("/lockouts", auth.GetLoginLockouts)
+			apiSecurityRouter.DELETE("/lockouts/:id", auth.ClearLoginLockout)
+		}
+		apiAuthRouter.GET("/audit", admin, audit.GetAuditLogs)
+
+		// 两步验证API
+		apiTwoFactorRouter := apiAuthRouter.Group("/2fa")
+		{
+			apiTwoFactorRouter.GET("", auth.GetTwoFactorStatus)
+			apiTwoFactorRouter.POST("/setup", auth.SetupTwoFactor)
+			apiTwoFactorRouter.POST("/enable", auth.EnableTwoFactor)
+			apiTwoFactorRouter.POST("/disable", auth.DisableTwoFactor)
+			apiTwoFactorRouter.POST("/recovery-codes", auth.RegenerateRecoveryCodes)
+		}
 
 		// 文件管理API
-		apiFileRouter := apiAuthRouter.Group("/files")
+		apiFileRouter := apiAuthRouter.Group("/files", operator)
 		{
 			apiFileRouter.GET("", file.ListFiles)
 			apiFileRouter.GET("/download", file.DownloadFile)
+			apiFileRouter.GET("/download/archive", file.DownloadArchive)
 			apiFileRouter.POST("/upload", file.UploadFile)
+			apiFileRouter.POST("/uploads", file.CreateUpload)
+			apiFileRouter.GET("/uploads/:id", file.GetUpload)
+			apiFileRouter.PATCH("/uploads/:id", file.UploadChunk)
+			apiFileRouter.POST("/uploads/:id/complete", file.CompleteUpload)
+			apiFileRouter.DELETE("/uploads/:id", file.CancelUpload)
 			apiFileRouter.POST("/move", file.MoveFile)
 			apiFileRouter.POST("/copy", file.CopyFile)
 			apiFileRouter.DELETE("", file.DeleteFile)
+			apiFileRouter.GET("/trash", file.GetTrashItems)
+			apiFileRouter.POST("/trash/:id/restore", file.RestoreTrashItem)
+			apiFileRouter.DELETE("/trash/:id", file.PurgeTrashItem)
+			apiFileRouter.DELETE("/trash", file.EmptyTrash)
 			apiFileRouter.POST("/mkdir", file.CreateDirectory)
 			apiFileRouter.POST("/compress", file.CompressFiles)
 			apiFileRouter.POST("/extract", file.ExtractFiles)
@@ -67,7 +129,7 @@ func LoadRoutes(r *gin.Engine) {
 		}
 
 		// 系统监控API
-		apiSysRouter := apiAuthRouter.Group("/system")
+		apiSysRouter := apiAuthRouter.Group("/system", readOnly)
 		{
 			apiSysRouter.GET("", system.GetSystemInfo)
 			apiSysRouter.GET("/cpu", system.GetCPUInfo)
@@ -75,11 +137,11 @@ func LoadRoutes(r *gin.Engine) {
 			apiSysRouter.GET("/disk", system.GetDiskInfo)
 			apiSysRouter.GET("/network", system.GetNetworkInfo)
 			apiSysRouter.GET("/processes", system.GetProcessList)
-			apiSysRouter.POST("/process/kill", system.KillProcess)
+			apiSysRouter.POST("/process/kill", operator, system.KillProcess)
 		}
 
 		// 定时任务API
-		apiCronRouter := apiAuthRouter.Group("/crontab")
+		apiCronRouter := apiAuthRouter.Group("/crontab", operator)
 		{
 			apiCronRouter.GET("", crontab.GetCrontabList)
 			apiCronRouter.POST("", crontab.CreateCrontabEntry)
@@ -89,7 +151,7 @@ func LoadRoutes(r *gin.Engine) {
 		}
 
 		// SSL证书管理API
-		apiSslRouter := apiAuthRouter.Group("/acme/ssl")
+		apiSslRouter := apiAuthRouter.Group("/acme/ssl", operator)
 		{
 			apiSslRouter.GET("", ssl.GetSSL)
 			apiSslRouter.POST("", ssl.IssueSSL)
@@ -98,7 +160,7 @@ func LoadRoutes(r *gin.Engine) {
 		}
 
 		// ACME客户端管理API
-		apiSslClientRouter := apiAuthRouter.Group("/acme/clients")
+		apiSslClientRouter := apiAuthRouter.Group("/acme/clients", operator)
 		{
 			apiSslClientRouter.GET("", ssl.GetAcmeClients)
 			apiSslClientRouter.POST("", ssl.CreateAcmeClient)
@@ -107,7 +169,7 @@ func LoadRoutes(r *gin.Engine) {
 		}
 
 		// DNS账户管理API
-		apiSslDnsRouter := apiAuthRouter.Group("/acme/dns")
+		apiSslDnsRouter := apiAuthRouter.Group("/acme/dns", operator)
 		{
 			apiSslDnsRouter.GET("", ssl.GetDnsAccounts)
 			apiSslDnsRouter.POST("", ssl.CreateDnsAccount)
@@ -116,7 +178,7 @@ func LoadRoutes(r *gin.Engine) {
 		}
 
 		// Nginx管理API
-		apiNginxRouter := apiAuthRouter.Group("/nginx")
+		apiNginxRouter := apiAuthRouter.Group("/nginx", operator)
 		{
 			apiNginxRouter.GET("/status", nginx.GetNginxStatus)
 			apiNginxRouter.GET("/config", nginx.GetNginxConfig)
@@ -133,21 +195,65 @@ func LoadRoutes(r *gin.Engine) {
 		}
 
 		// AI工具链API
-		apiAiRouter := apiAuthRouter.Group("/ai")
+		apiAiRouter := apiAuthRouter.Group("/ai", operator)
 		{
 			apiAiRouter.POST("/log", ai.AnalyzeLog)
+			apiAiRouter.POST("/log/source", ai.AnalyzeLogSource)
 			apiAiRouter.POST("/files", ai.AnalyzeFiles)
+			apiAiRouter.POST("/db", ai.DatabaseAgent)
+			apiAiRouter.POST("/nginx", ai.GenerateNginxSite)
+			apiAiRouter.POST("/diagnose", ai.DiagnoseSystem)
+			apiAiRouter.GET("/conversations", ai.GetConversations)
+			apiAiRouter.GET("/conversations/:id", ai.GetConversation)
+			apiAiRouter.DELETE("/conversations/:id", ai.DeleteConversation)
+			apiAiRouter.GET("/usage/me", ai.GetMyAIUsage)
+			// 用量统计和配额仅管理员可用
+			apiAiRouter.GET("/usage", admin, ai.GetAIUsage)
+			apiAiRouter.PUT("/quotas/:username", admin, ai.SetAIQuota)
+			apiAiRouter.GET("/approvals", ai.GetToolApprovals)
+			// 高风险调用须由管理员审批，且不能审批自己提交的调用
+			apiAiRouter.POST("/approvals/:id/approve", admin, ai.ApproveToolCall)
+			apiAiRouter.POST("/approvals/:id/reject", admin, ai.RejectToolCall)
+		}
+
+		// 数据库管理API
+		apiDatabaseRouter := apiAuthRouter.Group("/databases", operator)
+		{
+			apiDatabaseRouter.GET("", dbmgr.GetConnections)
+			apiDatabaseRouter.POST("", dbmgr.CreateConnection)
+			apiDatabaseRouter.PUT("/:id", dbmgr.UpdateConnection)
+			apiDatabaseRouter.DELETE("/:id", dbmgr.DeleteConnection)
+			apiDatabaseRouter.POST("/:id/test", dbmgr.TestConnection)
+			apiDatabaseRouter.GET("/:id/databases", dbmgr.ListDatabases)
+			apiDatabaseRouter.GET("/:id/tables", dbmgr.ListTables)
+			apiDatabaseRouter.GET("/:id/users", dbmgr.ListUsers)
+			apiDatabaseRouter.POST("/:id/query", dbmgr.ExecuteQuery)
+			apiDatabaseRouter.POST("/:id/backup", dbmgr.BackupDatabase)
+		}
+
+		// 数据库定时备份API
+		apiBackupRouter := apiAuthRouter.Group("/backups", operator)
+		{
+			apiBackupRouter.GET("/plans", backup.GetBackupPlans)
+			apiBackupRouter.POST("/plans", backup.CreateBackupPlan)
+			apiBackupRouter.PUT("/plans/:id", backup.UpdateBackupPlan)
+			apiBackupRouter.DELETE("/plans/:id", backup.DeleteBackupPlan)
+			apiBackupRouter.POST("/plans/:id/run", backup.RunBackupPlan)
+			apiBackupRouter.GET("/records", backup.GetBackupRecords)
+			apiBackupRouter.GET("/records/:id/download", backup.DownloadBackup)
+			apiBackupRouter.POST("/records/:id/restore", backup.RestoreBackup)
+			apiBackupRouter.DELETE("/records/:id", backup.DeleteBackupRecord)
 		}
 
 		// 系统设置API
-		apiSettingRouter := apiAuthRouter.Group("/setting")
+		apiSettingRouter := apiAuthRouter.Group("/setting", admin)
 		{
 			apiSettingRouter.PUT("", setting.SaveSettings)
 			apiSettingRouter.GET("", setting.GetSettings)
 		}
 
 		// 防火墙管理API
-		apiFirewallRouter := apiAuthRouter.Group("/firewall")
+		apiFirewallRouter := apiAuthRouter.Group("/firewall", operator)
 		{
 			apiFirewallRouter.GET("/status", firewall.GetFirewallStatus)
 			apiFirewallRouter.POST("/enable", firewall.EnableFirewall)
@@ -160,7 +266,7 @@ func LoadRoutes(r *gin.Engine) {
 		}
 
 		// 日志管理API
-		apiLogRouter := r.Group("/log")
+		apiLogRouter := apiAuthRouter.Group("/log", readOnly)
 		{
 			apiLogRouter.POST("/query", log.GetLogByRequestID)
 			apiLogRouter.POST("/verify", log.VerifyLogIntegrity)
@@ -168,7 +274,7 @@ func LoadRoutes(r *gin.Engine) {
 			apiLogRouter.GET("/stats", log.GetLogStats)
 		}
 		// Docker管理API
-		apiDockerRouter := r.Group("/docker")
+		apiDockerRouter := apiAuthRouter.Group("/docker", operator)
 		{
 			// 镜像管理
 			apiDockerRouter.GET("/images", docker.GetDockerImages)
@@ -182,15 +288,20 @@ func LoadRoutes(r *gin.Engine) {
 			apiDockerRouter.POST("/containers/:id/stop", docker.StopDockerContainer)
 			apiDockerRouter.DELETE("/containers/:id", docker.RemoveDockerContainer)
 
-			// 终端连接
-			apiDockerRouter.GET("/containers/:id/terminal", docker.DockerTerminal)
-
 			// 镜像源管理
 			apiDockerRouter.POST("/registry", docker.SetDockerRegistry)
 		}
+	}
 
-		// WebSocket连接（PTY终端）
-		r.GET("/ws/pty", gin.WrapH(pty.RegisterPTYHandler("/pty")))
+	// WebSocket 路由，浏览器无法设置请求头，握手时 token 额外允许通过 token 查询参数或 Sec-WebSocket-Protocol 传递
+	apiWsRouter := r.Group("/api/auth", middleware.AllowQueryToken(), middleware.JWTAuth())
+	{
+		// Docker 容器终端
+		apiWsRouter.GET("/docker/containers/:id/terminal", operator, docker.DockerTerminal)
+		// PTY 终端即服务器 shell，仅管理员可用
+		apiWsRouter.GET("/ws/pty", admin, gin.WrapH(pty.RegisterPTYHandler("/pty")))
+		// AI 分析结果流式推送，与 /ai 下的接口权限一致
+		apiWsRouter.GET("/ws/ai", operator, ai.StreamWebSocket("/ai"))
 	}
 	// 404错误处理
 	r.NoRoute(func(c *gin.Context) {
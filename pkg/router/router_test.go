@@ -0,0 +1,245 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/middleware"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testSecret = "router-test-secret"
+
+func setupRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	config.AppConfig = &config.Config{JWT: config.JWTConfig{Secret: testSecret}}
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.AuthToken{}, &models.AuditLog{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	database.DbConn = db
+
+	r := gin.New()
+	LoadRoutes(r)
+	return r
+}
+
+// roleToken 为指定角色创建会话并签发对应的 token
+func roleToken(t *testing.T, role string) string {
+	t.Helper()
+	session := models.AuthToken{TokenID: "session-" + role, Username: role, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := database.DbConn.Create(&session).Error; err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	claims := &middleware.Claims{
+		Username: role,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        session.TokenID,
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func signToken(t *testing.T, secret string, expiresAt time.Time) string {
+	t.Helper()
+	claims := &middleware.Claims{
+		Username: "admin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+// authRoutes 返回所有 /api/auth 下的路由，路径参数替换为占位值
+func authRoutes(r *gin.Engine) []gin.RouteInfo {
+	var routes []gin.RouteInfo
+	for _, route := range r.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/auth/") {
+			continue
+		}
+		parts := strings.Split(route.Path, "/")
+		for i, part := range parts {
+			if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+				parts[i] = "1"
+			}
+		}
+		route.Path = strings.Join(parts, "/")
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+func TestAuthRoutesRequireToken(t *testing.T) {
+	r := setupRouter(t)
+
+	routes := authRoutes(r)
+	if len(routes) == 0 {
+		t.Fatal("no /api/auth routes registered")
+	}
+
+	for _, route := range routes {
+		req := httptest.NewRequest(route.Method, route.Path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: expected 401, got %d", route.Method, route.Path, w.Code)
+		}
+	}
+}
+
+func TestAuthRoutesRejectInvalidToken(t *testing.T) {
+	r := setupRouter(t)
+
+	tokens := map[string]string{
+		"garbage":      "not-a-jwt",
+		"wrong secret": signToken(t, "other-secret", time.Now().Add(time.Hour)),
+		"expired":      signToken(t, testSecret, time.Now().Add(-time.Hour)),
+	}
+
+	for name, token := range tokens {
+		for _, route := range authRoutes(r) {
+			req := httptest.NewRequest(route.Method, route.Path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("%s: %s %s: expected 401, got %d", name, route.Method, route.Path, w.Code)
+			}
+		}
+	}
+}
+
+func TestPrivilegedRoutesMountedUnderAuth(t *testing.T) {
+	r := setupRouter(t)
+
+	for _, path := range []string{"/log/list", "/docker/images", "/ws/pty"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected route to be removed from root, got %d", path, w.Code)
+		}
+	}
+
+	for _, path := range []string{"/api/auth/log/list", "/api/auth/docker/images", "/api/auth/ws/pty"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("GET %s: expected 401, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestPublicRoutesDoNotRequireToken(t *testing.T) {
+	r := setupRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/public", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /api/public: expected 200, got %d", w.Code)
+	}
+}
+
+// adminRoutes 仅管理员可访问的路由
+var adminRoutes = []struct{ method, path string }{
+	{http.MethodGet, "/api/auth/users"},
+	{http.MethodPost, "/api/auth/users"},
+	{http.MethodPut, "/api/auth/users/1"},
+	{http.MethodDelete, "/api/auth/users/1"},
+	{http.MethodGet, "/api/auth/security/lockouts"},
+	{http.MethodDelete, "/api/auth/security/lockouts/1"},
+	{http.MethodGet, "/api/auth/audit"},
+	{http.MethodGet, "/api/auth/setting"},
+	{http.MethodPut, "/api/auth/setting"},
+	{http.MethodGet, "/api/auth/ws/pty"},
+	{http.MethodPost, "/api/auth/ai/approvals/1/approve"},
+	{http.MethodPost, "/api/auth/ai/approvals/1/reject"},
+	{http.MethodGet, "/api/auth/ai/usage"},
+	{http.MethodPut, "/api/auth/ai/quotas/ops"},
+}
+
+func TestAdminRoutesDenyLowerRoles(t *testing.T) {
+	r := setupRouter(t)
+
+	for _, role := range []string{models.RoleReadOnly, models.RoleOperator} {
+		token := roleToken(t, role)
+		for _, route := range adminRoutes {
+			req := httptest.NewRequest(route.method, route.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusForbidden {
+				t.Errorf("%s: %s %s: expected 403, got %d", role, route.method, route.path, w.Code)
+			}
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/users", nil)
+	req.Header.Set("Authorization", "Bearer "+roleToken(t, models.RoleAdmin))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("admin: GET /api/auth/users: expected 200, got %d", w.Code)
+	}
+}
+
+func TestOperatorRoutesDenyReadOnly(t *testing.T) {
+	r := setupRouter(t)
+	token := roleToken(t, models.RoleReadOnly)
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/api/auth/files"},
+		{http.MethodPost, "/api/auth/system/process/kill"},
+		{http.MethodGet, "/api/auth/docker/containers/1/terminal"},
+		{http.MethodPost, "/api/auth/databases/1/query"},
+		{http.MethodPost, "/api/auth/ai/db"},
+		{http.MethodPost, "/api/auth/ai/nginx"},
+		{http.MethodPost, "/api/auth/ai/diagnose"},
+		{http.MethodGet, "/api/auth/ws/ai"},
+	} {
+		req := httptest.NewRequest(route.method, route.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403, got %d", route.method, route.path, w.Code)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package setting
 import (
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
 	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"gorm.io/gorm"
 )
 
 func GetPanelSettings() (models.PanelSettings, error) {
@@ -16,7 +17,11 @@ func GetPanelSettings() (models.PanelSettings, error) {
 }
 
 func SavePanelSettings(settings models.PanelSettings) error {
-	DbConn := database.DbConn
+	return SavePanelSettingsTx(database.DbConn, settings)
+}
+
+// SavePanelSettingsTx 在指定的数据库连接（如事务）中保存面板设置
+func SavePanelSettingsTx(DbConn *gorm.DB, settings models.PanelSettings) error {
 	// 检查是否已存在设置
 	var existingSettings models.PanelSettings
 	err := DbConn.First(&existingSettings).Error
@@ -0,0 +1,188 @@
+package setup
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/setting"
+	"gorm.io/gorm"
+)
+
+// 旧版本自动创建的演示账号，初始化完成后会被删除
+const (
+	legacyDemoUsername = "demo"
+	legacyDemoPassword = "Abc123456"
+)
+
+// ErrAlreadyCompleted 面板已完成初始化
+var ErrAlreadyCompleted = errors.New("panel setup has already been completed")
+
+// ErrInvalidToken 初始化令牌错误
+var ErrInvalidToken = errors.New("invalid setup token")
+
+var (
+	required atomic.Bool
+	mu       sync.Mutex
+	// token 一次性初始化令牌，仅在启动时输出到服务器日志，防止他人抢先初始化
+	token string
+)
+
+// Options 初始化参数
+type Options struct {
+	Token    string
+	Username string
+	Password string
+	Settings *models.PanelSettings
+}
+
+// Init 根据数据库状态判断是否进入初始化模式，需在数据库连接后调用
+func Init() error {
+	needed, err := needsSetup()
+	if err != nil {
+		return err
+	}
+	required.Store(needed)
+
+	if needed {
+		if err := generateToken(); err != nil {
+			return err
+		}
+		log.Printf("面板尚未初始化，请使用初始化令牌 %s 调用 /api/public/setup 创建管理员账号", token)
+		return nil
+	}
+
+	// 已初始化但仍在使用默认密钥（如手动删除了配置文件），强制轮换
+	if isWeakSecret(config.AppConfig.JWT.Secret) {
+		log.Println("检测到默认JWT密钥，已自动生成随机密钥，所有用户需要重新登录")
+		return rotateJWTSecret()
+	}
+	return nil
+}
+
+// Required 是否处于初始化模式
+func Required() bool {
+	return required.Load()
+}
+
+// Complete 创建管理员账号、轮换JWT密钥并保存面板设置，完成后退出初始化模式
+func Complete(opts Options) (models.User, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !Required() {
+		return models.User{}, ErrAlreadyCompleted
+	}
+
+	if !validToken(opts.Token) {
+		return models.User{}, ErrInvalidToken
+	}
+
+	admin := models.User{
+		Username: opts.Username,
+		Password: opts.Password,
+		Role:     models.RoleAdmin,
+	}
+	if err := admin.HashPassword(); err != nil {
+		return models.User{}, err
+	}
+
+	// 数据库变更在同一事务中完成，JWT密钥轮换放在最后，失败时回滚事务并恢复原密钥
+	err := database.DbConn.Transaction(func(tx *gorm.DB) error {
+		// 删除旧版本遗留的默认演示账号及其会话
+		var demo models.User
+		if err := tx.Where("username = ?", legacyDemoUsername).First(&demo).Error; err == nil && demo.CheckPassword(legacyDemoPassword) == nil {
+			if err := tx.Unscoped().Delete(&demo).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("username = ?", demo.Username).Delete(&models.AuthToken{}).Error; err != nil {
+				return err
+			}
+		}
+
+		var count int64
+		tx.Model(&models.User{}).Where("username = ?", admin.Username).Count(&count)
+		if count > 0 {
+			return errors.New("username already exists")
+		}
+
+		if err := tx.Create(&admin).Error; err != nil {
+			return err
+		}
+
+		if opts.Settings != nil {
+			if err := setting.SavePanelSettingsTx(tx, *opts.Settings); err != nil {
+				return err
+			}
+		}
+
+		previous := config.AppConfig.JWT.Secret
+		if err := rotateJWTSecret(); err != nil {
+			config.AppConfig.JWT.Secret = previous
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+
+	required.Store(false)
+	token = ""
+	return admin, nil
+}
+
+// needsSetup 没有管理员，或唯一的管理员是仍使用默认密码的演示账号时需要初始化
+func needsSetup() (bool, error) {
+	var admins []models.User
+	if err := database.DbConn.Where("role = ?", models.RoleAdmin).Find(&admins).Error; err != nil {
+		return false, err
+	}
+
+	for _, admin := range admins {
+		if admin.Username == legacyDemoUsername && admin.CheckPassword(legacyDemoPassword) == nil {
+			continue
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// isWeakSecret 判断JWT密钥是否为空、默认值或过短
+func isWeakSecret(secret string) bool {
+	return secret == "" || secret == config.DefaultJWTSecret || len(secret) < 16
+}
+
+// generateToken 生成一次性初始化令牌
+func generateToken() error {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+
+	token = hex.EncodeToString(buf)
+	return nil
+}
+
+// validToken 以常量时间比较初始化令牌
+func validToken(value string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(value), []byte(token)) == 1
+}
+
+// rotateJWTSecret 生成随机JWT密钥并写入配置文件，已签发的token全部失效
+func rotateJWTSecret() error {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+
+	config.AppConfig.JWT.Secret = hex.EncodeToString(buf)
+	return config.Save()
+}
@@ -0,0 +1,111 @@
+package setup
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/config"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/database"
+	"github.com/EtaPanel-dev/EtaPanel/core/pkg/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// initSetup 在临时目录中初始化空数据库并进入初始化模式，返回日志中输出的初始化令牌
+func initSetup(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	config.AppConfig = &config.Config{JWT: config.JWTConfig{Secret: config.DefaultJWTSecret}}
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.AuthToken{}, &models.PanelSettings{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	database.DbConn = db
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	if err := Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if !Required() {
+		t.Fatal("expected setup mode with an empty database")
+	}
+
+	match := regexp.MustCompile(`初始化令牌 ([0-9a-f]{32})`).FindStringSubmatch(buf.String())
+	if match == nil {
+		t.Fatalf("expected the setup token in the log, got %q", buf.String())
+	}
+	return match[1]
+}
+
+func TestCompleteRequiresToken(t *testing.T) {
+	token := initSetup(t)
+
+	for _, value := range []string{"", "wrong"} {
+		if _, err := Complete(Options{Token: value, Username: "admin", Password: "S3cure-Passw0rd"}); err != ErrInvalidToken {
+			t.Fatalf("token %q: expected ErrInvalidToken, got %v", value, err)
+		}
+	}
+
+	admin, err := Complete(Options{Token: token, Username: "admin", Password: "S3cure-Passw0rd"})
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if admin.Role != models.RoleAdmin || Required() {
+		t.Fatalf("expected admin created and setup finished, got role %q required %v", admin.Role, Required())
+	}
+	if isWeakSecret(config.AppConfig.JWT.Secret) {
+		t.Fatal("expected the JWT secret to be rotated")
+	}
+
+	// 令牌只能使用一次
+	if _, err := Complete(Options{Token: token, Username: "other", Password: "S3cure-Passw0rd"}); err != ErrAlreadyCompleted {
+		t.Fatalf("second setup: expected ErrAlreadyCompleted, got %v", err)
+	}
+}
+
+func TestCompleteRollsBackWhenSecretRotationFails(t *testing.T) {
+	token := initSetup(t)
+
+	// 配置文件路径被目录占用，写入失败
+	if err := os.Mkdir("config.toml", 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	settings := &models.PanelSettings{Name: "EtaPanel"}
+	if _, err := Complete(Options{Token: token, Username: "admin", Password: "S3cure-Passw0rd", Settings: settings}); err == nil {
+		t.Fatal("expected an error when the config cannot be saved")
+	}
+
+	var users, panels int64
+	database.DbConn.Model(&models.User{}).Count(&users)
+	database.DbConn.Model(&models.PanelSettings{}).Count(&panels)
+	if users != 0 || panels != 0 {
+		t.Fatalf("expected the transaction rolled back, got %d users and %d settings", users, panels)
+	}
+	if config.AppConfig.JWT.Secret != config.DefaultJWTSecret {
+		t.Fatal("expected the previous JWT secret restored")
+	}
+	if !Required() {
+		t.Fatal("expected to stay in setup mode")
+	}
+
+	// 修复后可使用同一令牌重试
+	if err := os.Remove("config.toml"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err := Complete(Options{Token: token, Username: "admin", Password: "S3cure-Passw0rd"}); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+}